@@ -1483,6 +1483,8 @@ func newConsulConfig(runtimeCfg *config.RuntimeConfig, logger hclog.Logger) (*co
 
 	cfg.PeeringEnabled = runtimeCfg.PeeringEnabled
 	cfg.PeeringTestAllowPeerRegistrations = runtimeCfg.PeeringTestAllowPeerRegistrations
+	cfg.PeeringStreamMaxRetryBackoff = runtimeCfg.PeeringStreamMaxRetryBackoff
+	cfg.PeeringStreamMaxMessageSize = runtimeCfg.PeeringStreamMaxMessageSize
 
 	cfg.RequestLimitsMode = runtimeCfg.RequestLimitsMode.String()
 	cfg.RequestLimitsReadRate = runtimeCfg.RequestLimitsReadRate
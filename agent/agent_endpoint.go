@@ -488,6 +488,82 @@ func (s *HTTPHandlers) AgentService(resp http.ResponseWriter, req *http.Request)
 	return service, err
 }
 
+// AgentServiceExtensions returns the Envoy extensions configured for a proxy
+// registered with the agent, and whether they were applied to the resources
+// last sent to it over xDS.
+func (s *HTTPHandlers) AgentServiceExtensions(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	id := strings.TrimPrefix(req.URL.Path, "/v1/agent/service/extensions/")
+	if id == "" {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Missing service ID"}
+	}
+
+	var token string
+	s.parseToken(req, &token)
+
+	var entMeta acl.EnterpriseMeta
+	if err := s.parseEntMetaNoWildcard(req, &entMeta); err != nil {
+		return nil, err
+	}
+
+	authz, err := s.agent.delegate.ResolveTokenAndDefaultMeta(token, &entMeta, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sid := structs.NewServiceID(id, &entMeta)
+
+	if !s.validateRequestPartition(resp, &entMeta) {
+		return nil, nil
+	}
+
+	svc := s.agent.State.Service(sid)
+	if svc == nil {
+		return nil, HTTPError{StatusCode: http.StatusNotFound, Reason: fmt.Sprintf("unknown service ID: %s", sid.String())}
+	}
+
+	var authzContext acl.AuthorizerContext
+	svc.FillAuthzContext(&authzContext)
+	if err := authz.ToAllowAuthorizer().ServiceReadAllowed(svc.Service, &authzContext); err != nil {
+		return nil, err
+	}
+
+	if s.agent.xdsServer != nil {
+		if results, ok := s.agent.xdsServer.ExtensionResults(sid); ok {
+			exts := make([]*api.AgentServiceExtension, 0, len(results))
+			for _, result := range results {
+				ext := &api.AgentServiceExtension{
+					Name:     result.Config.EnvoyExtension.Name,
+					Required: result.Config.EnvoyExtension.Required,
+					Service:  result.Config.ServiceName,
+					Applied:  result.Err == nil,
+				}
+				if result.Err != nil {
+					ext.Error = result.Err.Error()
+				}
+				exts = append(exts, ext)
+			}
+			return exts, nil
+		}
+	}
+
+	// Envoy hasn't been configured yet, so only the extensions of the local
+	// service are known.
+	localSvc := api.CompoundServiceName{
+		Name:      svc.Proxy.DestinationServiceName,
+		Namespace: sid.NamespaceOrDefault(),
+		Partition: sid.PartitionOrEmpty(),
+	}
+	exts := make([]*api.AgentServiceExtension, 0, len(svc.Proxy.EnvoyExtensions))
+	for _, ext := range svc.Proxy.EnvoyExtensions {
+		exts = append(exts, &api.AgentServiceExtension{
+			Name:     ext.Name,
+			Required: ext.Required,
+			Service:  localSvc,
+		})
+	}
+	return exts, nil
+}
+
 func (s *HTTPHandlers) AgentChecks(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	// Fetch the ACL token, if any.
 	var token string
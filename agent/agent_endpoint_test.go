@@ -5697,6 +5697,78 @@ func TestAgent_ServiceMaintenance_ACLDeny(t *testing.T) {
 	})
 }
 
+func TestAgent_ServiceExtensions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// The extensions of the local service come from its service defaults.
+	entryReq := &structs.ConfigEntryRequest{
+		Op:         structs.ConfigEntryUpsert,
+		Datacenter: "dc1",
+		Entry: &structs.ServiceConfigEntry{
+			Kind: structs.ServiceDefaults,
+			Name: "web",
+			EnvoyExtensions: []structs.EnvoyExtension{
+				{
+					Name:     structs.BuiltinAWSLambdaExtension,
+					Required: true,
+					Arguments: map[string]interface{}{
+						"ARN":    "arn:aws:lambda:us-east-1:111111111111:function:lambda-1234",
+						"Region": "us-east-1",
+					},
+				},
+			},
+		},
+	}
+	var entryResp bool
+	require.NoError(t, a.RPC(context.Background(), "ConfigEntry.Apply", entryReq, &entryResp))
+
+	serviceReq := AddServiceRequest{
+		Service: &structs.NodeService{
+			Kind:    structs.ServiceKindConnectProxy,
+			ID:      "web-sidecar-proxy",
+			Service: "web-sidecar-proxy",
+			Port:    21000,
+			Proxy: structs.ConnectProxyConfig{
+				DestinationServiceName: "web",
+				DestinationServiceID:   "web",
+			},
+		},
+		Source: ConfigSourceLocal,
+	}
+	require.NoError(t, a.AddService(serviceReq))
+
+	t.Run("no xDS stream", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/service/extensions/web-sidecar-proxy", nil)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var exts []*api.AgentServiceExtension
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&exts))
+		require.Equal(t, []*api.AgentServiceExtension{
+			{
+				Name:     structs.BuiltinAWSLambdaExtension,
+				Required: true,
+				Service:  api.CompoundServiceName{Name: "web", Namespace: "default"},
+			},
+		}, exts)
+	})
+
+	t.Run("unknown service", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/service/extensions/api-sidecar-proxy", nil)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
 func TestAgent_NodeMaintenance_BadRequest(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -1030,6 +1030,8 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 		ReadReplica:                       boolVal(c.ReadReplica),
 		PeeringEnabled:                    boolVal(c.Peering.Enabled),
 		PeeringTestAllowPeerRegistrations: boolValWithDefault(c.Peering.TestAllowPeerRegistrations, false),
+		PeeringStreamMaxRetryBackoff:      b.durationVal("peering.stream_max_retry_backoff", c.Peering.StreamMaxRetryBackoff),
+		PeeringStreamMaxMessageSize:       intVal(c.Peering.StreamMaxMessageSize),
 		PidFile:                           stringVal(c.PidFile),
 		PrimaryDatacenter:                 primaryDatacenter,
 		PrimaryGateways:                   b.expandAllOptionalAddrs("primary_gateways", c.PrimaryGateways),
@@ -1316,6 +1318,12 @@ func (b *builder) validate(rt RuntimeConfig) error {
 	if rt.AEInterval <= 0 {
 		return fmt.Errorf("ae_interval cannot be %s. Must be positive", rt.AEInterval)
 	}
+	if rt.PeeringStreamMaxRetryBackoff <= 0 {
+		return fmt.Errorf("peering.stream_max_retry_backoff cannot be %s. Must be positive", rt.PeeringStreamMaxRetryBackoff)
+	}
+	if rt.PeeringStreamMaxMessageSize <= 0 {
+		return fmt.Errorf("peering.stream_max_message_size cannot be %d. Must be positive", rt.PeeringStreamMaxMessageSize)
+	}
 	if rt.AutopilotMaxTrailingLogs < 0 {
 		return fmt.Errorf("autopilot.max_trailing_logs cannot be %d. Must be greater than or equal to zero", rt.AutopilotMaxTrailingLogs)
 	}
@@ -915,6 +915,14 @@ type Peering struct {
 	// TestAllowPeerRegistrations controls whether CatalogRegister endpoints allow registrations for objects with `PeerName`
 	// This always gets overridden in NonUserSource()
 	TestAllowPeerRegistrations *bool `mapstructure:"test_allow_peer_registrations" json:"test_allow_peer_registrations,omitempty"`
+
+	// StreamMaxRetryBackoff is the longest a server waits between attempts to
+	// re-establish a peering stream.
+	StreamMaxRetryBackoff *string `mapstructure:"stream_max_retry_backoff" json:"stream_max_retry_backoff,omitempty"`
+
+	// StreamMaxMessageSize is the largest message, in bytes, exchanged with a
+	// peer over the peering stream.
+	StreamMaxMessageSize *int `mapstructure:"stream_max_message_size" json:"stream_max_message_size,omitempty"`
 }
 
 type XDS struct {
@@ -151,6 +151,8 @@ func DefaultSource() Source {
 
 		peering = {
 			enabled = true
+			stream_max_retry_backoff = "64s"
+			stream_max_message_size = 8388608
 		}
 	`,
 	}
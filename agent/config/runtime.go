@@ -844,6 +844,21 @@ type RuntimeConfig struct {
 	// registrations for objects with `PeerName`
 	PeeringTestAllowPeerRegistrations bool
 
+	// PeeringStreamMaxRetryBackoff is the longest a server waits between
+	// attempts to re-establish a peering stream. The wait grows exponentially
+	// with jitter until it reaches this value.
+	//
+	// hcl: peering { stream_max_retry_backoff = "duration" }
+	PeeringStreamMaxRetryBackoff time.Duration
+
+	// PeeringStreamMaxMessageSize is the largest message, in bytes, that a
+	// server sends or accepts over a peering stream it dialed. Exported
+	// service updates above this size are split across several messages for
+	// peers that advertise they can reassemble them.
+	//
+	// hcl: peering { stream_max_message_size = int }
+	PeeringStreamMaxMessageSize int
+
 	// PidFile is the file to store our PID in.
 	//
 	// hcl: pid_file = string
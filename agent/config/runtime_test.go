@@ -1840,6 +1840,26 @@ func TestLoad_IntegrationWithFlags(t *testing.T) {
 		hcl:         []string{`autopilot = { max_trailing_logs = -1 }`},
 		expectedErr: "autopilot.max_trailing_logs cannot be -1. Must be greater than or equal to zero",
 	})
+	run(t, testCase{
+		desc: "peering.stream_max_retry_backoff invalid",
+		args: []string{
+			`-datacenter=a`,
+			`-data-dir=` + dataDir,
+		},
+		json:        []string{`{ "peering": { "stream_max_retry_backoff": "-1s" } }`},
+		hcl:         []string{`peering = { stream_max_retry_backoff = "-1s" }`},
+		expectedErr: "peering.stream_max_retry_backoff cannot be -1s. Must be positive",
+	})
+	run(t, testCase{
+		desc: "peering.stream_max_message_size invalid",
+		args: []string{
+			`-datacenter=a`,
+			`-data-dir=` + dataDir,
+		},
+		json:        []string{`{ "peering": { "stream_max_message_size": 0 } }`},
+		hcl:         []string{`peering = { stream_max_message_size = 0 }`},
+		expectedErr: "peering.stream_max_message_size cannot be 0. Must be positive",
+	})
 	run(t, testCase{
 		desc:        "bind_addr cannot be empty",
 		args:        []string{`-data-dir=` + dataDir},
@@ -6168,47 +6188,49 @@ func TestLoad_FullConfig(t *testing.T) {
 			EnableSyslog:   true,
 			SyslogFacility: "hHv79Uia",
 		},
-		MaxQueryTime:            18237 * time.Second,
-		NodeID:                  types.NodeID("AsUIlw99"),
-		NodeMeta:                map[string]string{"5mgGQMBk": "mJLtVMSG", "A7ynFMJB": "0Nx6RGab"},
-		NodeName:                "otlLxGaI",
-		ReadReplica:             true,
-		PeeringEnabled:          true,
-		PidFile:                 "43xN80Km",
-		PrimaryGateways:         []string{"aej8eeZo", "roh2KahS"},
-		PrimaryGatewaysInterval: 18866 * time.Second,
-		RPCAdvertiseAddr:        tcpAddr("17.99.29.16:3757"),
-		RPCBindAddr:             tcpAddr("16.99.34.17:3757"),
-		RPCHandshakeTimeout:     1932 * time.Millisecond,
-		RPCClientTimeout:        62 * time.Second,
-		RPCHoldTimeout:          15707 * time.Second,
-		RPCProtocol:             30793,
-		RPCRateLimit:            12029.43,
-		RPCMaxBurst:             44848,
-		RPCMaxConnsPerClient:    2954,
-		RaftProtocol:            3,
-		RaftSnapshotThreshold:   16384,
-		RaftSnapshotInterval:    30 * time.Second,
-		RaftTrailingLogs:        83749,
-		ReconnectTimeoutLAN:     23739 * time.Second,
-		ReconnectTimeoutWAN:     26694 * time.Second,
-		RequestLimitsMode:       consulrate.ModePermissive,
-		RequestLimitsReadRate:   99.0,
-		RequestLimitsWriteRate:  101.0,
-		RejoinAfterLeave:        true,
-		RetryJoinIntervalLAN:    8067 * time.Second,
-		RetryJoinIntervalWAN:    28866 * time.Second,
-		RetryJoinLAN:            []string{"pbsSFY7U", "l0qLtWij", "LR3hGDoG", "MwVpZ4Up"},
-		RetryJoinMaxAttemptsLAN: 913,
-		RetryJoinMaxAttemptsWAN: 23160,
-		RetryJoinWAN:            []string{"PFsR02Ye", "rJdQIhER", "EbFSc3nA", "kwXTh623"},
-		RPCConfig:               consul.RPCConfig{EnableStreaming: true},
-		SegmentLimit:            123,
-		SerfPortLAN:             8301,
-		SerfPortWAN:             8302,
-		ServerMode:              true,
-		ServerName:              "Oerr9n1G",
-		ServerPort:              3757,
+		MaxQueryTime:                 18237 * time.Second,
+		NodeID:                       types.NodeID("AsUIlw99"),
+		NodeMeta:                     map[string]string{"5mgGQMBk": "mJLtVMSG", "A7ynFMJB": "0Nx6RGab"},
+		NodeName:                     "otlLxGaI",
+		ReadReplica:                  true,
+		PeeringEnabled:               true,
+		PeeringStreamMaxRetryBackoff: 31 * time.Second,
+		PeeringStreamMaxMessageSize:  4194304,
+		PidFile:                      "43xN80Km",
+		PrimaryGateways:              []string{"aej8eeZo", "roh2KahS"},
+		PrimaryGatewaysInterval:      18866 * time.Second,
+		RPCAdvertiseAddr:             tcpAddr("17.99.29.16:3757"),
+		RPCBindAddr:                  tcpAddr("16.99.34.17:3757"),
+		RPCHandshakeTimeout:          1932 * time.Millisecond,
+		RPCClientTimeout:             62 * time.Second,
+		RPCHoldTimeout:               15707 * time.Second,
+		RPCProtocol:                  30793,
+		RPCRateLimit:                 12029.43,
+		RPCMaxBurst:                  44848,
+		RPCMaxConnsPerClient:         2954,
+		RaftProtocol:                 3,
+		RaftSnapshotThreshold:        16384,
+		RaftSnapshotInterval:         30 * time.Second,
+		RaftTrailingLogs:             83749,
+		ReconnectTimeoutLAN:          23739 * time.Second,
+		ReconnectTimeoutWAN:          26694 * time.Second,
+		RequestLimitsMode:            consulrate.ModePermissive,
+		RequestLimitsReadRate:        99.0,
+		RequestLimitsWriteRate:       101.0,
+		RejoinAfterLeave:             true,
+		RetryJoinIntervalLAN:         8067 * time.Second,
+		RetryJoinIntervalWAN:         28866 * time.Second,
+		RetryJoinLAN:                 []string{"pbsSFY7U", "l0qLtWij", "LR3hGDoG", "MwVpZ4Up"},
+		RetryJoinMaxAttemptsLAN:      913,
+		RetryJoinMaxAttemptsWAN:      23160,
+		RetryJoinWAN:                 []string{"PFsR02Ye", "rJdQIhER", "EbFSc3nA", "kwXTh623"},
+		RPCConfig:                    consul.RPCConfig{EnableStreaming: true},
+		SegmentLimit:                 123,
+		SerfPortLAN:                  8301,
+		SerfPortWAN:                  8302,
+		ServerMode:                   true,
+		ServerName:                   "Oerr9n1G",
+		ServerPort:                   3757,
 		Services: []*structs.ServiceDefinition{
 			{
 				ID:      "wI1dzxS4",
@@ -324,6 +324,8 @@ non_voting_server = true
 partition = ""
 peering {
     enabled = true
+    stream_max_retry_backoff = "31s"
+    stream_max_message_size = 4194304
 }
 performance {
     leave_drain_time = "8265s"
@@ -130,6 +130,7 @@ func MergeServiceConfig(defaults *structs.ServiceConfigResponse, service *struct
 				Name:      ext.Name,
 				Required:  ext.Required,
 				Arguments: ext.Arguments,
+				Priority:  ext.Priority,
 			}
 		}
 		ns.Proxy.EnvoyExtensions = nsExtensions
@@ -212,3 +212,13 @@ type RootResult struct {
 type NeedsStop interface {
 	Stop()
 }
+
+// RootRotator is an optional interface that allows a CA to generate a new root
+// certificate without a change to its configuration, for operators who need
+// to rotate the root on a schedule.
+type RootRotator interface {
+	// RotateRootState returns the state to configure a new instance of the
+	// provider with so that its GenerateRoot returns a new root certificate.
+	// state is the state of the current provider, which must not be modified.
+	RotateRootState(state map[string]string) (map[string]string, error)
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"math/big"
 	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -28,6 +29,11 @@ var (
 	ErrNotInitialized = errors.New("provider not initialized")
 )
 
+// rootGenerationStateKey is the provider state key holding the number of root
+// rotations forced with RotateRootState. It is part of the provider ID so each
+// forced rotation gets a new private key and root certificate.
+const rootGenerationStateKey = "root_generation"
+
 type ConsulProvider struct {
 	Delegate ConsulProviderStateDelegate
 
@@ -38,6 +44,10 @@ type ConsulProvider struct {
 	spiffeID  *connect.SpiffeIDSigning
 	logger    hclog.Logger
 
+	// rootGeneration is the number of forced root rotations, empty until the
+	// first one.
+	rootGeneration string
+
 	// testState is only used to test Consul leader's handling of providers that
 	// need to persist state. Consul provider actually manages it's state directly
 	// in the FSM since it is highly sensitive not (root private keys) not just
@@ -74,7 +84,13 @@ func (c *ConsulProvider) Configure(cfg ProviderConfig) error {
 		return err
 	}
 	c.config = config
+	c.rootGeneration = cfg.State[rootGenerationStateKey]
 	c.id = hexStringHash(fmt.Sprintf("%s,%s,%s,%d,%v", config.PrivateKey, config.RootCert, config.PrivateKeyType, config.PrivateKeyBits, cfg.IsPrimary))
+	if c.rootGeneration != "" {
+		// Only forced rotations change the ID scheme so the IDs of existing
+		// providers are unchanged.
+		c.id = hexStringHash(fmt.Sprintf("%s,%s", c.id, c.rootGeneration))
+	}
 	c.clusterID = cfg.ClusterID
 	c.isPrimary = cfg.IsPrimary
 	c.spiffeID = connect.SpiffeIDSigningForCluster(c.clusterID)
@@ -148,7 +164,44 @@ func (c *ConsulProvider) Configure(cfg ProviderConfig) error {
 // state handling behavior without needing to plumb a full test mock provider
 // right through Consul server code.
 func (c *ConsulProvider) State() (map[string]string, error) {
-	return c.testState, nil
+	if c.rootGeneration == "" {
+		return c.testState, nil
+	}
+
+	// The root generation is persisted so the provider is configured with the
+	// same ID after a restart or a reconfiguration.
+	state := make(map[string]string, len(c.testState)+1)
+	for k, v := range c.testState {
+		state[k] = v
+	}
+	state[rootGenerationStateKey] = c.rootGeneration
+	return state, nil
+}
+
+// RotateRootState implements RootRotator by incrementing the root generation,
+// which gives the provider a new ID and therefore a new private key and root
+// certificate. The rotation is only supported if the root is generated by the
+// provider.
+func (c *ConsulProvider) RotateRootState(state map[string]string) (map[string]string, error) {
+	if c.config.PrivateKey != "" || c.config.RootCert != "" {
+		return nil, errors.New("the root can't be rotated when the private key and root certificate are provided in the configuration")
+	}
+
+	var generation uint64
+	if s := state[rootGenerationStateKey]; s != "" {
+		var err error
+		generation, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s state %q: %w", rootGenerationStateKey, s, err)
+		}
+	}
+
+	next := make(map[string]string, len(state)+1)
+	for k, v := range state {
+		next[k] = v
+	}
+	next[rootGenerationStateKey] = strconv.FormatUint(generation+1, 10)
+	return next, nil
 }
 
 // GenerateRoot initializes a new root certificate and private key if needed.
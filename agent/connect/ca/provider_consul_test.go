@@ -140,6 +140,61 @@ func TestConsulCAProvider_Bootstrap_WithCert(t *testing.T) {
 	require.NotEqualf(t, defaultNotAfter.Year(), parsed.NotAfter.Year(), "parsed cert ttl expected to be different from default root cert ttl")
 }
 
+func TestConsulCAProvider_RotateRootState(t *testing.T) {
+	t.Parallel()
+
+	conf := testConsulCAConfig()
+	delegate := newMockDelegate(t, conf)
+
+	provider := TestConsulProvider(t, delegate)
+	require.NoError(t, provider.Configure(testProviderConfig(conf)))
+	root, err := provider.GenerateRoot()
+	require.NoError(t, err)
+	state, err := provider.State()
+	require.NoError(t, err)
+
+	rotatedState, err := provider.RotateRootState(state)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"root_generation": "1"}, rotatedState)
+
+	// A provider configured with the rotated state generates a new root.
+	rotatedCfg := testProviderConfig(conf)
+	rotatedCfg.State = rotatedState
+	rotated := TestConsulProvider(t, delegate)
+	require.NoError(t, rotated.Configure(rotatedCfg))
+	rotatedRoot, err := rotated.GenerateRoot()
+	require.NoError(t, err)
+	require.NotEqual(t, root.PEM, rotatedRoot.PEM)
+
+	// The rotated state is persisted, so the provider keeps its new root when
+	// it is configured again.
+	persistedState, err := rotated.State()
+	require.NoError(t, err)
+	require.Equal(t, rotatedState, persistedState)
+
+	rotatedCfg.State = persistedState
+	restarted := TestConsulProvider(t, delegate)
+	require.NoError(t, restarted.Configure(rotatedCfg))
+	restartedRoot, err := restarted.GenerateRoot()
+	require.NoError(t, err)
+	require.Equal(t, rotatedRoot.PEM, restartedRoot.PEM)
+
+	next, err := restarted.RotateRootState(persistedState)
+	require.NoError(t, err)
+	require.Equal(t, "2", next["root_generation"])
+
+	// The root can't be rotated when it is provided in the configuration.
+	rootCA := connect.TestCA(t, nil)
+	conf.Config = map[string]interface{}{
+		"PrivateKey": rootCA.SigningKey,
+		"RootCert":   rootCA.RootCert,
+	}
+	provided := TestConsulProvider(t, delegate)
+	require.NoError(t, provided.Configure(testProviderConfig(conf)))
+	_, err = provided.RotateRootState(nil)
+	require.ErrorContains(t, err, "the root can't be rotated")
+}
+
 func TestConsulCAProvider_SignLeaf(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
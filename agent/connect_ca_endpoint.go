@@ -7,6 +7,7 @@ import (
 
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
 )
 
 // GET /v1/connect/ca/roots
@@ -104,3 +105,17 @@ func (s *HTTPHandlers) ConnectCAConfigurationSet(req *http.Request) (interface{}
 	}
 	return nil, err
 }
+
+// PUT /v1/connect/ca/rotate
+func (s *HTTPHandlers) ConnectCARotateRoot(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.CARequest
+	s.parseDC(req, &args.Datacenter)
+	s.parseToken(req, &args.Token)
+
+	var reply structs.CARoot
+	if err := s.agent.RPC(req.Context(), "ConnectCA.RotateRoot", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return api.CARotateRootResponse{RootID: reply.ID}, nil
+}
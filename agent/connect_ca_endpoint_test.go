@@ -17,6 +17,7 @@ import (
 
 	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
 )
 
 func TestConnectCARoots_empty(t *testing.T) {
@@ -69,6 +70,42 @@ func TestConnectCARoots_list(t *testing.T) {
 	}
 }
 
+func TestConnectCARotateRoot(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/connect/ca/roots", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.ConnectCARoots(resp, req)
+	require.NoError(t, err)
+	oldRootID := obj.(structs.IndexedCARoots).ActiveRootID
+
+	req, _ = http.NewRequest("PUT", "/v1/connect/ca/rotate", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.ConnectCARotateRoot(resp, req)
+	require.NoError(t, err)
+
+	value := obj.(api.CARotateRootResponse)
+	require.NotEmpty(t, value.RootID)
+	require.NotEqual(t, oldRootID, value.RootID)
+
+	req, _ = http.NewRequest("GET", "/v1/connect/ca/roots", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.ConnectCARoots(resp, req)
+	require.NoError(t, err)
+
+	roots := obj.(structs.IndexedCARoots)
+	require.Equal(t, value.RootID, roots.ActiveRootID)
+	require.Len(t, roots.Roots, 2)
+}
+
 func TestConnectCAConfig(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
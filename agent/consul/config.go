@@ -431,6 +431,14 @@ type Config struct {
 
 	PeeringTestAllowPeerRegistrations bool
 
+	// PeeringStreamMaxRetryBackoff is the longest the server waits between
+	// attempts to re-establish a peering stream.
+	PeeringStreamMaxRetryBackoff time.Duration
+
+	// PeeringStreamMaxMessageSize is the largest message, in bytes, the server
+	// replicates to a peer.
+	PeeringStreamMaxMessageSize int
+
 	// Embedded Consul Enterprise specific configuration
 	*EnterpriseConfig
 }
@@ -556,6 +564,8 @@ func DefaultConfig() *Config {
 		MaxQueryTime:             600 * time.Second,
 
 		PeeringTestAllowPeerRegistrations: false,
+		PeeringStreamMaxRetryBackoff:      64 * time.Second,
+		PeeringStreamMaxMessageSize:       8 * 1024 * 1024,
 
 		EnterpriseConfig: DefaultEnterpriseConfig(),
 	}
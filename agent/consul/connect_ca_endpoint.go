@@ -103,6 +103,37 @@ func (s *ConnectCA) ConfigurationSet(
 	return s.srv.caManager.UpdateConfiguration(args)
 }
 
+// RotateRoot forces the CA to rotate to a new root generated from its
+// current configuration, and returns the new active root.
+func (s *ConnectCA) RotateRoot(
+	args *structs.CARequest,
+	reply *structs.CARoot) error {
+	// Exit early if Connect hasn't been enabled.
+	if !s.srv.config.ConnectEnabled {
+		return ErrConnectNotEnabled
+	}
+
+	if done, err := s.srv.ForwardRPC("ConnectCA.RotateRoot", args, reply); done {
+		return err
+	}
+
+	// This action requires operator write access.
+	authz, err := s.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if err := authz.ToAllowAuthorizer().OperatorWriteAllowed(nil); err != nil {
+		return err
+	}
+
+	root, err := s.srv.caManager.RotateRoot()
+	if err != nil {
+		return err
+	}
+	*reply = *root
+	return nil
+}
+
 // Roots returns the currently trusted root certificates.
 func (s *ConnectCA) Roots(
 	args *structs.DCSpecificRequest,
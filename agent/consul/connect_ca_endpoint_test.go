@@ -548,6 +548,96 @@ func TestConnectCAConfig_TriggerRotation(t *testing.T) {
 	}
 }
 
+func TestConnectCA_RotateRoot(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	rootReq := &structs.DCSpecificRequest{
+		Datacenter: "dc1",
+	}
+	var rootList structs.IndexedCARoots
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.Roots", rootReq, &rootList))
+	require.Len(t, rootList.Roots, 1)
+	oldRoot := rootList.Roots[0]
+
+	var configBefore structs.CAConfiguration
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.ConfigurationGet", rootReq, &configBefore))
+
+	var newRoot structs.CARoot
+	args := &structs.CARequest{
+		Datacenter: "dc1",
+	}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.RotateRoot", args, &newRoot))
+	require.NotEqual(t, oldRoot.ID, newRoot.ID)
+
+	testutil.RunStep(t, "the new root is active and cross-signed by the old one", func(t *testing.T) {
+		var reply structs.IndexedCARoots
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.Roots", rootReq, &reply))
+		require.Len(t, reply.Roots, 2)
+		require.Equal(t, newRoot.ID, reply.ActiveRootID)
+
+		for _, r := range reply.Roots {
+			if r.ID == oldRoot.ID {
+				require.False(t, r.Active)
+				require.Equal(t, oldRoot.RootCert, r.RootCert)
+				continue
+			}
+			require.True(t, r.Active)
+			require.Len(t, r.IntermediateCerts, 1)
+
+			xc := testParseCert(t, r.IntermediateCerts[0])
+			require.Equal(t, testParseCert(t, oldRoot.RootCert).AuthorityKeyId, xc.AuthorityKeyId)
+		}
+	})
+
+	testutil.RunStep(t, "the provider config is unchanged", func(t *testing.T) {
+		var reply structs.CAConfiguration
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.ConfigurationGet", rootReq, &reply))
+		require.Equal(t, configBefore.Provider, reply.Provider)
+		require.Equal(t, configBefore.Config, reply.Config)
+	})
+
+	testutil.RunStep(t, "a config change doesn't undo the rotation", func(t *testing.T) {
+		var current structs.CAConfiguration
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.ConfigurationGet", rootReq, &current))
+		current.Config["LeafCertTTL"] = "50h"
+
+		args := &structs.CARequest{
+			Datacenter: "dc1",
+			Config:     &current,
+		}
+		var reply interface{}
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.ConfigurationSet", args, &reply))
+
+		var roots structs.IndexedCARoots
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.Roots", rootReq, &roots))
+		require.Len(t, roots.Roots, 2)
+		require.Equal(t, newRoot.ID, roots.ActiveRootID)
+	})
+
+	testutil.RunStep(t, "the root can be rotated again", func(t *testing.T) {
+		var reply structs.CARoot
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.RotateRoot", args, &reply))
+		require.NotEqual(t, newRoot.ID, reply.ID)
+
+		var roots structs.IndexedCARoots
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.Roots", rootReq, &roots))
+		require.Len(t, roots.Roots, 3)
+		require.Equal(t, reply.ID, roots.ActiveRootID)
+	})
+}
+
 func TestConnectCAConfig_Vault_TriggerRotation_Fails(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
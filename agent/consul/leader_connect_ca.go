@@ -862,6 +862,78 @@ func (c *CAManager) UpdateConfiguration(args *structs.CARequest) (reterr error)
 	return nil
 }
 
+// RotateRoot forces the CA provider to generate a new root certificate from
+// its current configuration and rotates to it, the same way as a configuration
+// change does: the old root cross-signs the new one when the provider
+// supports it. It returns the new active root.
+func (c *CAManager) RotateRoot() (newRoot *structs.CARoot, reterr error) {
+	if c.serverConf.Datacenter != c.serverConf.PrimaryDatacenter {
+		return nil, fmt.Errorf("the root can only be rotated in the primary datacenter")
+	}
+
+	oldState, err := c.setState(caStateReconfig, true)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if reterr == nil {
+			c.setState(caStateInitialized, false)
+		} else {
+			c.setState(oldState, false)
+		}
+	}()
+
+	state := c.delegate.State()
+	_, config, err := state.CAConfig(nil)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, fmt.Errorf("CA configuration not initialized")
+	}
+
+	oldProvider, _ := c.getCAProvider()
+	if oldProvider == nil {
+		return nil, fmt.Errorf("internal error: CA provider is nil")
+	}
+	rotator, ok := oldProvider.(ca.RootRotator)
+	if !ok {
+		return nil, fmt.Errorf("the %s CA provider does not support rotating the root without a configuration change", config.Provider)
+	}
+
+	newConfig := *config
+	newConfig.State, err = rotator.RotateRootState(config.State)
+	if err != nil {
+		return nil, fmt.Errorf("could not rotate the root: %w", err)
+	}
+
+	newProvider, err := c.newProvider(&newConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize provider: %v", err)
+	}
+	pCfg := ca.ProviderConfig{
+		ClusterID:  newConfig.ClusterID,
+		Datacenter: c.serverConf.Datacenter,
+		IsPrimary:  true,
+		RawConfig:  newConfig.Config,
+		State:      newConfig.State,
+	}
+	if err := newProvider.Configure(pCfg); err != nil {
+		return nil, fmt.Errorf("error configuring provider: %v", err)
+	}
+
+	args := &structs.CARequest{Config: &newConfig}
+	if err := c.primaryUpdateRootCA(newProvider, args, config); err != nil {
+		if err := newProvider.Cleanup(false, newConfig.Config); err != nil {
+			c.logger.Warn("failed to clean up CA provider after a failed root rotation", "provider", newProvider, "error", err)
+		}
+		return nil, err
+	}
+
+	_, root := c.getCAProvider()
+	return root, nil
+}
+
 // ValidateConfigUpdater is an optional interface that may be implemented
 // by a ca.Provider. If the provider implements this interface, the
 // ValidateConfigurationUpdate will be called when a user attempts to change the
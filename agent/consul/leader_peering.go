@@ -26,6 +26,7 @@ import (
 	"github.com/hashicorp/consul/agent/grpc-external/services/peerstream"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/logging"
 	"github.com/hashicorp/consul/proto/pbpeering"
 	"github.com/hashicorp/consul/proto/pbpeerstream"
@@ -65,8 +66,9 @@ var (
 	maxFastConnRetries = uint(5)
 	// maxFastRetryBackoff is the maximum amount of time we'll wait between retries following the fast path.
 	maxFastRetryBackoff = 8192 * time.Millisecond
-	// maxRetryBackoffPeering is the maximum number of seconds we'll wait between retries when attempting to re-establish a peering connection.
-	maxRetryBackoffPeering = 64
+	// defaultMaxRetryBackoffPeering is the maximum amount of time we'll wait between retries when attempting to
+	// re-establish a peering connection, unless the server is configured with a different maximum.
+	defaultMaxRetryBackoffPeering = 64 * time.Second
 )
 
 func (s *Server) startPeeringStreamSync(ctx context.Context) {
@@ -360,7 +362,10 @@ func (s *Server) establishStream(ctx context.Context,
 				// send keepalive pings even if there is no active streams
 				PermitWithoutStream: true,
 			}),
-			grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(8*1024*1024), grpc.MaxCallRecvMsgSize(8*1024*1024)),
+			grpc.WithDefaultCallOptions(
+				grpc.MaxCallSendMsgSize(s.config.PeeringStreamMaxMessageSize),
+				grpc.MaxCallRecvMsgSize(s.config.PeeringStreamMaxMessageSize),
+			),
 		}
 
 		logger.Trace("dialing peer", "addr", addr)
@@ -429,7 +434,7 @@ func (s *Server) establishStream(ctx context.Context,
 		case err != nil:
 			logger.Error("error managing peering stream", "error", err)
 		}
-	}, peeringRetryTimeout)
+	}, peeringRetryTimeoutWithJitter(s.config.PeeringStreamMaxRetryBackoff))
 
 	return nil
 }
@@ -691,7 +696,8 @@ func retryLoopBackoffPeering(ctx context.Context, logger hclog.Logger, loopFn fu
 // Retrying quickly is important in the case of a failed precondition error because we expect it to resolve
 // quickly. For example in the case of connecting with a follower through a load balancer, we just need to retry
 // until our request lands on a leader.
-func peeringRetryTimeout(failedAttempts uint, loopErr error) time.Duration {
+// The default backoff is capped at maxBackoff.
+func peeringRetryTimeout(failedAttempts uint, loopErr error, maxBackoff time.Duration) time.Duration {
 	if loopErr != nil && isErrCode(loopErr, codes.FailedPrecondition) {
 		// Wait a constant time for the first number of retries.
 		if failedAttempts <= maxFastConnRetries {
@@ -715,10 +721,26 @@ func peeringRetryTimeout(failedAttempts uint, loopErr error) time.Duration {
 	}
 
 	// Else we go with the default backoff from retryLoopBackoff.
-	if (1 << failedAttempts) < maxRetryBackoffPeering {
-		return (1 << failedAttempts) * time.Second
+	if failedAttempts < 32 {
+		if d := time.Duration(1<<failedAttempts) * time.Second; d < maxBackoff {
+			return d
+		}
+	}
+	return maxBackoff
+}
+
+// peeringRetryTimeoutWithJitter returns a retry timeout function following peeringRetryTimeout, with the default
+// backoff capped at maxBackoff. Each timeout is randomized to between half and all of the computed value so that
+// dialers whose streams dropped at the same time, e.g. because the accepting servers restarted, don't all
+// reconnect at once.
+func peeringRetryTimeoutWithJitter(maxBackoff time.Duration) func(failedAttempts uint, loopErr error) time.Duration {
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxRetryBackoffPeering
+	}
+	return func(failedAttempts uint, loopErr error) time.Duration {
+		timeout := peeringRetryTimeout(failedAttempts, loopErr, maxBackoff)
+		return timeout/2 + lib.RandomStagger(timeout/2)
 	}
-	return time.Duration(maxRetryBackoffPeering) * time.Second
 }
 
 // isErrCode returns true if err is a gRPC error with given error code.
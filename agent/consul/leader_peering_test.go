@@ -655,6 +655,68 @@ func TestLeader_Peering_DeferredDeletion(t *testing.T) {
 	})
 }
 
+func TestLeader_Peering_LastStreamErr(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	_, s1 := testServerWithConfig(t, func(c *Config) {
+		c.NodeName = "s1.dc1"
+		c.Datacenter = "dc1"
+		c.TLSConfig.Domain = "consul"
+	})
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	var (
+		peerID   = "cc56f0b8-3885-4e78-8d7b-614a0c45712d"
+		peerName = "my-peer-s2"
+	)
+
+	// Write an accepting peering so the leader doesn't dial it.
+	require.NoError(t, s1.fsm.State().PeeringWrite(1, &pbpeering.PeeringWriteRequest{
+		Peering: &pbpeering.Peering{
+			ID:   peerID,
+			Name: peerName,
+		},
+	}))
+
+	read := func(t *testing.T) *pbpeering.Peering {
+		resp, err := s1.peeringServer.PeeringRead(context.Background(), &pbpeering.PeeringReadRequest{Name: peerName})
+		require.NoError(t, err)
+		require.NotNil(t, resp.Peering)
+		return resp.Peering
+	}
+
+	tracker := s1.peerStreamServer.Tracker
+
+	testutil.RunStep(t, "connected peering has no error", func(t *testing.T) {
+		_, err := tracker.Connected(peerID)
+		require.NoError(t, err)
+
+		p := read(t)
+		require.Equal(t, pbpeering.PeeringState_ACTIVE, p.State)
+		require.Empty(t, p.LastStreamErr)
+	})
+
+	testutil.RunStep(t, "failing peering reports the error", func(t *testing.T) {
+		tracker.DisconnectedDueToError(peerID, "stream ended unexpectedly")
+
+		p := read(t)
+		require.Equal(t, pbpeering.PeeringState_FAILING, p.State)
+		require.Equal(t, "stream ended unexpectedly", p.LastStreamErr)
+		require.Equal(t, "stream ended unexpectedly", p.ToAPI().LastStreamErr)
+	})
+
+	testutil.RunStep(t, "reconnected peering has no error", func(t *testing.T) {
+		_, err := tracker.Connected(peerID)
+		require.NoError(t, err)
+
+		p := read(t)
+		require.Equal(t, pbpeering.PeeringState_ACTIVE, p.State)
+		require.Empty(t, p.LastStreamErr)
+	})
+}
+
 func TestLeader_Peering_RemoteInfo(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -1585,7 +1647,7 @@ func TestLeader_Peering_peeringRetryTimeout_failedPreconditionErrors(t *testing.
 	for _, c := range cases {
 		t.Run(fmt.Sprintf("failed attempts %d", c.failedAttempts), func(t *testing.T) {
 			err := grpcstatus.Error(codes.FailedPrecondition, "msg")
-			require.Equal(t, c.expDuration, peeringRetryTimeout(c.failedAttempts, err))
+			require.Equal(t, c.expDuration, peeringRetryTimeout(c.failedAttempts, err, defaultMaxRetryBackoffPeering))
 		})
 	}
 }
@@ -1613,11 +1675,55 @@ func TestLeader_Peering_peeringRetryTimeout_regularErrors(t *testing.T) {
 	for _, c := range cases {
 		t.Run(fmt.Sprintf("failed attempts %d", c.failedAttempts), func(t *testing.T) {
 			err := errors.New("error")
-			require.Equal(t, c.expDuration, peeringRetryTimeout(c.failedAttempts, err))
+			require.Equal(t, c.expDuration, peeringRetryTimeout(c.failedAttempts, err, defaultMaxRetryBackoffPeering))
 		})
 	}
 }
 
+// Test peeringRetryTimeout with a configured maximum backoff.
+func TestLeader_Peering_peeringRetryTimeout_maxBackoff(t *testing.T) {
+	err := errors.New("error")
+	require.Equal(t, 4*time.Second, peeringRetryTimeout(2, err, 10*time.Second))
+	require.Equal(t, 8*time.Second, peeringRetryTimeout(3, err, 10*time.Second))
+	require.Equal(t, 10*time.Second, peeringRetryTimeout(4, err, 10*time.Second))
+	require.Equal(t, 10*time.Second, peeringRetryTimeout(100, err, 10*time.Second))
+}
+
+// Test that the jittered timeouts of repeated stream failures grow with the number of failed attempts and never
+// exceed the maximum backoff.
+func TestLeader_Peering_peeringRetryTimeoutWithJitter(t *testing.T) {
+	const maxBackoff = 20 * time.Second
+	retryTimeout := peeringRetryTimeoutWithJitter(maxBackoff)
+	err := errors.New("stream dropped")
+
+	var prevMin time.Duration
+	for attempt := uint(1); attempt <= 40; attempt++ {
+		base := peeringRetryTimeout(attempt, err, maxBackoff)
+		require.GreaterOrEqual(t, base, prevMin, "backoff shrank at attempt %d", attempt)
+		prevMin = base
+
+		// Sample a few times since the timeout is randomized.
+		for i := 0; i < 10; i++ {
+			timeout := retryTimeout(attempt, err)
+			require.GreaterOrEqual(t, timeout, base/2, "attempt %d", attempt)
+			require.LessOrEqual(t, timeout, base, "attempt %d", attempt)
+			require.LessOrEqual(t, timeout, maxBackoff, "attempt %d", attempt)
+		}
+	}
+
+	// The lower bound of the jittered timeout for a later attempt is above the
+	// upper bound of an early one.
+	require.Greater(t, peeringRetryTimeout(5, err, maxBackoff)/2, peeringRetryTimeout(1, err, maxBackoff))
+
+	t.Run("default max", func(t *testing.T) {
+		retryTimeout := peeringRetryTimeoutWithJitter(0)
+		for i := 0; i < 10; i++ {
+			require.LessOrEqual(t, retryTimeout(100, err), defaultMaxRetryBackoffPeering)
+			require.GreaterOrEqual(t, retryTimeout(100, err), defaultMaxRetryBackoffPeering/2)
+		}
+	})
+}
+
 // This test exercises all the functionality of retryLoopBackoffPeering.
 func TestLeader_Peering_retryLoopBackoffPeering(t *testing.T) {
 	ctx := context.Background()
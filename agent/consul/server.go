@@ -766,6 +766,7 @@ func NewServer(config *Config, flat Deps, externalGRPCServer *grpc.Server, incom
 		ACLResolver:    s.ACLResolver,
 		Datacenter:     s.config.Datacenter,
 		ConnectEnabled: s.config.ConnectEnabled,
+		MaxMessageSize: s.config.PeeringStreamMaxMessageSize,
 		ForwardRPC: func(info structs.RPCInfo, fn func(*grpc.ClientConn) error) (bool, error) {
 			// Only forward the request if the dc in the request matches the server's datacenter.
 			if info.RequestDatacenter() != "" && info.RequestDatacenter() != config.Datacenter {
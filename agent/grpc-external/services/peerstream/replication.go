@@ -3,10 +3,12 @@ package peerstream
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/golang/protobuf/proto"
 	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/protobuf/encoding/protowire"
 	newproto "google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 
@@ -60,20 +62,45 @@ func makeExportedServiceListResponse(
 	}, nil
 }
 
-// makeServiceResponse handles preparing exported service instance updates to the peer cluster.
+// makeServiceResponses handles preparing exported service instance updates to the peer cluster.
 // Each cache.UpdateEvent will contain all instances for a service name.
 // If there are no instances in the event, we consider that to be a de-registration.
-func makeServiceResponse(update cache.UpdateEvent) (*pbpeerstream.ReplicationMessage_Response, error) {
+//
+// When maxSize is positive and the instances don't fit in a single replication
+// message of maxSize bytes, they are split across consecutive responses which
+// each fit, and which the peer only applies once it received all of them.
+func makeServiceResponses(update cache.UpdateEvent, maxSize int) ([]*pbpeerstream.ReplicationMessage_Response, error) {
 	serviceName := strings.TrimPrefix(update.CorrelationID, subExportedService)
 	csn, ok := update.Result.(*pbservice.IndexedCheckServiceNodes)
 	if !ok {
 		return nil, fmt.Errorf("invalid type for service response: %T", update.Result)
 	}
 
-	export := &pbpeerstream.ExportedService{
-		Nodes: csn.Nodes,
+	resp, err := makeServiceResponse(serviceName, &pbpeerstream.ExportedService{Nodes: csn.Nodes})
+	if err != nil {
+		return nil, err
+	}
+	if maxSize <= 0 || exportedServiceResponseSize(resp) <= maxSize {
+		return []*pbpeerstream.ReplicationMessage_Response{resp}, nil
 	}
 
+	chunks := chunkServiceNodes(serviceName, csn.Nodes, maxSize)
+	resps := make([]*pbpeerstream.ReplicationMessage_Response, 0, len(chunks))
+	for i, nodes := range chunks {
+		resp, err := makeServiceResponse(serviceName, &pbpeerstream.ExportedService{
+			Nodes:      nodes,
+			Chunk:      uint32(i),
+			ChunkCount: uint32(len(chunks)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		resps = append(resps, resp)
+	}
+	return resps, nil
+}
+
+func makeServiceResponse(serviceName string, export *pbpeerstream.ExportedService) (*pbpeerstream.ReplicationMessage_Response, error) {
 	any, err := anypb.New(export)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal: %w", err)
@@ -87,6 +114,47 @@ func makeServiceResponse(update cache.UpdateEvent) (*pbpeerstream.ReplicationMes
 	}, nil
 }
 
+// exportedServiceResponseSize returns the size of the replication message
+// carrying resp once it is assigned the longest possible nonce.
+func exportedServiceResponseSize(resp *pbpeerstream.ReplicationMessage_Response) int {
+	resp = proto.Clone(resp).(*pbpeerstream.ReplicationMessage_Response)
+	resp.Nonce = fmt.Sprintf("%016x", uint64(math.MaxUint64))
+	return proto.Size(makeReplicationResponse(resp))
+}
+
+// chunkServiceNodes splits nodes into chunks whose exported service responses
+// each fit in maxSize bytes. A node too large to fit on its own is put in its
+// own chunk, which the caller fails to send.
+func chunkServiceNodes(serviceName string, nodes []*pbservice.CheckServiceNode, maxSize int) [][]*pbservice.CheckServiceNode {
+	// The size of an empty chunk is computed with the largest chunk indexes.
+	// Adding the nodes can also grow the length prefixes of the exported
+	// service, the Any wrapping it, and the response by up to 4 bytes each.
+	empty, _ := makeServiceResponse(serviceName, &pbpeerstream.ExportedService{
+		Chunk:      math.MaxUint32,
+		ChunkCount: math.MaxUint32,
+	})
+	overhead := exportedServiceResponseSize(empty) + 3*4
+
+	var (
+		chunks [][]*pbservice.CheckServiceNode
+		chunk  []*pbservice.CheckServiceNode
+		size   = overhead
+	)
+	for _, node := range nodes {
+		// Each node is a length-delimited field with a one byte tag.
+		n := newproto.Size(node)
+		n += 1 + protowire.SizeVarint(uint64(n))
+
+		if len(chunk) > 0 && size+n > maxSize {
+			chunks = append(chunks, chunk)
+			chunk, size = nil, overhead
+		}
+		chunk = append(chunk, node)
+		size += n
+	}
+	return append(chunks, chunk)
+}
+
 func makeCARootsResponse(
 	update cache.UpdateEvent,
 ) (*pbpeerstream.ReplicationMessage_Response, error) {
@@ -140,6 +208,7 @@ func (s *Server) processResponse(
 	peerName string,
 	partition string,
 	mutableStatus *MutableStatus,
+	chunks exportedServiceChunks,
 	resp *pbpeerstream.ReplicationMessage_Response,
 ) (*pbpeerstream.ReplicationMessage, error) {
 	if !pbpeerstream.KnownTypeURL(resp.ResourceURL) {
@@ -173,7 +242,7 @@ func (s *Server) processResponse(
 			), err
 		}
 
-		if err := s.handleUpsert(peerName, partition, mutableStatus, resp.ResourceURL, resp.ResourceID, resp.Resource); err != nil {
+		if err := s.handleUpsert(peerName, partition, mutableStatus, chunks, resp.ResourceURL, resp.ResourceID, resp.Resource); err != nil {
 			return makeNACKReply(
 				resp.ResourceURL,
 				resp.Nonce,
@@ -204,6 +273,7 @@ func (s *Server) handleUpsert(
 	peerName string,
 	partition string,
 	mutableStatus *MutableStatus,
+	chunks exportedServiceChunks,
 	resourceURL string,
 	resourceID string,
 	resource *anypb.Any,
@@ -229,12 +299,21 @@ func (s *Server) handleUpsert(
 		sn := structs.ServiceNameFromString(resourceID)
 		sn.OverridePartition(partition)
 
-		export := &pbpeerstream.ExportedService{}
-		if err := resource.UnmarshalTo(export); err != nil {
+		chunk := &pbpeerstream.ExportedService{}
+		if err := resource.UnmarshalTo(chunk); err != nil {
 			return fmt.Errorf("failed to unmarshal resource: %w", err)
 		}
 
-		err := s.handleUpdateService(peerName, partition, sn, export)
+		export, err := chunks.add(resourceID, chunk)
+		if err != nil {
+			return fmt.Errorf("failed to reassemble update for service=%q: %w", sn.String(), err)
+		}
+		if export == nil {
+			// The remaining chunks of the update are still to be received.
+			return nil
+		}
+
+		err = s.handleUpdateService(peerName, partition, sn, export)
 		if err != nil {
 			return fmt.Errorf("did not increment imported services count for service=%q: %w", sn.String(), err)
 		}
@@ -261,6 +340,41 @@ func (s *Server) handleUpsert(
 	}
 }
 
+// exportedServiceChunks buffers the chunks of the exported service updates
+// received on a stream, keyed by service, until the last chunk of each update
+// is received.
+type exportedServiceChunks map[string]*pbpeerstream.ExportedService
+
+// add buffers chunk, received for the service with the given resource ID. It
+// returns the update made of every chunk once the last one is added, and nil
+// until then. Updates that weren't split are returned as is.
+func (c exportedServiceChunks) add(resourceID string, chunk *pbpeerstream.ExportedService) (*pbpeerstream.ExportedService, error) {
+	if chunk.ChunkCount <= 1 {
+		delete(c, resourceID)
+		return chunk, nil
+	}
+
+	// The Chunk of the pending update is the index of the next chunk expected.
+	pending := c[resourceID]
+	if chunk.Chunk == 0 {
+		pending = &pbpeerstream.ExportedService{ChunkCount: chunk.ChunkCount}
+		c[resourceID] = pending
+	}
+	if pending == nil || chunk.Chunk != pending.Chunk || chunk.ChunkCount != pending.ChunkCount {
+		delete(c, resourceID)
+		return nil, fmt.Errorf("received chunk %d of %d out of order", chunk.Chunk, chunk.ChunkCount)
+	}
+
+	pending.Nodes = append(pending.Nodes, chunk.Nodes...)
+	pending.Chunk++
+	if pending.Chunk < pending.ChunkCount {
+		return nil, nil
+	}
+
+	delete(c, resourceID)
+	return &pbpeerstream.ExportedService{Nodes: pending.Nodes}, nil
+}
+
 func (s *Server) handleUpsertExportedServiceList(
 	mutableStatus *MutableStatus,
 	peerName string,
@@ -22,6 +22,9 @@ import (
 const (
 	defaultOutgoingHeartbeatInterval = 15 * time.Second
 	defaultIncomingHeartbeatTimeout  = 2 * time.Minute
+
+	// defaultMaxMessageSize matches the receive limit of the dialing peer.
+	defaultMaxMessageSize = 8 * 1024 * 1024
 )
 
 type Server struct {
@@ -40,6 +43,13 @@ type Config struct {
 	Datacenter     string
 	ConnectEnabled bool
 
+	// MaxMessageSize is the largest replication message, in bytes, sent to or
+	// accepted from a peer. It is advertised to peers when subscribing to their
+	// resources. Exported service updates above the smaller of it and the
+	// peer's limit are split across several responses, since the peer would
+	// reject them and tear down the stream.
+	MaxMessageSize int
+
 	// outgoingHeartbeatInterval is how often we send a heartbeat.
 	outgoingHeartbeatInterval time.Duration
 
@@ -66,6 +76,9 @@ func NewServer(cfg Config) *Server {
 	if cfg.incomingHeartbeatTimeout == 0 {
 		cfg.incomingHeartbeatTimeout = defaultIncomingHeartbeatTimeout
 	}
+	if cfg.MaxMessageSize == 0 {
+		cfg.MaxMessageSize = defaultMaxMessageSize
+	}
 	return &Server{
 		Config:  cfg,
 		Tracker: NewTracker(cfg.incomingHeartbeatTimeout),
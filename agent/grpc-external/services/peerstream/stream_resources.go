@@ -393,8 +393,9 @@ func (s *Server) realHandleStream(streamReq HandleStreamRequest) error {
 	// Subscribe to all relevant resource types.
 	for _, resourceURL := range resources {
 		sub := makeReplicationRequest(&pbpeerstream.ReplicationMessage_Request{
-			ResourceURL: resourceURL,
-			PeerID:      streamReq.RemoteID,
+			ResourceURL:    resourceURL,
+			PeerID:         streamReq.RemoteID,
+			MaxMessageSize: uint32(s.MaxMessageSize),
 		})
 		if err := streamSend(sub); err != nil {
 			// TODO(peering) Test error handling in calls to Send/Recv
@@ -461,6 +462,18 @@ func (s *Server) realHandleStream(streamReq HandleStreamRequest) error {
 	// The nonce is used to correlate response/(ack|nack) pairs.
 	var nonce uint64
 
+	// Exported service updates too large for a single message are received in
+	// chunks, which are buffered until the last one is received.
+	chunks := make(exportedServiceChunks)
+
+	// maxMessageSize is the largest response sent to the peer. Exported service
+	// updates are only split below it when the peer advertised that it can
+	// reassemble them, which older peers don't.
+	var (
+		maxMessageSize = s.MaxMessageSize
+		splitUpdates   bool
+	)
+
 	// The main loop that processes sends and receives.
 	for {
 		select {
@@ -581,6 +594,18 @@ func (s *Server) realHandleStream(streamReq HandleStreamRequest) error {
 						return grpcstatus.Error(codes.InvalidArgument, "initial subscription request for a resource type must not contain an error")
 					}
 
+					if req.ResourceURL == pbpeerstream.TypeURLExportedService {
+						// The exported service updates are only sent once the peer
+						// subscribed to them, so they all use the negotiated size.
+						if req.MaxMessageSize > 0 {
+							splitUpdates = true
+							if int(req.MaxMessageSize) < maxMessageSize {
+								maxMessageSize = int(req.MaxMessageSize)
+							}
+						}
+						status.SetMaxMessageSize(maxMessageSize)
+					}
+
 					if remoteSubTracker.Subscribe(req.ResourceURL) {
 						logger.Info("subscribing to resource type", "resourceURL", req.ResourceURL)
 					}
@@ -610,7 +635,7 @@ func (s *Server) realHandleStream(streamReq HandleStreamRequest) error {
 			}
 
 			if resp := msg.GetResponse(); resp != nil {
-				reply, err := s.processResponse(streamReq.PeerName, streamReq.Partition, status, resp)
+				reply, err := s.processResponse(streamReq.PeerName, streamReq.Partition, status, chunks, resp)
 				if err != nil {
 					logger.Error("failed to persist resource", "resourceURL", resp.ResourceURL, "resourceID", resp.ResourceID)
 					status.TrackRecvError(err.Error())
@@ -653,17 +678,22 @@ func (s *Server) realHandleStream(streamReq HandleStreamRequest) error {
 			}
 
 		case update := <-subCh:
-			var resp *pbpeerstream.ReplicationMessage_Response
+			var resps []*pbpeerstream.ReplicationMessage_Response
 			switch {
 			case strings.HasPrefix(update.CorrelationID, subExportedServiceList):
-				resp, err = makeExportedServiceListResponse(status, update)
+				resp, err := makeExportedServiceListResponse(status, update)
 				if err != nil {
 					// Log the error and skip this response to avoid locking up peering due to a bad update event.
 					logger.Error("failed to create exported service list response", "error", err)
 					continue
 				}
+				resps = append(resps, resp)
 			case strings.HasPrefix(update.CorrelationID, subExportedService):
-				resp, err = makeServiceResponse(update)
+				splitSize := 0
+				if splitUpdates {
+					splitSize = maxMessageSize
+				}
+				resps, err = makeServiceResponses(update, splitSize)
 				if err != nil {
 					// Log the error and skip this response to avoid locking up peering due to a bad update event.
 					logger.Error("failed to create service response", "error", err)
@@ -671,36 +701,51 @@ func (s *Server) realHandleStream(streamReq HandleStreamRequest) error {
 				}
 
 			case update.CorrelationID == subCARoot:
-				resp, err = makeCARootsResponse(update)
+				resp, err := makeCARootsResponse(update)
 				if err != nil {
 					// Log the error and skip this response to avoid locking up peering due to a bad update event.
 					logger.Error("failed to create ca roots response", "error", err)
 					continue
 				}
+				resps = append(resps, resp)
 
 			case update.CorrelationID == subServerAddrs:
-				resp, err = makeServerAddrsResponse(update)
+				resp, err := makeServerAddrsResponse(update)
 				if err != nil {
 					logger.Error("failed to create server address response", "error", err)
 					continue
 				}
+				resps = append(resps, resp)
 
 			default:
 				logger.Warn("unrecognized update type from subscription manager: " + update.CorrelationID)
 				continue
 			}
-			if resp == nil {
-				continue
-			}
 
-			// Assign a new unique nonce to the response.
-			nonce++
-			resp.Nonce = fmt.Sprintf("%08x", nonce)
+			for _, resp := range resps {
+				if resp == nil {
+					continue
+				}
 
-			replResp := makeReplicationResponse(resp)
-			if err := streamSend(replResp); err != nil {
-				// note: govet warns of context leak but it is cleaned up in a defer
-				return fmt.Errorf("failed to push data for %q: %w", update.CorrelationID, err)
+				// Assign a new unique nonce to the response.
+				nonce++
+				resp.Nonce = fmt.Sprintf("%08x", nonce)
+
+				replResp := makeReplicationResponse(resp)
+				if size := proto.Size(replResp); size > maxMessageSize {
+					// Sending the response would make the peer close the stream and the
+					// same update would be sent again on reconnect, so we skip it and
+					// keep replicating the other resources instead.
+					err := fmt.Errorf("response for %q is %d bytes, larger than the maximum message size of %d bytes",
+						update.CorrelationID, size, maxMessageSize)
+					logger.Error("failed to send resource", "resourceID", resp.ResourceID, "error", err)
+					status.TrackSendError(err.Error())
+					continue
+				}
+				if err := streamSend(replResp); err != nil {
+					// note: govet warns of context leak but it is cleaned up in a defer
+					return fmt.Errorf("failed to push data for %q: %w", update.CorrelationID, err)
+				}
 			}
 		}
 	}
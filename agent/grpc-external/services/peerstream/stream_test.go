@@ -603,6 +603,7 @@ func TestStreamResources_Server_StreamTracker(t *testing.T) {
 
 		expect := Status{
 			Connected:        true,
+			MaxMessageSize:   defaultMaxMessageSize,
 			LastSendSuccess:  lastSendSuccess,
 			LastAck:          &lastSendAck,
 			ExportedServices: []string{},
@@ -640,6 +641,7 @@ func TestStreamResources_Server_StreamTracker(t *testing.T) {
 
 		expect := Status{
 			Connected:        true,
+			MaxMessageSize:   defaultMaxMessageSize,
 			LastSendSuccess:  lastSendSuccess,
 			LastAck:          &lastSendAck,
 			LastNack:         &lastNack,
@@ -692,6 +694,7 @@ func TestStreamResources_Server_StreamTracker(t *testing.T) {
 
 		expect := Status{
 			Connected:               true,
+			MaxMessageSize:          defaultMaxMessageSize,
 			LastSendSuccess:         lastSendSuccess,
 			LastAck:                 &lastSendAck,
 			LastNack:                &lastNack,
@@ -748,6 +751,7 @@ func TestStreamResources_Server_StreamTracker(t *testing.T) {
 
 		expect := Status{
 			Connected:               true,
+			MaxMessageSize:          defaultMaxMessageSize,
 			LastSendSuccess:         lastSendSuccess,
 			LastAck:                 &lastSendAck,
 			LastNack:                &lastNack,
@@ -778,6 +782,7 @@ func TestStreamResources_Server_StreamTracker(t *testing.T) {
 
 		expect := Status{
 			Connected:               true,
+			MaxMessageSize:          defaultMaxMessageSize,
 			LastSendSuccess:         lastSendSuccess,
 			LastAck:                 &lastSendAck,
 			LastNack:                &lastNack,
@@ -1088,6 +1093,182 @@ func TestStreamResources_Server_ServiceUpdates(t *testing.T) {
 	})
 }
 
+func TestStreamResources_Server_MaxMessageSize(t *testing.T) {
+	srv, store := newTestServer(t, nil)
+
+	var lastIdx uint64 = 1
+	p := writePeeringToBeDialed(t, store, lastIdx, "my-peering")
+	_, _ = writeInitialRootsAndCA(t, store)
+
+	// The peer accepts smaller messages than the server's own limit, so the
+	// updates are split to fit the peer's limit.
+	client := makeClientWithMaxMessageSize(t, srv, p.ID, 8*1024)
+	writeMaxMessageSizeExports(t, store, lastIdx, "my-peering")
+
+	bigSN := structs.NewServiceName("big", nil).String()
+	received := make(map[string]int)
+	var bigResps []*pbpeerstream.ReplicationMessage_Response
+	retry.Run(t, func(r *retry.R) {
+		for {
+			msg, err := client.RecvWithTimeout(100 * time.Millisecond)
+			if err == io.EOF && msg == nil {
+				break
+			}
+			require.NoError(r, err)
+			require.LessOrEqual(r, proto.Size(msg), 8*1024)
+
+			resp := msg.GetResponse()
+			if resp == nil || resp.ResourceURL != pbpeerstream.TypeURLExportedService {
+				continue
+			}
+			var export pbpeerstream.ExportedService
+			require.NoError(r, resp.Resource.UnmarshalTo(&export))
+			received[resp.ResourceID] = len(export.Nodes)
+			if resp.ResourceID == bigSN {
+				bigResps = append(bigResps, resp)
+			}
+		}
+
+		for i := 0; i < maxMessageSizeNumServices; i++ {
+			sn := structs.NewServiceName(fmt.Sprintf("svc-%d", i), nil).String()
+			require.Equal(r, 1, received[sn], "instances of %s", sn)
+		}
+
+		require.NotEmpty(r, bigResps)
+		var last pbpeerstream.ExportedService
+		require.NoError(r, bigResps[len(bigResps)-1].Resource.UnmarshalTo(&last))
+		require.Greater(r, last.ChunkCount, uint32(1))
+		require.Equal(r, last.ChunkCount-1, last.Chunk)
+	})
+
+	status, ok := srv.StreamStatus(p.ID)
+	require.True(t, ok)
+	require.True(t, status.Connected)
+	require.Equal(t, 8*1024, status.MaxMessageSize)
+	require.Empty(t, status.LastSendErrorMessage)
+
+	// Apply the chunks on an importing server, which only stores the instances
+	// of "big" once it received all of them.
+	importer, importerStore := newTestServer(t, func(c *Config) {
+		backend := c.Backend.(*testStreamBackend)
+		backend.leader = func() bool {
+			return false
+		}
+	})
+	importerPeerID := "1fabcd52-1d46-49b0-b1d8-71559aee47f5"
+	require.NoError(t, importerStore.PeeringWrite(1, &pbpeering.PeeringWriteRequest{
+		Peering: &pbpeering.Peering{
+			ID:   importerPeerID,
+			Name: "my-peering",
+		},
+	}))
+	mst, err := importer.Tracker.Connected(importerPeerID)
+	require.NoError(t, err)
+
+	chunks := make(exportedServiceChunks)
+	for _, resp := range bigResps {
+		reply, err := importer.processResponse("my-peering", acl.DefaultPartitionName, mst, chunks, resp)
+		require.NoError(t, err)
+		require.Nil(t, reply.GetRequest().Error)
+	}
+	require.Empty(t, chunks)
+
+	_, nodes, err := importerStore.CheckServiceNodes(nil, "big", acl.DefaultEnterpriseMeta(), "my-peering")
+	require.NoError(t, err)
+	require.Len(t, nodes, 200)
+}
+
+func TestStreamResources_Server_MaxMessageSize_PeerWithoutSplitUpdates(t *testing.T) {
+	srv, store := newTestServer(t, func(c *Config) {
+		c.MaxMessageSize = 8 * 1024
+	})
+
+	var lastIdx uint64 = 1
+	p := writePeeringToBeDialed(t, store, lastIdx, "my-peering")
+	_, _ = writeInitialRootsAndCA(t, store)
+
+	// The peer doesn't advertise a message size, so it can't reassemble split
+	// updates and the update of "big" is skipped instead.
+	client := makeClient(t, srv, p.ID)
+	writeMaxMessageSizeExports(t, store, lastIdx, "my-peering")
+
+	bigSN := structs.NewServiceName("big", nil).String()
+	received := make(map[string]int)
+	retry.Run(t, func(r *retry.R) {
+		for {
+			msg, err := client.RecvWithTimeout(100 * time.Millisecond)
+			if err == io.EOF && msg == nil {
+				break
+			}
+			require.NoError(r, err)
+
+			resp := msg.GetResponse()
+			if resp == nil || resp.ResourceURL != pbpeerstream.TypeURLExportedService {
+				continue
+			}
+			var export pbpeerstream.ExportedService
+			require.NoError(r, resp.Resource.UnmarshalTo(&export))
+			require.Zero(r, export.ChunkCount)
+			received[resp.ResourceID] = len(export.Nodes)
+		}
+
+		for i := 0; i < maxMessageSizeNumServices; i++ {
+			sn := structs.NewServiceName(fmt.Sprintf("svc-%d", i), nil).String()
+			require.Equal(r, 1, received[sn], "instances of %s", sn)
+		}
+	})
+	require.NotContains(t, received, bigSN)
+
+	// The stream stays up rather than being torn down by the peer.
+	status, ok := srv.StreamStatus(p.ID)
+	require.True(t, ok)
+	require.True(t, status.Connected)
+	require.Equal(t, 8*1024, status.MaxMessageSize)
+	require.Contains(t, status.LastSendErrorMessage, "larger than the maximum message size of 8192 bytes")
+}
+
+// maxMessageSizeNumServices is the number of single instance services
+// exported by writeMaxMessageSizeExports.
+const maxMessageSizeNumServices = 50
+
+// writeMaxMessageSizeExports exports a "big" service to peerName whose instances
+// don't fit in an 8KiB message, along with services with a single instance.
+func writeMaxMessageSizeExports(t *testing.T, store *state.Store, lastIdx uint64, peerName string) {
+	t.Helper()
+
+	entry := &structs.ExportedServicesConfigEntry{Name: "default"}
+	register := func(name string, instances int) {
+		for i := 0; i < instances; i++ {
+			node := &structs.Node{
+				Node:    fmt.Sprintf("%s-node-%d", name, i),
+				Address: fmt.Sprintf("10.0.%d.%d", i/250, i%250+1),
+			}
+			lastIdx++
+			require.NoError(t, store.EnsureNode(lastIdx, node))
+
+			lastIdx++
+			require.NoError(t, store.EnsureService(lastIdx, node.Node, &structs.NodeService{
+				ID:      fmt.Sprintf("%s-%d", name, i),
+				Service: name,
+				Port:    5000,
+			}))
+		}
+		entry.Services = append(entry.Services, structs.ExportedService{
+			Name:      name,
+			Consumers: []structs.ServiceConsumer{{Peer: peerName}},
+		})
+	}
+	register("big", 200)
+	for i := 0; i < maxMessageSizeNumServices; i++ {
+		register(fmt.Sprintf("svc-%d", i), 1)
+	}
+
+	require.NoError(t, entry.Normalize())
+	require.NoError(t, entry.Validate())
+	lastIdx++
+	require.NoError(t, store.EnsureConfigEntry(lastIdx, entry))
+}
+
 func TestStreamResources_Server_CARootUpdates(t *testing.T) {
 	srv, store := newTestServer(t, nil)
 
@@ -1386,6 +1567,14 @@ func TestStreamResources_Server_KeepsConnectionOpenWithHeartbeat(t *testing.T) {
 // message handshake.
 func makeClient(t *testing.T, srv *testServer, peerID string) *MockClient {
 	t.Helper()
+	return makeClientWithMaxMessageSize(t, srv, peerID, 0)
+}
+
+// makeClientWithMaxMessageSize makes a client which advertises maxMessageSize
+// when subscribing to the server's resources. Clients that leave it at zero
+// behave like peers that can't reassemble split exported service updates.
+func makeClientWithMaxMessageSize(t *testing.T, srv *testServer, peerID string, maxMessageSize uint32) *MockClient {
+	t.Helper()
 
 	client := NewMockClient(context.Background())
 
@@ -1432,8 +1621,9 @@ func makeClient(t *testing.T, srv *testServer, peerID string) *MockClient {
 		init := &pbpeerstream.ReplicationMessage{
 			Payload: &pbpeerstream.ReplicationMessage_Request_{
 				Request: &pbpeerstream.ReplicationMessage_Request{
-					PeerID:      peerID,
-					ResourceURL: resourceURL,
+					PeerID:         peerID,
+					ResourceURL:    resourceURL,
+					MaxMessageSize: maxMessageSize,
 				},
 			},
 		}
@@ -1448,7 +1638,8 @@ func makeClient(t *testing.T, srv *testServer, peerID string) *MockClient {
 					// The PeerID field is only set for the messages coming FROM
 					// the establishing side and are going to be empty from the
 					// other side.
-					PeerID: "",
+					PeerID:         "",
+					MaxMessageSize: uint32(srv.MaxMessageSize),
 				},
 			},
 		},
@@ -1459,7 +1650,8 @@ func makeClient(t *testing.T, srv *testServer, peerID string) *MockClient {
 					// The PeerID field is only set for the messages coming FROM
 					// the establishing side and are going to be empty from the
 					// other side.
-					PeerID: "",
+					PeerID:         "",
+					MaxMessageSize: uint32(srv.MaxMessageSize),
 				},
 			},
 		},
@@ -1470,7 +1662,8 @@ func makeClient(t *testing.T, srv *testServer, peerID string) *MockClient {
 					// The PeerID field is only set for the messages coming FROM
 					// the establishing side and are going to be empty from the
 					// other side.
-					PeerID: "",
+					PeerID:         "",
+					MaxMessageSize: uint32(srv.MaxMessageSize),
 				},
 			},
 		},
@@ -1618,7 +1811,7 @@ func Test_processResponse_Validation(t *testing.T) {
 	require.NoError(t, err)
 
 	run := func(t *testing.T, tc testCase) {
-		reply, err := srv.processResponse(peerName, "", mst, tc.in)
+		reply, err := srv.processResponse(peerName, "", mst, make(exportedServiceChunks), tc.in)
 		if tc.wantErr {
 			require.Error(t, err)
 		} else {
@@ -1922,6 +2115,46 @@ func expectReplEvents(t *testing.T, client *MockClient, checkFns ...func(t *test
 	}
 }
 
+func Test_exportedServiceChunks(t *testing.T) {
+	node := func(name string) *pbservice.CheckServiceNode {
+		return &pbservice.CheckServiceNode{Node: &pbservice.Node{Node: name}}
+	}
+	chunk := func(i, count uint32, nodes ...*pbservice.CheckServiceNode) *pbpeerstream.ExportedService {
+		return &pbpeerstream.ExportedService{Nodes: nodes, Chunk: i, ChunkCount: count}
+	}
+
+	chunks := make(exportedServiceChunks)
+
+	// Updates that weren't split are returned as is.
+	export, err := chunks.add("api", chunk(0, 0, node("a")))
+	require.NoError(t, err)
+	prototest.AssertDeepEqual(t, chunk(0, 0, node("a")), export)
+
+	export, err = chunks.add("api", chunk(0, 2, node("a")))
+	require.NoError(t, err)
+	require.Nil(t, export)
+
+	// Chunks of other services don't interfere.
+	export, err = chunks.add("web", chunk(0, 1, node("w")))
+	require.NoError(t, err)
+	prototest.AssertDeepEqual(t, chunk(0, 1, node("w")), export)
+
+	export, err = chunks.add("api", chunk(1, 2, node("b")))
+	require.NoError(t, err)
+	prototest.AssertDeepEqual(t, chunk(0, 0, node("a"), node("b")), export)
+	require.Empty(t, chunks)
+
+	// Chunks received out of order are dropped.
+	_, err = chunks.add("api", chunk(1, 2, node("b")))
+	require.ErrorContains(t, err, "received chunk 1 of 2 out of order")
+
+	_, err = chunks.add("api", chunk(0, 3, node("a")))
+	require.NoError(t, err)
+	_, err = chunks.add("api", chunk(2, 3, node("c")))
+	require.ErrorContains(t, err, "received chunk 2 of 3 out of order")
+	require.Empty(t, chunks)
+}
+
 func Test_processResponse_ExportedServiceUpdates(t *testing.T) {
 	srv, store := newTestServer(t, func(c *Config) {
 		backend := c.Backend.(*testStreamBackend)
@@ -1980,7 +2213,7 @@ func Test_processResponse_ExportedServiceUpdates(t *testing.T) {
 		}
 
 		// Simulate an update arriving for billing/api.
-		_, err = srv.processResponse(peerName, acl.DefaultPartitionName, mst, in)
+		_, err = srv.processResponse(peerName, acl.DefaultPartitionName, mst, make(exportedServiceChunks), in)
 		require.NoError(t, err)
 
 		if len(tc.exportedServices) > 0 {
@@ -1993,7 +2226,7 @@ func Test_processResponse_ExportedServiceUpdates(t *testing.T) {
 			}
 
 			// Simulate an update arriving for billing/api.
-			_, err = srv.processResponse(peerName, acl.DefaultPartitionName, mst, resp)
+			_, err = srv.processResponse(peerName, acl.DefaultPartitionName, mst, make(exportedServiceChunks), resp)
 			require.NoError(t, err)
 			// Test the count and contents separately to ensure the count code path is hit.
 			require.Equal(t, mst.GetImportedServicesCount(), len(tc.exportedServices))
@@ -241,6 +241,10 @@ type Status struct {
 	// LastRecvErrorMessage tracks the last error message when receiving from the stream.
 	LastRecvErrorMessage string
 
+	// MaxMessageSize is the largest replication message, in bytes, sent to the peer
+	// on the current stream. It is set once the peer subscribes to exported services.
+	MaxMessageSize int
+
 	// TODO(peering): consider keeping track of imported and exported services thru raft
 	// ImportedServices keeps track of which service names are imported for the peer
 	ImportedServices []string
@@ -333,6 +337,7 @@ func (s *MutableStatus) TrackDisconnectedGracefully() {
 	s.Connected = false
 	s.DisconnectTime = ptr(s.timeNow().UTC())
 	s.DisconnectErrorMessage = ""
+	s.MaxMessageSize = 0
 	s.mu.Unlock()
 }
 
@@ -343,6 +348,14 @@ func (s *MutableStatus) TrackDisconnectedDueToError(error string) {
 	s.Connected = false
 	s.DisconnectTime = ptr(s.timeNow().UTC())
 	s.DisconnectErrorMessage = error
+	s.MaxMessageSize = 0
+	s.mu.Unlock()
+}
+
+// SetMaxMessageSize records the largest replication message sent to the peer.
+func (s *MutableStatus) SetMaxMessageSize(size int) {
+	s.mu.Lock()
+	s.MaxMessageSize = size
 	s.mu.Unlock()
 }
 
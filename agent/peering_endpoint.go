@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -13,13 +15,30 @@ import (
 	"github.com/hashicorp/consul/proto/pbpeering"
 )
 
-// PeeringEndpoint handles GET, DELETE on v1/peering/name
+// PeeringEndpoint handles GET, DELETE on v1/peering/name and GET on
+// v1/peering/name/trust-bundle and v1/peering/name/exported-services
 func (s *HTTPHandlers) PeeringEndpoint(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	name := strings.TrimPrefix(req.URL.Path, "/v1/peering/")
 	if name == "" {
 		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Must specify a name to fetch."}
 	}
 
+	// Peer names can't contain a slash, so this never shadows a peering.
+	if strings.HasSuffix(name, "/trust-bundle") {
+		name = strings.TrimSuffix(name, "/trust-bundle")
+		if req.Method != "GET" {
+			return nil, MethodNotAllowedError{req.Method, []string{"GET"}}
+		}
+		return s.peeringTrustBundleRead(resp, req, name)
+	}
+	if strings.HasSuffix(name, "/exported-services") {
+		name = strings.TrimSuffix(name, "/exported-services")
+		if req.Method != "GET" {
+			return nil, MethodNotAllowedError{req.Method, []string{"GET"}}
+		}
+		return s.peeringExportedServices(resp, req, name)
+	}
+
 	// Switch on the method
 	switch req.Method {
 	case "GET":
@@ -62,6 +81,68 @@ func (s *HTTPHandlers) peeringRead(resp http.ResponseWriter, req *http.Request,
 	return result.Peering.ToAPI(), nil
 }
 
+// peeringTrustBundleRead fetches the trust bundle imported from the peer with
+// the given name and partition.
+func (s *HTTPHandlers) peeringTrustBundleRead(resp http.ResponseWriter, req *http.Request, name string) (interface{}, error) {
+	var entMeta acl.EnterpriseMeta
+	if err := s.parseEntMetaPartition(req, &entMeta); err != nil {
+		return nil, err
+	}
+	args := pbpeering.TrustBundleReadRequest{
+		Name:      name,
+		Partition: entMeta.PartitionOrEmpty(),
+	}
+
+	var dc string
+	options := structs.QueryOptions{}
+	s.parse(resp, req, &dc, &options)
+	ctx, err := external.ContextWithQueryOptions(req.Context(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.agent.rpcClientPeering.TrustBundleRead(ctx, &args)
+	if err != nil {
+		return nil, err
+	}
+	if result.Bundle == nil {
+		return nil, HTTPError{StatusCode: http.StatusNotFound, Reason: fmt.Sprintf("Trust bundle not found for %q", name)}
+	}
+
+	return result.Bundle.ToAPI(), nil
+}
+
+// peeringExportedServices lists the services exported to the peer with the
+// given name and partition.
+func (s *HTTPHandlers) peeringExportedServices(resp http.ResponseWriter, req *http.Request, name string) (interface{}, error) {
+	args := structs.ServiceDumpRequest{
+		PeerName: name,
+	}
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+	if err := s.parseEntMetaPartition(req, &args.EnterpriseMeta); err != nil {
+		return nil, err
+	}
+
+	var out structs.IndexedServiceList
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC(req.Context(), "Internal.ExportedServicesForPeer", &args, &out); err != nil {
+		return nil, err
+	}
+
+	// Ensure at least a zero length slice
+	result := make([]api.CompoundServiceName, 0, len(out.Services))
+	for _, svc := range out.Services {
+		result = append(result, api.CompoundServiceName{
+			Name:      svc.Name,
+			Namespace: svc.NamespaceOrEmpty(),
+			Partition: svc.PartitionOrEmpty(),
+		})
+	}
+	return result, nil
+}
+
 // PeeringList fetches all peerings in the datacenter in OSS or in a given partition in Consul Enterprise.
 func (s *HTTPHandlers) PeeringList(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	var entMeta acl.EnterpriseMeta
@@ -85,7 +166,35 @@ func (s *HTTPHandlers) PeeringList(resp http.ResponseWriter, req *http.Request)
 		return nil, err
 	}
 
-	return pbresp.ToAPI(), nil
+	list := pbresp.ToAPI()
+
+	// Optionally only return the peerings with the requested names.
+	if names := req.URL.Query()["name"]; len(names) > 0 {
+		requested := make(map[string]struct{}, len(names))
+		for _, name := range names {
+			requested[name] = struct{}{}
+		}
+		filtered := make([]*api.Peering, 0, len(names))
+		for _, p := range list {
+			if _, ok := requested[p.Name]; ok {
+				filtered = append(filtered, p)
+			}
+		}
+		list = filtered
+	}
+
+	// Optionally only return peerings in the requested state.
+	if state := req.URL.Query().Get("state"); state != "" {
+		filtered := make([]*api.Peering, 0, len(list))
+		for _, p := range list {
+			if strings.EqualFold(string(p.State), state) {
+				filtered = append(filtered, p)
+			}
+		}
+		list = filtered
+	}
+
+	return list, nil
 }
 
 // PeeringGenerateToken handles POSTs to the /v1/peering/token endpoint. The request
@@ -148,6 +257,10 @@ func (s *HTTPHandlers) PeeringEstablish(resp http.ResponseWriter, req *http.Requ
 	if args.PeeringToken == "" {
 		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "PeeringToken is required in the payload when establishing a peering."}
 	}
+	dialTimeout := apiRequest.DialTimeout.Duration()
+	if dialTimeout < 0 {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "DialTimeout must not be negative."}
+	}
 
 	var entMeta acl.EnterpriseMeta
 	if err := s.parseEntMetaPartition(req, &entMeta); err != nil {
@@ -165,8 +278,22 @@ func (s *HTTPHandlers) PeeringEstablish(resp http.ResponseWriter, req *http.Requ
 		return nil, err
 	}
 
+	// The deadline is propagated to the servers, which stop trying to reach
+	// the remote peer once it is exceeded.
+	if dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dialTimeout)
+		defer cancel()
+	}
+
 	out, err := s.agent.rpcClientPeering.Establish(ctx, args)
 	if err != nil {
+		if dialTimeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, HTTPError{
+				StatusCode: http.StatusGatewayTimeout,
+				Reason:     fmt.Sprintf("Timed out after %s establishing the peering: %v", dialTimeout, err),
+			}
+		}
 		return nil, err
 	}
 
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -15,6 +16,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/proto/pbpeering"
@@ -249,6 +251,28 @@ func TestHTTP_Peering_Establish(t *testing.T) {
 		require.Contains(t, string(body), "PeeringToken is required")
 	})
 
+	t.Run("Negative DialTimeout", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/v1/peering/establish",
+			bytes.NewReader([]byte(`{"PeerName": "peer1-usw1", "PeeringToken": "abc", "DialTimeout": "-1s"}`)))
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		body, _ := io.ReadAll(resp.Body)
+		require.Contains(t, string(body), "DialTimeout must not be negative")
+	})
+
+	t.Run("Invalid DialTimeout", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/v1/peering/establish",
+			bytes.NewReader([]byte(`{"PeerName": "peer1-usw1", "PeeringToken": "abc", "DialTimeout": "soon"}`)))
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		body, _ := io.ReadAll(resp.Body)
+		require.Contains(t, string(body), "Body decoding failed")
+	})
+
 	t.Run("Success", func(t *testing.T) {
 		a2 := NewTestAgent(t, `datacenter = "dc2"`)
 		testrpc.WaitForTestAgent(t, a2.RPC, "dc2")
@@ -288,6 +312,77 @@ func TestHTTP_Peering_Establish(t *testing.T) {
 	})
 }
 
+func TestHTTP_Peering_Establish_DialTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	a2 := NewTestAgent(t, `datacenter = "dc2"`)
+	testrpc.WaitForTestAgent(t, a2.RPC, "dc2")
+
+	// The remote servers accept connections but never answer, so without a
+	// timeout the secret exchange would hang.
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				conn.Close()
+			}
+		}()
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+
+	bodyBytes, err := json.Marshal(&pbpeering.GenerateTokenRequest{PeerName: "foo"})
+	require.NoError(t, err)
+	req, err := http.NewRequest("POST", "/v1/peering/token", bytes.NewReader(bodyBytes))
+	require.NoError(t, err)
+	resp := httptest.NewRecorder()
+	a.srv.h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code, "expected 200, got %d: %v", resp.Code, resp.Body.String())
+
+	var tokenResp pbpeering.GenerateTokenResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tokenResp))
+
+	tokenJSON, err := base64.StdEncoding.DecodeString(tokenResp.PeeringToken)
+	require.NoError(t, err)
+	var token structs.PeeringToken
+	require.NoError(t, json.Unmarshal(tokenJSON, &token))
+	token.ServerAddresses = []string{lis.Addr().String()}
+	token.ManualServerAddresses = nil
+	tokenJSON, err = json.Marshal(&token)
+	require.NoError(t, err)
+
+	b, err := json.Marshal(&api.PeeringEstablishRequest{
+		PeerName:     "zip",
+		PeeringToken: base64.StdEncoding.EncodeToString(tokenJSON),
+		DialTimeout:  api.NewReadableDuration(time.Second),
+	})
+	require.NoError(t, err)
+
+	req, err = http.NewRequest("POST", "/v1/peering/establish", bytes.NewReader(b))
+	require.NoError(t, err)
+	resp = httptest.NewRecorder()
+	start := time.Now()
+	a2.srv.h.ServeHTTP(resp, req)
+
+	require.Less(t, time.Since(start), 10*time.Second)
+	require.Equal(t, http.StatusGatewayTimeout, resp.Code, "expected 504, got %d: %v", resp.Code, resp.Body.String())
+	require.Contains(t, resp.Body.String(), "Timed out after 1s establishing the peering")
+}
+
 func TestHTTP_Peering_MethodNotAllowed(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -390,6 +485,146 @@ func TestHTTP_Peering_Read(t *testing.T) {
 	})
 }
 
+func TestHTTP_Peering_TrustBundleRead(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, name := range []string{"foo", "bar"} {
+		_, err := a.rpcClientPeering.PeeringWrite(ctx, &pbpeering.PeeringWriteRequest{
+			Peering: &pbpeering.Peering{
+				Name:                name,
+				State:               pbpeering.PeeringState_ACTIVE,
+				PeerServerName:      name + "servername",
+				PeerServerAddresses: []string{"addr1"},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	// Only foo has sent its trust bundle.
+	server, ok := a.delegate.(*consul.Server)
+	require.True(t, ok)
+	bundle := &pbpeering.PeeringTrustBundle{
+		TrustDomain: "foo.consul",
+		PeerName:    "foo",
+		RootPEMs:    []string{"root-1\n", "root-2\n"},
+	}
+	require.NoError(t, server.FSM().State().PeeringTrustBundleWrite(1000, bundle))
+
+	t.Run("return foo", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/v1/peering/foo/trust-bundle", nil)
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var apiResp api.PeeringTrustBundle
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&apiResp))
+
+		require.Equal(t, "foo", apiResp.PeerName)
+		require.Equal(t, "foo.consul", apiResp.TrustDomain)
+		require.Equal(t, bundle.RootPEMs, apiResp.RootPEMs)
+	})
+
+	t.Run("no trust bundle", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/v1/peering/bar/trust-bundle", nil)
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+		require.Equal(t, "Trust bundle not found for \"bar\"", resp.Body.String())
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		req, err := http.NewRequest("DELETE", "/v1/peering/foo/trust-bundle", nil)
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+	})
+}
+
+func TestHTTP_Peering_ExportedServices(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, name := range []string{"foo", "bar"} {
+		_, err := a.rpcClientPeering.PeeringWrite(ctx, &pbpeering.PeeringWriteRequest{
+			Peering: &pbpeering.Peering{
+				Name:                name,
+				State:               pbpeering.PeeringState_ACTIVE,
+				PeerServerName:      name + "servername",
+				PeerServerAddresses: []string{"addr1"},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	// Services are only exported to foo.
+	entry := structs.ConfigEntryRequest{
+		Op:         structs.ConfigEntryUpsert,
+		Datacenter: "dc1",
+		Entry: &structs.ExportedServicesConfigEntry{
+			Name: "default",
+			Services: []structs.ExportedService{
+				{Name: "api", Consumers: []structs.ServiceConsumer{{Peer: "foo"}}},
+				{Name: "web", Consumers: []structs.ServiceConsumer{{Peer: "foo"}}},
+			},
+		},
+	}
+	var applied bool
+	require.NoError(t, a.RPC(context.Background(), "ConfigEntry.Apply", &entry, &applied))
+	require.True(t, applied)
+
+	t.Run("return foo", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/v1/peering/foo/exported-services", nil)
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assertIndex(t, resp)
+
+		var services []api.CompoundServiceName
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&services))
+		require.ElementsMatch(t, []api.CompoundServiceName{{Name: "api"}, {Name: "web"}}, services)
+	})
+
+	t.Run("no exported services", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/v1/peering/bar/exported-services", nil)
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, "[]", resp.Body.String())
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		req, err := http.NewRequest("DELETE", "/v1/peering/foo/exported-services", nil)
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+	})
+}
+
 func TestHTTP_Peering_Delete(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -509,4 +744,33 @@ func TestHTTP_Peering_List(t *testing.T) {
 			require.Equal(t, 0, len(p.StreamStatus.ExportedServices))
 		}
 	})
+
+	t.Run("filter by state", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/v1/peerings?state=ACTIVE", nil)
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var apiResp []*api.Peering
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&apiResp))
+
+		require.Len(t, apiResp, 1)
+		require.Equal(t, "bar", apiResp[0].Name)
+		require.Equal(t, api.PeeringStateActive, apiResp[0].State)
+	})
+
+	t.Run("filter by name", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/v1/peerings?name=foo&name=missing", nil)
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var apiResp []*api.Peering
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&apiResp))
+
+		require.Len(t, apiResp, 1)
+		require.Equal(t, "foo", apiResp[0].Name)
+	})
 }
@@ -954,7 +954,7 @@ func TestConfigSnapshotTerminatingGatewayWithLambdaService(t testing.T, extraUpd
 				{
 					Name: structs.BuiltinAWSLambdaExtension,
 					Arguments: map[string]interface{}{
-						"ARN":                "lambda-arn",
+						"ARN":                "arn:aws:lambda:us-east-1:111111111111:function:lambda-1234",
 						"PayloadPassthrough": true,
 						"Region":             "us-east-1",
 					},
@@ -965,6 +965,37 @@ func TestConfigSnapshotTerminatingGatewayWithLambdaService(t testing.T, extraUpd
 	return TestConfigSnapshotTerminatingGateway(t, true, nil, updateEvents)
 }
 
+// TestConfigSnapshotTerminatingGatewayWithLambdaDestination returns a
+// terminating gateway fronting destinations, where the external-IP-HTTP
+// destination is a Lambda.
+func TestConfigSnapshotTerminatingGatewayWithLambdaDestination(t testing.T) *ConfigSnapshot {
+	externalIPHTTP := structs.NewServiceName("external-IP-HTTP", nil)
+
+	return TestConfigSnapshotTerminatingGatewayDestinations(t, true, []UpdateEvent{
+		{
+			CorrelationID: serviceConfigIDPrefix + externalIPHTTP.String(),
+			Result: &structs.ServiceConfigResponse{
+				Mode:        structs.ProxyModeTransparent,
+				ProxyConfig: map[string]interface{}{"protocol": "http"},
+				Destination: structs.DestinationConfig{
+					Addresses: []string{"192.168.0.2"},
+					Port:      80,
+				},
+				EnvoyExtensions: []structs.EnvoyExtension{
+					{
+						Name: structs.BuiltinAWSLambdaExtension,
+						Arguments: map[string]interface{}{
+							"ARN":                "arn:aws:lambda:us-east-1:111111111111:function:lambda-1234",
+							"PayloadPassthrough": true,
+							"Region":             "us-east-1",
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
 func TestConfigSnapshotTerminatingGatewayWithLambdaServiceAndServiceResolvers(t testing.T) *ConfigSnapshot {
 	web := structs.NewServiceName("web", nil)
 
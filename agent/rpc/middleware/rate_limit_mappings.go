@@ -63,6 +63,7 @@ var rpcRateLimitSpecs = map[string]rate.OperationType{
 
 	"ConnectCA.ConfigurationGet": rate.OperationTypeRead,
 	"ConnectCA.ConfigurationSet": rate.OperationTypeWrite,
+	"ConnectCA.RotateRoot":       rate.OperationTypeWrite,
 	"ConnectCA.Roots":            rate.OperationTypeRead,
 	"ConnectCA.Sign":             rate.OperationTypeWrite,
 	"ConnectCA.SignIntermediate": rate.OperationTypeWrite,
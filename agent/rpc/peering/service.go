@@ -37,6 +37,7 @@ var (
 	errPeeringTokenEmptyServerAddresses = errors.New("peering token server addresses value is empty")
 	errPeeringTokenEmptyServerName      = errors.New("peering token server name value is empty")
 	errPeeringTokenEmptyPeerID          = errors.New("peering token peer ID value is empty")
+	errPeeringTokenExpiryInPast         = errors.New("peering token expiry time must be in the future")
 )
 
 const (
@@ -48,6 +49,17 @@ const (
 	establishmentTimeout = 3 * time.Second
 )
 
+// errPeeringTokenExpired is returned when an establish request contains a
+// token that has expired.
+type errPeeringTokenExpired struct {
+	expiryTime time.Time
+}
+
+// Error implements the error interface
+func (e *errPeeringTokenExpired) Error() string {
+	return fmt.Sprintf("peering token expired at %s", e.expiryTime.Format(time.RFC3339))
+}
+
 // errPeeringInvalidServerAddress is returned when an establish request contains
 // an invalid server address.
 type errPeeringInvalidServerAddress struct {
@@ -183,6 +195,7 @@ type Store interface {
 	PeeringTrustBundleRead(ws memdb.WatchSet, q state.Query) (uint64, *pbpeering.PeeringTrustBundle, error)
 	PeeringTrustBundleList(ws memdb.WatchSet, entMeta acl.EnterpriseMeta) (uint64, []*pbpeering.PeeringTrustBundle, error)
 	TrustBundleListByService(ws memdb.WatchSet, service, dc string, entMeta acl.EnterpriseMeta) (uint64, []*pbpeering.PeeringTrustBundle, error)
+	PeeringSecretsRead(ws memdb.WatchSet, peerID string) (*pbpeering.PeeringSecrets, error)
 }
 
 var peeringNotEnabledErr = grpcstatus.Error(codes.FailedPrecondition, "peering must be enabled to use this endpoint")
@@ -210,6 +223,11 @@ func (s *Server) GenerateToken(
 		return nil, fmt.Errorf("meta tags failed validation: %w", err)
 	}
 
+	expiryTime := pbpeering.TimePtrFromProto(req.ExpiryTime)
+	if expiryTime != nil && !expiryTime.After(time.Now()) {
+		return nil, grpcstatus.Error(codes.InvalidArgument, errPeeringTokenExpiryInPast.Error())
+	}
+
 	resp := &pbpeering.GenerateTokenResponse{}
 	handled, err := s.ForwardRPC(&writeRequest, func(conn *grpc.ClientConn) error {
 		ctx := external.ForwardMetadataContext(ctx)
@@ -328,6 +346,7 @@ func (s *Server) GenerateToken(
 			Partition:  req.PartitionOrDefault(),
 			Datacenter: s.Datacenter,
 		},
+		ExpiryTime: expiryTime,
 	}
 
 	encoded, err := s.Backend.EncodeToken(&tok)
@@ -630,7 +649,10 @@ func (s *Server) PeeringRead(ctx context.Context, req *pbpeering.PeeringReadRequ
 		Value:          strings.ToLower(req.Name),
 		EnterpriseMeta: *entMeta,
 	}
-	_, peering, err := s.Backend.Store().PeeringRead(nil, q)
+	// The secrets of the peering are read with the same watch set, so that
+	// the response changes along with them.
+	ws := memdb.NewWatchSet()
+	_, peering, err := s.Backend.Store().PeeringRead(ws, q)
 	if err != nil {
 		return nil, err
 	}
@@ -638,7 +660,7 @@ func (s *Server) PeeringRead(ctx context.Context, req *pbpeering.PeeringReadRequ
 		return &pbpeering.PeeringReadResponse{Peering: nil}, nil
 	}
 
-	cp := s.reconcilePeering(peering)
+	cp := s.reconcilePeering(ws, peering)
 	return &pbpeering.PeeringReadResponse{Peering: cp}, nil
 }
 
@@ -681,7 +703,8 @@ func (s *Server) PeeringList(ctx context.Context, req *pbpeering.PeeringListRequ
 
 	defer metrics.MeasureSince([]string{"peering", "list"}, time.Now())
 
-	idx, peerings, err := s.Backend.Store().PeeringList(nil, *entMeta)
+	ws := memdb.NewWatchSet()
+	idx, peerings, err := s.Backend.Store().PeeringList(ws, *entMeta)
 	if err != nil {
 		return nil, err
 	}
@@ -689,7 +712,7 @@ func (s *Server) PeeringList(ctx context.Context, req *pbpeering.PeeringListRequ
 	// reconcile the actual peering state; need to copy over the ds for peering
 	var cPeerings []*pbpeering.Peering
 	for _, p := range peerings {
-		cp := s.reconcilePeering(p)
+		cp := s.reconcilePeering(ws, p)
 		cPeerings = append(cPeerings, cp)
 	}
 
@@ -701,22 +724,26 @@ func (s *Server) PeeringList(ctx context.Context, req *pbpeering.PeeringListRequ
 // -- PeeringState.Active if the peering is active
 // -- ImportedServicesCount and ExportedServicesCount
 // NOTE: we return a new peering with this additional data
-func (s *Server) reconcilePeering(peering *pbpeering.Peering) *pbpeering.Peering {
+func (s *Server) reconcilePeering(ws memdb.WatchSet, peering *pbpeering.Peering) *pbpeering.Peering {
 	streamState, found := s.Tracker.StreamStatus(peering.ID)
 	if !found {
 		// TODO(peering): this may be noise on non-leaders
 		s.Logger.Warn("did not find peer in stream tracker; cannot populate imported and"+
 			" exported services count or reconcile peering state", "peerID", peering.ID)
 		peering.StreamStatus = &pbpeering.StreamStatus{}
-		return peering
+		cp := copyPeering(peering)
+		s.reconcileSecretsStatus(ws, cp)
+		return cp
 	} else {
 		cp := copyPeering(peering)
+		s.reconcileSecretsStatus(ws, cp)
 
 		// reconcile pbpeering.PeeringState_Active
 		if streamState.Connected {
 			cp.State = pbpeering.PeeringState_ACTIVE
 		} else if streamState.DisconnectErrorMessage != "" {
 			cp.State = pbpeering.PeeringState_FAILING
+			cp.LastStreamErr = streamState.DisconnectErrorMessage
 		}
 
 		latest := func(tt ...*time.Time) *time.Time {
@@ -741,6 +768,7 @@ func (s *Server) reconcilePeering(peering *pbpeering.Peering) *pbpeering.Peering
 			LastHeartbeat:    pbpeering.TimePtrToProto(streamState.LastRecvHeartbeat),
 			LastReceive:      pbpeering.TimePtrToProto(lastRecv),
 			LastSend:         pbpeering.TimePtrToProto(lastSend),
+			MaxMessageSize:   uint64(streamState.MaxMessageSize),
 		}
 
 		return cp
@@ -1050,6 +1078,20 @@ func validatePeer(peering *pbpeering.Peering, shouldDial bool) error {
 	return nil
 }
 
+// reconcileSecretsStatus records which secrets back the peering. Only their
+// presence is set on the peering, the secrets are never returned. The secrets
+// are added to ws, the watch set of the query reading the peering.
+func (s *Server) reconcileSecretsStatus(ws memdb.WatchSet, peering *pbpeering.Peering) {
+	secrets, err := s.Backend.Store().PeeringSecretsRead(ws, peering.ID)
+	if err != nil {
+		s.Logger.Warn("failed to read peering secrets", "peerID", peering.ID, "error", err)
+		return
+	}
+	peering.HasEstablishmentSecret = secrets.GetEstablishment().GetSecretID() != ""
+	peering.HasActiveStreamSecret = secrets.GetStream().GetActiveSecretID() != ""
+	peering.HasPendingStreamSecret = secrets.GetStream().GetPendingSecretID() != ""
+}
+
 func copyPeering(p *pbpeering.Peering) *pbpeering.Peering {
 	var copyP pbpeering.Peering
 	proto.Merge(&copyP, p)
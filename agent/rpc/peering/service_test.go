@@ -215,6 +215,43 @@ func TestPeeringService_GenerateTokenExternalAddress(t *testing.T) {
 	require.Equal(t, []string{roots.Active().RootCert}, token.CA)
 }
 
+func TestPeeringService_GenerateTokenExpiryTime(t *testing.T) {
+	// TODO(peering): see note on newTestServer, refactor to not use this
+	s := newTestServer(t, nil)
+	client := pbpeering.NewPeeringServiceClient(s.ClientConn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	testutil.RunStep(t, "expiry in the past is rejected", func(t *testing.T) {
+		expiry := time.Now().Add(-time.Minute)
+		req := pbpeering.GenerateTokenRequest{
+			PeerName:   "peerB",
+			ExpiryTime: pbpeering.TimePtrToProto(&expiry),
+		}
+		_, err := client.GenerateToken(ctx, &req)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "peering token expiry time must be in the future")
+	})
+
+	testutil.RunStep(t, "expiry is included in the token", func(t *testing.T) {
+		expiry := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		req := pbpeering.GenerateTokenRequest{
+			PeerName:   "peerB",
+			ExpiryTime: pbpeering.TimePtrToProto(&expiry),
+		}
+		resp, err := client.GenerateToken(ctx, &req)
+		require.NoError(t, err)
+
+		tokenJSON, err := base64.StdEncoding.DecodeString(resp.PeeringToken)
+		require.NoError(t, err)
+
+		token := &structs.PeeringToken{}
+		require.NoError(t, json.Unmarshal(tokenJSON, token))
+		require.NotNil(t, token.ExpiryTime)
+		require.True(t, expiry.Equal(*token.ExpiryTime))
+	})
+}
+
 func TestPeeringService_GenerateToken_ACLEnforcement(t *testing.T) {
 	// TODO(peering): see note on newTestServer, refactor to not use this
 	s := newTestServer(t, func(conf *consul.Config) {
@@ -856,6 +893,84 @@ func TestPeeringService_Read_ACLEnforcement(t *testing.T) {
 	}
 }
 
+func TestPeeringService_Read_SecretsStatus(t *testing.T) {
+	// TODO(peering): see note on newTestServer, refactor to not use this
+	s := newTestServer(t, nil)
+	client := pbpeering.NewPeeringServiceClient(s.ClientConn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	resp, err := client.GenerateToken(ctx, &pbpeering.GenerateTokenRequest{PeerName: "peerB"})
+	require.NoError(t, err)
+
+	tokenJSON, err := base64.StdEncoding.DecodeString(resp.PeeringToken)
+	require.NoError(t, err)
+	token := &structs.PeeringToken{}
+	require.NoError(t, json.Unmarshal(tokenJSON, token))
+
+	read := func(t *testing.T) *pbpeering.Peering {
+		resp, err := client.PeeringRead(ctx, &pbpeering.PeeringReadRequest{Name: "peerB"})
+		require.NoError(t, err)
+		require.NotNil(t, resp.Peering)
+
+		list, err := client.PeeringList(ctx, &pbpeering.PeeringListRequest{})
+		require.NoError(t, err)
+		require.Len(t, list.Peerings, 1)
+		prototest.AssertDeepEqual(t, resp.Peering, list.Peerings[0])
+
+		return resp.Peering
+	}
+
+	testutil.RunStep(t, "pending peering has an establishment secret", func(t *testing.T) {
+		p := read(t)
+		require.True(t, p.HasEstablishmentSecret)
+		require.False(t, p.HasActiveStreamSecret)
+		require.False(t, p.HasPendingStreamSecret)
+
+		out, err := json.Marshal(p.ToAPI())
+		require.NoError(t, err)
+		require.NotContains(t, string(out), token.EstablishmentSecret)
+	})
+
+	pendingSecret := testUUID(t)
+	testutil.RunStep(t, "exchanged secret is pending", func(t *testing.T) {
+		require.NoError(t, s.Server.FSM().State().PeeringSecretsWrite(100, &pbpeering.SecretsWriteRequest{
+			PeerID: token.PeerID,
+			Request: &pbpeering.SecretsWriteRequest_ExchangeSecret{
+				ExchangeSecret: &pbpeering.SecretsWriteRequest_ExchangeSecretRequest{
+					EstablishmentSecret: token.EstablishmentSecret,
+					PendingStreamSecret: pendingSecret,
+				},
+			},
+		}))
+
+		p := read(t)
+		require.False(t, p.HasEstablishmentSecret)
+		require.False(t, p.HasActiveStreamSecret)
+		require.True(t, p.HasPendingStreamSecret)
+	})
+
+	testutil.RunStep(t, "promoted secret is active", func(t *testing.T) {
+		require.NoError(t, s.Server.FSM().State().PeeringSecretsWrite(101, &pbpeering.SecretsWriteRequest{
+			PeerID: token.PeerID,
+			Request: &pbpeering.SecretsWriteRequest_PromotePending{
+				PromotePending: &pbpeering.SecretsWriteRequest_PromotePendingRequest{
+					ActiveStreamSecret: pendingSecret,
+				},
+			},
+		}))
+
+		p := read(t)
+		require.False(t, p.HasEstablishmentSecret)
+		require.True(t, p.HasActiveStreamSecret)
+		require.False(t, p.HasPendingStreamSecret)
+
+		out, err := json.Marshal(p.ToAPI())
+		require.NoError(t, err)
+		require.NotContains(t, string(out), pendingSecret)
+	})
+}
+
 func TestPeeringService_Delete(t *testing.T) {
 	tt := map[string]pbpeering.PeeringState{
 		"active peering":     pbpeering.PeeringState_ACTIVE,
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"time"
 
 	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/structs"
@@ -57,5 +58,9 @@ func validatePeeringToken(tok *structs.PeeringToken) error {
 		return errPeeringTokenEmptyPeerID
 	}
 
+	if tok.ExpiryTime != nil && !tok.ExpiryTime.After(time.Now()) {
+		return &errPeeringTokenExpired{*tok.ExpiryTime}
+	}
+
 	return nil
 }
@@ -3,6 +3,7 @@ package peering
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -10,6 +11,9 @@ import (
 )
 
 func TestValidatePeeringToken(t *testing.T) {
+	expiredTime := time.Now().Add(-time.Minute)
+	unexpiredTime := time.Now().Add(time.Hour)
+
 	type testCase struct {
 		name    string
 		token   *structs.PeeringToken
@@ -89,6 +93,27 @@ func TestValidatePeeringToken(t *testing.T) {
 				PeerID:          validPeerID,
 			},
 		},
+		{
+			name: "expired token",
+			token: &structs.PeeringToken{
+				CA:              []string{validCA},
+				ServerAddresses: []string{validAddress},
+				ServerName:      validServerName,
+				PeerID:          validPeerID,
+				ExpiryTime:      &expiredTime,
+			},
+			wantErr: &errPeeringTokenExpired{expiredTime},
+		},
+		{
+			name: "unexpired token",
+			token: &structs.PeeringToken{
+				CA:              []string{validCA},
+				ServerAddresses: []string{validAddress},
+				ServerName:      validServerName,
+				PeerID:          validPeerID,
+				ExpiryTime:      &unexpiredTime,
+			},
+		},
 		{
 			name: "valid token with hostname address",
 			token: &structs.PeeringToken{
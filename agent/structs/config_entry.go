@@ -57,7 +57,9 @@ var AllConfigEntryKinds = []string{
 }
 
 const (
-	BuiltinAWSLambdaExtension string = "builtin/aws/lambda"
+	BuiltinAWSLambdaExtension   string = "builtin/aws/lambda"
+	BuiltinGCPCloudRunExtension string = "builtin/cloudrun"
+	BuiltinLuaExtension         string = "builtin/lua"
 )
 
 // ConfigEntry is the interface for centralized configuration stored in Raft.
@@ -296,6 +298,9 @@ type EnvoyExtension struct {
 	Name      string
 	Required  bool
 	Arguments map[string]interface{} `bexpr:"-"`
+	// Priority orders the extensions patching the resources of a proxy, lower
+	// priorities being applied first.
+	Priority int `json:",omitempty"`
 }
 type EnvoyExtensions []EnvoyExtension
 
@@ -306,6 +311,7 @@ func (es EnvoyExtensions) ToAPI() []api.EnvoyExtension {
 			Name:      e.Name,
 			Required:  e.Required,
 			Arguments: e.Arguments,
+			Priority:  e.Priority,
 		}
 	}
 	return extensions
@@ -313,7 +319,9 @@ func (es EnvoyExtensions) ToAPI() []api.EnvoyExtension {
 
 func builtInExtension(name string) bool {
 	extensions := map[string]struct{}{
-		BuiltinAWSLambdaExtension: {},
+		BuiltinAWSLambdaExtension:   {},
+		BuiltinGCPCloudRunExtension: {},
+		BuiltinLuaExtension:         {},
 	}
 
 	_, ok := extensions[name]
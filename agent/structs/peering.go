@@ -1,5 +1,7 @@
 package structs
 
+import "time"
+
 // PeeringToken identifies a peer in order for a connection to be established.
 type PeeringToken struct {
 	CA                    []string
@@ -9,6 +11,7 @@ type PeeringToken struct {
 	PeerID                string
 	EstablishmentSecret   string
 	Remote                PeeringTokenRemote
+	ExpiryTime            *time.Time `json:",omitempty"`
 }
 
 type PeeringTokenRemote struct {
@@ -27,6 +27,7 @@ import (
 	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/xds/xdscommon"
 	"github.com/hashicorp/consul/proto/pbpeering"
 )
 
@@ -326,17 +327,7 @@ func makeMTLSTransportSocket(cfgSnap *proxycfg.ConfigSnapshot, uid proxycfg.Upst
 }
 
 func clusterNameForDestination(cfgSnap *proxycfg.ConfigSnapshot, name string, address string, namespace string, partition string) string {
-	name = destinationSpecificServiceName(name, address)
-	sni := connect.ServiceSNI(name, "", namespace, partition, cfgSnap.Datacenter, cfgSnap.Roots.TrustDomain)
-
-	// Prefixed with destination to distinguish from non-passthrough clusters for the same upstream.
-	return "destination." + sni
-}
-
-func destinationSpecificServiceName(name string, address string) string {
-	address = strings.ReplaceAll(address, ":", "-")
-	address = strings.ReplaceAll(address, ".", "-")
-	return fmt.Sprintf("%s.%s", address, name)
+	return xdscommon.DestinationSNI(name, address, namespace, partition, cfgSnap.Datacenter, cfgSnap.Roots.TrustDomain)
 }
 
 // clustersFromSnapshotMeshGateway returns the xDS API representation of the "clusters"
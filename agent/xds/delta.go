@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -25,8 +26,10 @@ import (
 	external "github.com/hashicorp/consul/agent/grpc-external"
 	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/xds/luaplugin"
 	"github.com/hashicorp/consul/agent/xds/serverlessplugin"
 	"github.com/hashicorp/consul/agent/xds/xdscommon"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/logging"
 )
 
@@ -107,6 +110,9 @@ func (s *Server) processDelta(stream ADSDeltaStream, reqCh <-chan *envoy_discove
 
 		streamStartTime = time.Now()
 		streamStartOnce sync.Once
+
+		// extensionStream identifies this stream's extension results.
+		extensionStream = s.extensionStatuses.newStream()
 	)
 
 	var (
@@ -251,17 +257,11 @@ func (s *Server) processDelta(stream ADSDeltaStream, reqCh <-chan *envoy_discove
 				s.ResourceMapMutateFn(newResourceMap)
 			}
 
-			cfgs := xdscommon.GetExtensionConfigurations(cfgSnap)
-			for _, extensions := range cfgs {
-				for _, ext := range extensions {
-					switch ext.EnvoyExtension.Name {
-					case structs.BuiltinAWSLambdaExtension:
-						newResourceMap, err = serverlessplugin.Extend(newResourceMap, ext)
-						if err != nil && ext.EnvoyExtension.Required {
-							return status.Errorf(codes.Unavailable, "failed to patch xDS resources in the serverless plugin: %v", err)
-						}
-					}
-				}
+			chain := extensionChain(generator.Logger, cfgSnap)
+			newResourceMap, err = chain.Apply(newResourceMap)
+			s.extensionStatuses.set(proxyID, extensionStream, chain.Results())
+			if err != nil {
+				return status.Errorf(codes.Unavailable, "failed to patch xDS resources with extensions: %v", err)
 			}
 
 			if err := populateChildIndexMap(newResourceMap); err != nil {
@@ -312,6 +312,7 @@ func (s *Server) processDelta(stream ADSDeltaStream, reqCh <-chan *envoy_discove
 			// here since we can't start watching until we get to this state in the
 			// state machine.
 			defer watchCancel()
+			defer s.extensionStatuses.delete(proxyID, extensionStream)
 
 			generator.Logger = generator.Logger.With("service_id", proxyID.String()) // enhance future logs
 
@@ -983,3 +984,40 @@ func hashResource(res proto.Message) (string, error) {
 
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
+
+// extensionChain returns the chain of the extensions configured for the
+// proxy, which log with the given logger. The services are visited in a stable
+// order so that extensions of the same priority are always applied in the same
+// order.
+func extensionChain(logger hclog.Logger, cfgSnap *proxycfg.ConfigSnapshot) *xdscommon.ExtensionChain {
+	cfgs := xdscommon.GetExtensionConfigurations(cfgSnap)
+
+	svcs := make([]api.CompoundServiceName, 0, len(cfgs))
+	for svc := range cfgs {
+		svcs = append(svcs, svc)
+	}
+	sort.Slice(svcs, func(i, j int) bool {
+		a, b := svcs[i], svcs[j]
+		if a.Partition != b.Partition {
+			return a.Partition < b.Partition
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	chain := &xdscommon.ExtensionChain{}
+	for _, svc := range svcs {
+		for _, ext := range cfgs[svc] {
+			ext.Logger = logger
+			switch ext.EnvoyExtension.Name {
+			case structs.BuiltinAWSLambdaExtension, structs.BuiltinGCPCloudRunExtension:
+				chain.Add(ext, serverlessplugin.Extend)
+			case structs.BuiltinLuaExtension:
+				chain.Add(ext, luaplugin.Extend)
+			}
+		}
+	}
+	return chain
+}
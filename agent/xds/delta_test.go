@@ -7,7 +7,10 @@ import (
 	"testing"
 	"time"
 
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_lua_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
 	envoy_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	envoy_resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/require"
@@ -1468,6 +1471,142 @@ func TestServer_DeltaAggregatedResources_v3_StreamDrained(t *testing.T) {
 
 }
 
+func TestServer_DeltaAggregatedResources_v3_ExtensionResults(t *testing.T) {
+	aclResolve := func(id string) (acl.Authorizer, error) { return acl.ManageAll(), nil }
+	scenario := newTestServerDeltaScenario(t, aclResolve, "web-sidecar-proxy", "", 0, nil)
+	server, mgr, errCh, envoy := scenario.server, scenario.mgr, scenario.errCh, scenario.envoy
+
+	sid := structs.NewServiceID("web-sidecar-proxy", nil)
+
+	mgr.RegisterProxy(t, sid)
+
+	testutil.RunStep(t, "no results before the first snapshot", func(t *testing.T) {
+		envoy.SendDeltaReq(t, xdscommon.ClusterType, nil)
+		assertDeltaChanBlocked(t, envoy.deltaStream.sendCh)
+
+		_, ok := server.ExtensionResults(sid)
+		require.False(t, ok)
+	})
+
+	testutil.RunStep(t, "results of the applied extensions", func(t *testing.T) {
+		// The second extension has an invalid ARN, and isn't required so the
+		// resources are still sent.
+		snap := newTestSnapshot(t, nil, "http", &structs.ServiceConfigEntry{
+			Kind:     structs.ServiceDefaults,
+			Name:     "db",
+			Protocol: "http",
+			EnvoyExtensions: []structs.EnvoyExtension{
+				{
+					Name: structs.BuiltinAWSLambdaExtension,
+					Arguments: map[string]interface{}{
+						"ARN":    "arn:aws:lambda:us-east-1:111111111111:function:lambda-1234",
+						"Region": "us-east-1",
+					},
+				},
+				{
+					Name: structs.BuiltinAWSLambdaExtension,
+					Arguments: map[string]interface{}{
+						"ARN":    "not-an-arn",
+						"Region": "us-east-1",
+					},
+				},
+			},
+		})
+		mgr.DeliverConfig(t, sid, snap)
+
+		select {
+		case <-envoy.deltaStream.sendCh:
+		case <-time.After(50 * time.Millisecond):
+			t.Fatalf("no response received after 50ms")
+		}
+
+		results, ok := server.ExtensionResults(sid)
+		require.True(t, ok)
+		require.Len(t, results, 2)
+		for _, result := range results {
+			require.Equal(t, "db", result.Config.ServiceName.Name)
+			require.Equal(t, structs.BuiltinAWSLambdaExtension, result.Config.EnvoyExtension.Name)
+		}
+		require.NoError(t, results[0].Err)
+		require.Error(t, results[1].Err)
+	})
+
+	testutil.RunStep(t, "results are dropped with the stream", func(t *testing.T) {
+		envoy.Close()
+		select {
+		case err := <-errCh:
+			require.NoError(t, err)
+		case <-time.After(50 * time.Millisecond):
+			t.Fatalf("timed out waiting for handler to finish")
+		}
+
+		_, ok := server.ExtensionResults(sid)
+		require.False(t, ok)
+	})
+}
+
+func TestExtensionChain_Priority(t *testing.T) {
+	// The extensions are listed in the reverse order of their priorities.
+	snap := proxycfg.TestConfigSnapshotDiscoveryChain(t, "default", nil, nil, &structs.ServiceConfigEntry{
+		Kind:     structs.ServiceDefaults,
+		Name:     "db",
+		Protocol: "http",
+		EnvoyExtensions: []structs.EnvoyExtension{
+			{
+				Name:      structs.BuiltinLuaExtension,
+				Arguments: map[string]interface{}{"Script": "-- second"},
+				Priority:  20,
+			},
+			{
+				Name:      structs.BuiltinLuaExtension,
+				Arguments: map[string]interface{}{"Script": "-- first"},
+				Priority:  10,
+			},
+		},
+	})
+
+	g := newResourceGenerator(testutil.Logger(t), nil, false)
+	res, err := g.allResourcesFromSnapshot(snap)
+	require.NoError(t, err)
+
+	chain := extensionChain(g.Logger, snap)
+	require.Equal(t, 2, chain.Len())
+	resources, err := chain.Apply(indexResources(g.Logger, res))
+	require.NoError(t, err)
+
+	results := chain.Results()
+	require.Len(t, results, 2)
+	require.Equal(t, 10, results[0].Config.Priority)
+	require.Equal(t, 20, results[1].Config.Priority)
+
+	// Each Lua filter is inserted right before the router, so the filter of
+	// the extension applied first comes first.
+	var scripts []string
+	for name, msg := range resources.Index[xdscommon.ListenerType] {
+		if !strings.HasPrefix(name, "db:") {
+			continue
+		}
+		listener := msg.(*envoy_listener_v3.Listener)
+		for _, chain := range listener.FilterChains {
+			for _, filter := range chain.Filters {
+				hcm := envoy_resource_v3.GetHTTPConnectionManager(filter)
+				if hcm == nil {
+					continue
+				}
+				for _, httpFilter := range hcm.HttpFilters {
+					if httpFilter.Name != "envoy.filters.http.lua" {
+						continue
+					}
+					var lua envoy_lua_v3.Lua
+					require.NoError(t, httpFilter.GetTypedConfig().UnmarshalTo(&lua))
+					scripts = append(scripts, lua.InlineCode)
+				}
+			}
+		}
+	}
+	require.Equal(t, []string{"-- first", "-- second"}, scripts)
+}
+
 type testLimiter struct {
 	termCh chan struct{}
 }
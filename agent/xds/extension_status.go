@@ -0,0 +1,66 @@
+package xds
+
+import (
+	"sync"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/xds/xdscommon"
+)
+
+// extensionStatuses holds the results of the extensions last applied to the
+// resources of each proxy with an active xDS stream.
+type extensionStatuses struct {
+	lock       sync.RWMutex
+	lastStream uint64
+	statuses   map[structs.ServiceID]extensionStatus
+}
+
+// extensionStatus is the results of the extensions last applied by a stream.
+// The stream is recorded so that when a proxy reconnects, the old stream
+// closing doesn't delete the results of the new one.
+type extensionStatus struct {
+	stream  uint64
+	results []xdscommon.ExtensionResult
+}
+
+func newExtensionStatuses() *extensionStatuses {
+	return &extensionStatuses{statuses: make(map[structs.ServiceID]extensionStatus)}
+}
+
+// newStream returns a unique ID for an xDS stream to record its results under.
+func (s *extensionStatuses) newStream() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.lastStream++
+	return s.lastStream
+}
+
+func (s *extensionStatuses) set(proxyID structs.ServiceID, stream uint64, results []xdscommon.ExtensionResult) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.statuses[proxyID] = extensionStatus{stream: stream, results: results}
+}
+
+// delete removes the results of the proxy if they were recorded by the given
+// stream.
+func (s *extensionStatuses) delete(proxyID structs.ServiceID, stream uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if status, ok := s.statuses[proxyID]; ok && status.stream == stream {
+		delete(s.statuses, proxyID)
+	}
+}
+
+func (s *extensionStatuses) get(proxyID structs.ServiceID) ([]xdscommon.ExtensionResult, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	status, ok := s.statuses[proxyID]
+	return status.results, ok
+}
+
+// ExtensionResults returns the results of the extensions applied to the xDS
+// resources last sent to the given proxy. It returns false if the proxy has
+// no xDS stream, or hasn't been sent its resources yet.
+func (s *Server) ExtensionResults(proxyID structs.ServiceID) ([]xdscommon.ExtensionResult, bool) {
+	return s.extensionStatuses.get(proxyID)
+}
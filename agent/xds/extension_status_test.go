@@ -0,0 +1,35 @@
+package xds
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/xds/xdscommon"
+)
+
+func TestExtensionStatuses_ReconnectedProxy(t *testing.T) {
+	statuses := newExtensionStatuses()
+	sid := structs.NewServiceID("web-sidecar-proxy", nil)
+
+	oldStream := statuses.newStream()
+	statuses.set(sid, oldStream, []xdscommon.ExtensionResult{{Err: errors.New("old")}})
+
+	// The proxy reconnects before its old stream is closed.
+	newStream := statuses.newStream()
+	require.NotEqual(t, oldStream, newStream)
+	newResults := []xdscommon.ExtensionResult{{}}
+	statuses.set(sid, newStream, newResults)
+
+	// Closing the old stream keeps the results of the new one.
+	statuses.delete(sid, oldStream)
+	results, ok := statuses.get(sid)
+	require.True(t, ok)
+	require.Equal(t, newResults, results)
+
+	statuses.delete(sid, newStream)
+	_, ok = statuses.get(sid)
+	require.False(t, ok)
+}
@@ -0,0 +1,107 @@
+package luaplugin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_lua_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/hashicorp/go-multierror"
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/xds/xdscommon"
+	"github.com/hashicorp/consul/api"
+)
+
+// luaConfig holds the arguments of the Lua extension.
+type luaConfig struct {
+	// Script is the inline Lua code run by the filter.
+	Script string `mapstructure:"Script"`
+}
+
+// Extend updates indexed xDS structures to include an inline Lua HTTP filter
+// in the outbound listener of the connect proxy for the upstream the extension
+// is configured for.
+func Extend(resources *xdscommon.IndexedResources, config xdscommon.ExtensionConfiguration) (*xdscommon.IndexedResources, error) {
+	if config.Kind != api.ServiceKindConnectProxy || !config.IsUpstream() {
+		return resources, nil
+	}
+
+	if config.EnvoyExtension.Name != structs.BuiltinLuaExtension {
+		return resources, nil
+	}
+
+	lua, err := makeLuaConfig(config.EnvoyExtension)
+	if err != nil {
+		return resources, fmt.Errorf("invalid arguments for extension %q: %w", config.EnvoyExtension.Name, err)
+	}
+
+	luaHTTPFilter, err := xdscommon.MakeEnvoyHTTPFilter("envoy.filters.http.lua", &envoy_lua_v3.Lua{
+		InlineCode: lua.Script,
+	})
+	if err != nil {
+		return resources, err
+	}
+
+	patchFilter := func(filter *envoy_listener_v3.Filter) (*envoy_listener_v3.Filter, bool, error) {
+		return insertLuaFilter(filter, luaHTTPFilter)
+	}
+
+	var resultErr error
+	for name, msg := range resources.Index[xdscommon.ListenerType] {
+		listener, ok := msg.(*envoy_listener_v3.Listener)
+		if !ok {
+			config.GetLogger().Debug("skipping resource of unsupported type",
+				"index_type", xdscommon.ListenerType, "name", name, "type", fmt.Sprintf("%T", msg))
+			continue
+		}
+
+		// Outbound listeners are named after the EnvoyID of their upstream.
+		if !strings.HasPrefix(listener.Name, config.EnvoyID()+":") {
+			continue
+		}
+
+		for _, filterChain := range listener.FilterChains {
+			if _, err := xdscommon.PatchFilterChain(filterChain, patchFilter); err != nil {
+				resultErr = multierror.Append(resultErr, fmt.Errorf("error patching listener %q: %w", name, err))
+			}
+		}
+	}
+
+	return resources, resultErr
+}
+
+func makeLuaConfig(ext api.EnvoyExtension) (luaConfig, error) {
+	var config luaConfig
+	if err := mapstructure.Decode(ext.Arguments, &config); err != nil {
+		return config, fmt.Errorf("error decoding arguments: %w", err)
+	}
+
+	if strings.TrimSpace(config.Script) == "" {
+		return config, errors.New("Script is required")
+	}
+
+	return config, nil
+}
+
+// insertLuaFilter inserts the Lua filter before the router filter of the
+// filter if it is an HTTP connection manager.
+func insertLuaFilter(filter *envoy_listener_v3.Filter, luaHTTPFilter *envoy_http_v3.HttpFilter) (*envoy_listener_v3.Filter, bool, error) {
+	config, err := xdscommon.GetHTTPConnectionManager(filter)
+	if err != nil || config == nil {
+		return filter, false, err
+	}
+
+	if !xdscommon.InsertHTTPFilterBeforeRouter(config, luaHTTPFilter) {
+		return filter, false, nil
+	}
+
+	newFilter, err := xdscommon.MakeFilter(xdscommon.HTTPConnectionManagerFilterName, config)
+	if err != nil {
+		return filter, false, errors.New("error making new filter")
+	}
+	return newFilter, true, nil
+}
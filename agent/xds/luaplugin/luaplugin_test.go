@@ -0,0 +1,150 @@
+package luaplugin
+
+import (
+	"bytes"
+	"testing"
+
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_lua_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
+	envoy_http_router_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	envoy_resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/xds/xdscommon"
+	"github.com/hashicorp/consul/api"
+)
+
+const testScript = "function envoy_on_request(request_handle) request_handle:headers():add(\"x-lua\", \"1\") end"
+
+func makeTestExtensionConfiguration(script string) xdscommon.ExtensionConfiguration {
+	db := api.CompoundServiceName{Name: "db", Namespace: "default", Partition: "default"}
+	return xdscommon.ExtensionConfiguration{
+		EnvoyExtension: api.EnvoyExtension{
+			Name: structs.BuiltinLuaExtension,
+			Arguments: map[string]interface{}{
+				"Script": script,
+			},
+		},
+		ServiceName: db,
+		Kind:        api.ServiceKindConnectProxy,
+		Upstreams: map[api.CompoundServiceName]xdscommon.UpstreamData{
+			db: {
+				SNI:               map[string]struct{}{"db.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul": {}},
+				EnvoyID:           "db",
+				OutgoingProxyKind: api.ServiceKindConnectProxy,
+			},
+		},
+	}
+}
+
+func makeTestListener(t *testing.T, name string) *envoy_listener_v3.Listener {
+	router, err := xdscommon.MakeEnvoyHTTPFilter("envoy.filters.http.router", &envoy_http_router_v3.Router{})
+	require.NoError(t, err)
+
+	rbac := &envoy_http_v3.HttpFilter{Name: "envoy.filters.http.rbac"}
+
+	hcm, err := xdscommon.MakeFilter("envoy.filters.network.http_connection_manager", &envoy_http_v3.HttpConnectionManager{
+		StatPrefix:  "upstream.db.default.default.dc1",
+		HttpFilters: []*envoy_http_v3.HttpFilter{rbac, router},
+	})
+	require.NoError(t, err)
+
+	return &envoy_listener_v3.Listener{
+		Name: name,
+		FilterChains: []*envoy_listener_v3.FilterChain{
+			{Filters: []*envoy_listener_v3.Filter{hcm}},
+		},
+	}
+}
+
+func TestExtend_InsertsLuaFilterBeforeRouter(t *testing.T) {
+	config := makeTestExtensionConfiguration(testScript)
+
+	upstreamListener := makeTestListener(t, "db:127.0.0.1:9191")
+	otherListener := makeTestListener(t, "web:127.0.0.1:9292")
+	otherListenerOrig := proto.Clone(otherListener)
+
+	resources := xdscommon.EmptyIndexedResources()
+	resources.Index[xdscommon.ListenerType][upstreamListener.Name] = upstreamListener
+	resources.Index[xdscommon.ListenerType][otherListener.Name] = otherListener
+
+	resources, err := Extend(resources, config)
+	require.NoError(t, err)
+
+	patched := resources.Index[xdscommon.ListenerType][upstreamListener.Name].(*envoy_listener_v3.Listener)
+	hcm := envoy_resource_v3.GetHTTPConnectionManager(patched.FilterChains[0].Filters[0])
+	require.NotNil(t, hcm)
+
+	var names []string
+	for _, httpFilter := range hcm.HttpFilters {
+		names = append(names, httpFilter.Name)
+	}
+	require.Equal(t, []string{
+		"envoy.filters.http.rbac",
+		"envoy.filters.http.lua",
+		"envoy.filters.http.router",
+	}, names)
+
+	var luaConfig envoy_lua_v3.Lua
+	require.NoError(t, hcm.HttpFilters[1].GetTypedConfig().UnmarshalTo(&luaConfig))
+	require.Equal(t, testScript, luaConfig.InlineCode)
+
+	// Listeners for other upstreams must be left alone.
+	require.True(t, proto.Equal(otherListenerOrig, resources.Index[xdscommon.ListenerType][otherListener.Name]))
+}
+
+func TestExtend_EmptyScript(t *testing.T) {
+	for name, script := range map[string]string{
+		"empty":      "",
+		"whitespace": "  \n\t",
+	} {
+		t.Run(name, func(t *testing.T) {
+			config := makeTestExtensionConfiguration(script)
+
+			listener := makeTestListener(t, "db:127.0.0.1:9191")
+			resources := xdscommon.EmptyIndexedResources()
+			resources.Index[xdscommon.ListenerType][listener.Name] = listener
+
+			_, err := Extend(resources, config)
+			require.EqualError(t, err, `invalid arguments for extension "builtin/lua": Script is required`)
+		})
+	}
+}
+
+func TestExtend_SkipsNonConnectProxy(t *testing.T) {
+	config := makeTestExtensionConfiguration(testScript)
+	config.Kind = api.ServiceKindTerminatingGateway
+
+	listener := makeTestListener(t, "db:127.0.0.1:9191")
+	orig := proto.Clone(listener)
+
+	resources := xdscommon.EmptyIndexedResources()
+	resources.Index[xdscommon.ListenerType][listener.Name] = listener
+
+	resources, err := Extend(resources, config)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(orig, resources.Index[xdscommon.ListenerType][listener.Name]))
+}
+
+func TestExtend_SkipsUnknownTypes(t *testing.T) {
+	config := makeTestExtensionConfiguration(testScript)
+
+	var logs bytes.Buffer
+	config.Logger = hclog.New(&hclog.LoggerOptions{Level: hclog.Debug, Output: &logs})
+
+	name := "db:127.0.0.1:9191"
+	resources := xdscommon.EmptyIndexedResources()
+	secret := &envoy_tls_v3.Secret{Name: name}
+	resources.Index[xdscommon.ListenerType][name] = secret
+
+	_, err := Extend(resources, config)
+	require.NoError(t, err)
+	require.Same(t, secret, resources.Index[xdscommon.ListenerType][name])
+	require.Contains(t, logs.String(), "skipping resource of unsupported type")
+	require.Contains(t, logs.String(), `type="*tlsv3.Secret"`)
+}
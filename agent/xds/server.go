@@ -141,6 +141,8 @@ type Server struct {
 	ResourceMapMutateFn func(resourceMap *xdscommon.IndexedResources)
 
 	activeStreams *activeStreamCounters
+
+	extensionStatuses *extensionStatuses
 }
 
 // activeStreamCounters simply encapsulates two counters accessed atomically to
@@ -190,6 +192,7 @@ func NewServer(
 		SessionLimiter:     limiter,
 		AuthCheckFrequency: DefaultAuthCheckFrequency,
 		activeStreams:      &activeStreamCounters{},
+		extensionStatuses:  newExtensionStatuses(),
 	}
 }
 
@@ -48,7 +48,7 @@ func TestServerlessPluginFromSnapshot(t *testing.T) {
 				{
 					Name: structs.BuiltinAWSLambdaExtension,
 					Arguments: map[string]interface{}{
-						"ARN":                "lambda-arn",
+						"ARN":                "arn:aws:lambda:us-east-1:111111111111:function:lambda-1234",
 						"PayloadPassthrough": payloadPassthrough,
 						"InvocationMode":     invocationMode,
 						"Region":             "us-east-1",
@@ -91,6 +91,10 @@ func TestServerlessPluginFromSnapshot(t *testing.T) {
 			name:   "lambda-terminating-gateway-with-service-resolvers",
 			create: proxycfg.TestConfigSnapshotTerminatingGatewayWithLambdaServiceAndServiceResolvers,
 		},
+		{
+			name:   "lambda-terminating-gateway-destination",
+			create: proxycfg.TestConfigSnapshotTerminatingGatewayWithLambdaDestination,
+		},
 	}
 
 	latestEnvoyVersion := proxysupport.EnvoyVersions[0]
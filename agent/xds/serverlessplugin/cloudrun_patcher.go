@@ -0,0 +1,162 @@
+package serverlessplugin
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/xds/xdscommon"
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// cloudrunPatcher routes the requests to the upstream to a Cloud Run service.
+// The requests aren't authenticated with Google identity tokens, so the Cloud
+// Run service must allow unauthenticated invocations.
+type cloudrunPatcher struct {
+	URL  string `mapstructure:"URL"`
+	Kind api.ServiceKind
+
+	// Audience would be the audience of the Google identity tokens sent to
+	// the service. It is rejected since the Envoy API version Consul uses has
+	// no filter to fetch these tokens.
+	Audience string `mapstructure:"Audience"`
+
+	// host is the Cloud Run service host parsed from URL.
+	host string
+
+	noopEndpointsPatcher
+}
+
+var _ patcher = (*cloudrunPatcher)(nil)
+
+func makeCloudRunPatcher(ext api.EnvoyExtension, upstreamKind api.ServiceKind) (patcher, bool, error) {
+	var patcher cloudrunPatcher
+
+	if ext.Name != structs.BuiltinGCPCloudRunExtension {
+		return nil, false, nil
+	}
+
+	err := mapstructure.Decode(ext.Arguments, &patcher)
+	if err != nil {
+		return nil, false, fmt.Errorf("error decoding arguments: %w", err)
+	}
+
+	if patcher.URL == "" {
+		return nil, false, errors.New("URL is required")
+	}
+
+	if patcher.Audience != "" {
+		return nil, false, errors.New("Audience is not supported: requests to the Cloud Run service can't be authenticated with Google identity tokens, so the service must allow unauthenticated invocations")
+	}
+
+	u, err := url.Parse(patcher.URL)
+	if err != nil || u.Hostname() == "" {
+		return nil, false, fmt.Errorf("URL %q is not a valid Cloud Run service URL", patcher.URL)
+	}
+	patcher.host = u.Hostname()
+
+	patcher.Kind = upstreamKind
+
+	return patcher, true, nil
+}
+
+func (p cloudrunPatcher) CanPatch(kind api.ServiceKind) bool {
+	return canPatch(p.Kind, kind)
+}
+
+// PatchRoute rewrites the Host header of the requests routed to the upstream,
+// since Cloud Run routes requests to a service based on it.
+func (p cloudrunPatcher) PatchRoute(config xdscommon.ExtensionConfiguration, route *envoy_route_v3.RouteConfiguration) (*envoy_route_v3.RouteConfiguration, bool, error) {
+	var patched bool
+	for _, virtualHost := range route.VirtualHosts {
+		for _, route := range virtualHost.Routes {
+			action, ok := route.Action.(*envoy_route_v3.Route_Route)
+
+			if !ok {
+				continue
+			}
+
+			if matchesSNI(config, action.Route.GetCluster()) {
+				action.Route.HostRewriteSpecifier = &envoy_route_v3.RouteAction_HostRewriteLiteral{
+					HostRewriteLiteral: p.host,
+				}
+				patched = true
+			}
+
+			// The other clusters of weighted routes keep their Host header, so
+			// it is only rewritten for the clusters of the upstream.
+			for _, cluster := range action.Route.GetWeightedClusters().GetClusters() {
+				if !matchesSNI(config, cluster.Name) {
+					continue
+				}
+				cluster.HostRewriteSpecifier = &envoy_route_v3.WeightedCluster_ClusterWeight_HostRewriteLiteral{
+					HostRewriteLiteral: p.host,
+				}
+				patched = true
+			}
+		}
+	}
+
+	return route, patched, nil
+}
+
+func (p cloudrunPatcher) PatchCluster(_ xdscommon.ExtensionConfiguration, c *envoy_cluster_v3.Cluster) (*envoy_cluster_v3.Cluster, bool, error) {
+	transportSocket, err := makeUpstreamTLSTransportSocket(&envoy_tls_v3.UpstreamTlsContext{
+		Sni: p.host,
+	})
+
+	if err != nil {
+		return c, false, fmt.Errorf("failed to make transport socket: %w", err)
+	}
+
+	cluster := &envoy_cluster_v3.Cluster{
+		Name:                 c.Name,
+		ConnectTimeout:       c.ConnectTimeout,
+		ClusterDiscoveryType: &envoy_cluster_v3.Cluster_Type{Type: envoy_cluster_v3.Cluster_LOGICAL_DNS},
+		DnsLookupFamily:      envoy_cluster_v3.Cluster_AUTO,
+		LbPolicy:             envoy_cluster_v3.Cluster_ROUND_ROBIN,
+		LoadAssignment: &envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: c.Name,
+			Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{
+				{
+					LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+						{
+							HostIdentifier: &envoy_endpoint_v3.LbEndpoint_Endpoint{
+								Endpoint: &envoy_endpoint_v3.Endpoint{
+									Address: &envoy_core_v3.Address{
+										Address: &envoy_core_v3.Address_SocketAddress{
+											SocketAddress: &envoy_core_v3.SocketAddress{
+												Address: p.host,
+												PortSpecifier: &envoy_core_v3.SocketAddress_PortValue{
+													PortValue: 443,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		TransportSocket: transportSocket,
+	}
+	return cluster, true, nil
+}
+
+// PatchFilter leaves the filters as they are: the Host header is rewritten on
+// the routes, including those inlined in the HTTP connection manager which
+// are patched with PatchRoute, so the filters don't need any changes.
+func (p cloudrunPatcher) PatchFilter(filter *envoy_listener_v3.Filter) (*envoy_listener_v3.Filter, bool, error) {
+	return filter, false, nil
+}
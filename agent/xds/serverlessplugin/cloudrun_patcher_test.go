@@ -0,0 +1,289 @@
+package serverlessplugin
+
+import (
+	"testing"
+
+	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	envoy_resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/xds/xdscommon"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+)
+
+func TestMakeCloudRunPatcher(t *testing.T) {
+	kind := api.ServiceKindTerminatingGateway
+	cases := []struct {
+		name        string
+		url         string
+		audience    string
+		expected    cloudrunPatcher
+		ok          bool
+		expectedErr string
+	}{
+		{
+			name:        "missing url",
+			ok:          false,
+			expectedErr: "URL is required",
+		},
+		{
+			name:        "invalid url",
+			url:         "://",
+			ok:          false,
+			expectedErr: `URL "://" is not a valid Cloud Run service URL`,
+		},
+		{
+			name:        "audience",
+			url:         "https://hello-abc123-uc.a.run.app",
+			audience:    "https://hello-abc123-uc.a.run.app",
+			ok:          false,
+			expectedErr: "Audience is not supported: requests to the Cloud Run service can't be authenticated with Google identity tokens, so the service must allow unauthenticated invocations",
+		},
+		{
+			name: "valid url",
+			url:  "https://hello-abc123-uc.a.run.app",
+			expected: cloudrunPatcher{
+				URL:  "https://hello-abc123-uc.a.run.app",
+				Kind: kind,
+				host: "hello-abc123-uc.a.run.app",
+			},
+			ok: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ext := api.EnvoyExtension{
+				Name: structs.BuiltinGCPCloudRunExtension,
+				Arguments: map[string]interface{}{
+					"URL":      tc.url,
+					"Audience": tc.audience,
+				},
+			}
+
+			patcher, ok, err := makeCloudRunPatcher(ext, kind)
+
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.ok, ok)
+
+			if tc.ok {
+				require.Equal(t, tc.expected, patcher)
+			}
+		})
+	}
+}
+
+const testCloudRunSNI = "db.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"
+
+func makeTestCloudRunExtensionConfiguration(kind api.ServiceKind) xdscommon.ExtensionConfiguration {
+	cloudrunService := api.CompoundServiceName{Name: "db", Namespace: "default", Partition: "default"}
+	return xdscommon.ExtensionConfiguration{
+		EnvoyExtension: api.EnvoyExtension{
+			Name: structs.BuiltinGCPCloudRunExtension,
+			Arguments: map[string]interface{}{
+				"URL": "https://hello-abc123-uc.a.run.app",
+			},
+		},
+		ServiceName: cloudrunService,
+		Kind:        kind,
+		Upstreams: map[api.CompoundServiceName]xdscommon.UpstreamData{
+			cloudrunService: {
+				SNI:               map[string]struct{}{testCloudRunSNI: {}},
+				EnvoyID:           "db",
+				OutgoingProxyKind: kind,
+			},
+		},
+	}
+}
+
+func makeTestRouteConfiguration(clusters ...string) *envoy_route_v3.RouteConfiguration {
+	routeConfig := &envoy_route_v3.RouteConfiguration{
+		VirtualHosts: []*envoy_route_v3.VirtualHost{{}},
+	}
+	for _, cluster := range clusters {
+		routeConfig.VirtualHosts[0].Routes = append(routeConfig.VirtualHosts[0].Routes, &envoy_route_v3.Route{
+			Action: &envoy_route_v3.Route_Route{
+				Route: &envoy_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{Cluster: cluster},
+				},
+			},
+		})
+	}
+	return routeConfig
+}
+
+func TestCloudRunPatcher(t *testing.T) {
+	for _, kind := range []api.ServiceKind{api.ServiceKindConnectProxy, api.ServiceKindTerminatingGateway} {
+		t.Run(string(kind), func(t *testing.T) {
+			ext := api.EnvoyExtension{
+				Name: structs.BuiltinGCPCloudRunExtension,
+				Arguments: map[string]interface{}{
+					"URL": "https://hello-abc123-uc.a.run.app",
+				},
+			}
+
+			p, ok, err := makeCloudRunPatcher(ext, kind)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.True(t, p.CanPatch(kind))
+			require.False(t, p.CanPatch(api.ServiceKindMeshGateway))
+
+			cluster, patched, err := p.PatchCluster(xdscommon.ExtensionConfiguration{}, &envoy_cluster_v3.Cluster{Name: "db.default.dc1.internal.domain.consul"})
+			require.NoError(t, err)
+			require.True(t, patched)
+			require.Equal(t, "db.default.dc1.internal.domain.consul", cluster.Name)
+			require.Equal(t, envoy_cluster_v3.Cluster_LOGICAL_DNS, cluster.GetType())
+
+			addr := cluster.LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress()
+			require.Equal(t, "hello-abc123-uc.a.run.app", addr.Address)
+			require.Equal(t, uint32(443), addr.GetPortValue())
+
+			var tlsContext envoy_tls_v3.UpstreamTlsContext
+			require.NoError(t, cluster.TransportSocket.GetTypedConfig().UnmarshalTo(&tlsContext))
+			require.Equal(t, "hello-abc123-uc.a.run.app", tlsContext.Sni)
+
+			config := makeTestCloudRunExtensionConfiguration(kind)
+
+			// Only the routes to the Cloud Run service have their Host
+			// header rewritten.
+			route, patched, err := p.PatchRoute(config, makeTestRouteConfiguration(testCloudRunSNI, testSiblingSNI))
+			require.NoError(t, err)
+			require.True(t, patched)
+			require.Equal(t, "hello-abc123-uc.a.run.app", route.VirtualHosts[0].Routes[0].GetRoute().GetHostRewriteLiteral())
+			require.Nil(t, route.VirtualHosts[0].Routes[1].GetRoute().HostRewriteSpecifier)
+
+			_, patched, err = p.PatchRoute(config, makeTestRouteConfiguration(testSiblingSNI))
+			require.NoError(t, err)
+			require.False(t, patched)
+
+			// The Host header is rewritten for the Cloud Run cluster of
+			// weighted routes, and the other clusters keep it.
+			weighted := &envoy_route_v3.RouteConfiguration{
+				VirtualHosts: []*envoy_route_v3.VirtualHost{{
+					Routes: []*envoy_route_v3.Route{{
+						Action: &envoy_route_v3.Route_Route{
+							Route: &envoy_route_v3.RouteAction{
+								ClusterSpecifier: &envoy_route_v3.RouteAction_WeightedClusters{
+									WeightedClusters: &envoy_route_v3.WeightedCluster{
+										Clusters: []*envoy_route_v3.WeightedCluster_ClusterWeight{
+											{Name: testCloudRunSNI},
+											{Name: testSiblingSNI},
+										},
+									},
+								},
+							},
+						},
+					}},
+				}},
+			}
+			route, patched, err = p.PatchRoute(config, weighted)
+			require.NoError(t, err)
+			require.True(t, patched)
+			action := route.VirtualHosts[0].Routes[0].GetRoute()
+			require.Nil(t, action.HostRewriteSpecifier)
+			require.Equal(t, "hello-abc123-uc.a.run.app", action.GetWeightedClusters().Clusters[0].GetHostRewriteLiteral())
+			require.Nil(t, action.GetWeightedClusters().Clusters[1].HostRewriteSpecifier)
+
+			// Routes without a route action, such as redirects, are left
+			// alone.
+			_, patched, err = p.PatchRoute(config, &envoy_route_v3.RouteConfiguration{
+				VirtualHosts: []*envoy_route_v3.VirtualHost{
+					{
+						Routes: []*envoy_route_v3.Route{
+							{Action: &envoy_route_v3.Route_Redirect{}},
+						},
+					},
+				},
+			})
+			require.NoError(t, err)
+			require.False(t, patched)
+
+			// The Host header is rewritten on the routes, so the HTTP
+			// connection manager is left alone.
+			filter := &envoy_listener_v3.Filter{Name: xdscommon.HTTPConnectionManagerFilterName}
+			newFilter, patched, err := p.PatchFilter(filter)
+			require.NoError(t, err)
+			require.False(t, patched)
+			require.Same(t, filter, newFilter)
+		})
+	}
+}
+
+func TestExtend_CloudRun(t *testing.T) {
+	assertHostRewrite := func(t *testing.T, routeConfig *envoy_route_v3.RouteConfiguration) {
+		t.Helper()
+		require.Equal(t, "hello-abc123-uc.a.run.app", routeConfig.VirtualHosts[0].Routes[0].GetRoute().GetHostRewriteLiteral())
+		require.Nil(t, routeConfig.VirtualHosts[0].Routes[1].GetRoute().HostRewriteSpecifier)
+	}
+
+	t.Run("connect proxy inline route", func(t *testing.T) {
+		// Connect proxies inline the route configuration of upstreams without
+		// L7 routing in the HTTP connection manager of the upstream listener.
+		hcm := makeTestHTTPConnectionManagerFilter(t)
+		hcmConfig := envoy_resource_v3.GetHTTPConnectionManager(hcm)
+		hcmConfig.RouteSpecifier = &envoy_http_v3.HttpConnectionManager_RouteConfig{
+			RouteConfig: makeTestRouteConfiguration(testCloudRunSNI, testSiblingSNI),
+		}
+		hcm, err := xdscommon.MakeFilter(xdscommon.HTTPConnectionManagerFilterName, hcmConfig)
+		require.NoError(t, err)
+
+		listener := &envoy_listener_v3.Listener{
+			Name: "db:127.0.0.1:9191",
+			FilterChains: []*envoy_listener_v3.FilterChain{
+				{Filters: []*envoy_listener_v3.Filter{hcm}},
+			},
+		}
+		cluster := &envoy_cluster_v3.Cluster{Name: testCloudRunSNI}
+
+		resources := xdscommon.EmptyIndexedResources()
+		resources.Index[xdscommon.ListenerType][listener.Name] = listener
+		resources.Index[xdscommon.ClusterType][cluster.Name] = cluster
+
+		resources, err = Extend(resources, makeTestCloudRunExtensionConfiguration(api.ServiceKindConnectProxy))
+		require.NoError(t, err)
+
+		patchedListener := resources.Index[xdscommon.ListenerType][listener.Name].(*envoy_listener_v3.Listener)
+		patchedHCM := envoy_resource_v3.GetHTTPConnectionManager(patchedListener.FilterChains[0].Filters[0])
+		require.NotNil(t, patchedHCM)
+		assertHostRewrite(t, patchedHCM.GetRouteConfig())
+
+		patchedCluster := resources.Index[xdscommon.ClusterType][cluster.Name].(*envoy_cluster_v3.Cluster)
+		require.Equal(t, envoy_cluster_v3.Cluster_LOGICAL_DNS, patchedCluster.GetType())
+	})
+
+	t.Run("connect proxy RDS route", func(t *testing.T) {
+		// Upstreams with L7 routing use a route configuration named after
+		// their Envoy ID.
+		routeConfig := makeTestRouteConfiguration(testCloudRunSNI, testSiblingSNI)
+		routeConfig.Name = "db"
+
+		resources := xdscommon.EmptyIndexedResources()
+		resources.Index[xdscommon.RouteType][routeConfig.Name] = routeConfig
+
+		resources, err := Extend(resources, makeTestCloudRunExtensionConfiguration(api.ServiceKindConnectProxy))
+		require.NoError(t, err)
+		assertHostRewrite(t, resources.Index[xdscommon.RouteType][routeConfig.Name].(*envoy_route_v3.RouteConfiguration))
+	})
+
+	t.Run("terminating gateway", func(t *testing.T) {
+		routeConfig := makeTestRouteConfiguration(testCloudRunSNI, testSiblingSNI)
+		routeConfig.Name = testCloudRunSNI
+
+		resources := xdscommon.EmptyIndexedResources()
+		resources.Index[xdscommon.RouteType][routeConfig.Name] = routeConfig
+
+		resources, err := Extend(resources, makeTestCloudRunExtensionConfiguration(api.ServiceKindTerminatingGateway))
+		require.NoError(t, err)
+		assertHostRewrite(t, resources.Index[xdscommon.RouteType][routeConfig.Name].(*envoy_route_v3.RouteConfiguration))
+	})
+}
@@ -2,8 +2,6 @@ package serverlessplugin
 
 import (
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
-	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
-	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 
 	"github.com/golang/protobuf/ptypes"
@@ -14,6 +12,10 @@ import (
 // This is copied from xds and not put into the shared package because I'm not
 // convinced it should be shared.
 
+// outboundListenerName is the name of the transparent proxy outbound listener,
+// see xds.OutboundListenerName.
+const outboundListenerName = "outbound_listener"
+
 func makeUpstreamTLSTransportSocket(tlsContext *envoy_tls_v3.UpstreamTlsContext) (*envoy_core_v3.TransportSocket, error) {
 	if tlsContext == nil {
 		return nil, nil
@@ -33,27 +35,3 @@ func makeTransportSocket(name string, config proto.Message) (*envoy_core_v3.Tran
 		},
 	}, nil
 }
-
-func makeEnvoyHTTPFilter(name string, cfg proto.Message) (*envoy_http_v3.HttpFilter, error) {
-	any, err := ptypes.MarshalAny(cfg)
-	if err != nil {
-		return nil, err
-	}
-
-	return &envoy_http_v3.HttpFilter{
-		Name:       name,
-		ConfigType: &envoy_http_v3.HttpFilter_TypedConfig{TypedConfig: any},
-	}, nil
-}
-
-func makeFilter(name string, cfg proto.Message) (*envoy_listener_v3.Filter, error) {
-	any, err := ptypes.MarshalAny(cfg)
-	if err != nil {
-		return nil, err
-	}
-
-	return &envoy_listener_v3.Filter{
-		Name:       name,
-		ConfigType: &envoy_listener_v3.Filter_TypedConfig{TypedConfig: any},
-	}, nil
-}
@@ -3,6 +3,10 @@ package serverlessplugin
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
@@ -12,10 +16,16 @@ import (
 	envoy_lambda_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/aws_lambda/v3"
 	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
-	envoy_resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	envoy_upstreams_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	"github.com/golang/protobuf/proto"
 	pstruct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/xds/accesslogs"
+	"github.com/hashicorp/consul/agent/xds/xdscommon"
 	"github.com/mitchellh/mapstructure"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/hashicorp/consul/api"
 )
@@ -25,35 +35,203 @@ type lambdaPatcher struct {
 	PayloadPassthrough bool   `mapstructure:"PayloadPassthrough"`
 	Region             string `mapstructure:"Region"`
 	Kind               api.ServiceKind
-	InvocationMode     string `mapstructure:"InvocationMode"`
+	InvocationMode     string   `mapstructure:"InvocationMode"`
+	NumRetries         uint32   `mapstructure:"NumRetries"`
+	RetryOn            []string `mapstructure:"RetryOn"`
+
+	// SNI overrides the SNI used for the TLS handshake with the upstream, for
+	// example when the Lambda is reached through a private API endpoint.
+	SNI string `mapstructure:"SNI"`
+
+	// Protocol is the protocol used to reach the Lambda, either "http" or
+	// "http2". It defaults to "http", i.e. HTTP/1.1.
+	Protocol string `mapstructure:"Protocol"`
+
+	// AccessLog enables access logs for the requests to the Lambda. They are
+	// written to stdout, or to AccessLogPath when set, using the text format
+	// AccessLogFormat or the default JSON format of the proxy access logs.
+	AccessLog       bool   `mapstructure:"AccessLog"`
+	AccessLogPath   string `mapstructure:"AccessLogPath"`
+	AccessLogFormat string `mapstructure:"AccessLogFormat"`
+
+	// RequestHeadersToAdd and ResponseHeadersToAdd are set on the requests to
+	// and the responses from the Lambda, replacing any existing values.
+	// RequestHeadersToRemove and ResponseHeadersToRemove are removed from them.
+	RequestHeadersToAdd     map[string]string `mapstructure:"RequestHeadersToAdd"`
+	RequestHeadersToRemove  []string          `mapstructure:"RequestHeadersToRemove"`
+	ResponseHeadersToAdd    map[string]string `mapstructure:"ResponseHeadersToAdd"`
+	ResponseHeadersToRemove []string          `mapstructure:"ResponseHeadersToRemove"`
+
+	// IdleTimeout is how long, as a Go duration string, a connection to the
+	// Lambda can stay idle before envoy closes it. It defaults to the envoy
+	// default of one hour.
+	IdleTimeout string `mapstructure:"IdleTimeout"`
+
+	// ServiceStatName emits the stats of the Lambda cluster under a name
+	// derived from the Lambda service, see lambdaStatName, instead of the
+	// cluster name.
+	ServiceStatName bool `mapstructure:"ServiceStatName"`
+
+	noopEndpointsPatcher
 }
 
 var _ patcher = (*lambdaPatcher)(nil)
 
-func makeLambdaPatcher(ext api.EnvoyExtension, upstreamKind api.ServiceKind) (patcher, bool) {
+// lambdaRegionRegexp matches AWS region names such as us-east-1 or
+// us-gov-west-1.
+var lambdaRegionRegexp = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+
+// lambdaQualifierRegexp matches the version, such as 3 or $LATEST, or the
+// alias, such as PROD, a Lambda function ARN may be qualified with.
+var lambdaQualifierRegexp = regexp.MustCompile(`^[a-zA-Z0-9$_-]+$`)
+
+func makeLambdaPatcher(ext api.EnvoyExtension, upstreamKind api.ServiceKind) (patcher, bool, error) {
 	var patcher lambdaPatcher
 
 	if ext.Name != structs.BuiltinAWSLambdaExtension {
-		return nil, false
+		return nil, false, nil
 	}
 
 	// TODO this blows up if types aren't encode properly. We need to check this earlier in the Validate RPC.
 	err := mapstructure.Decode(ext.Arguments, &patcher)
 	if err != nil {
-		return nil, false
+		return nil, false, fmt.Errorf("error decoding arguments: %w", err)
 	}
 
-	if patcher.ARN == "" {
-		return nil, false
+	if err := patcher.validate(); err != nil {
+		return nil, false, err
 	}
 
-	if patcher.Region == "" {
-		return nil, false
+	patcher.Kind = upstreamKind
+
+	return patcher, true, nil
+}
+
+// validate checks the arguments of the patcher. The Region defaults to the
+// region of the ARN, and an explicit Region must match it.
+func (p *lambdaPatcher) validate() error {
+	if p.ARN == "" {
+		return errors.New("ARN is required")
 	}
 
-	patcher.Kind = upstreamKind
+	// Lambda function ARNs are of the form
+	// arn:<partition>:lambda:<region>:<account-id>:function:<name>[:<qualifier>]
+	// where the optional qualifier is a version or an alias. The ARN is passed
+	// to the aws_lambda filter as it is, so a qualified ARN invokes that
+	// version or alias.
+	parts := strings.Split(p.ARN, ":")
+	if len(parts) < 7 || len(parts) > 8 || parts[0] != "arn" || parts[2] != "lambda" || parts[5] != "function" || parts[6] == "" {
+		return fmt.Errorf("ARN %q is not a valid Lambda function ARN", p.ARN)
+	}
+	if len(parts) == 8 && !lambdaQualifierRegexp.MatchString(parts[7]) {
+		return fmt.Errorf("ARN %q has an invalid qualifier %q", p.ARN, parts[7])
+	}
+
+	arnRegion := parts[3]
+	if p.Region == "" {
+		p.Region = arnRegion
+	}
+
+	if p.Region == "" {
+		return errors.New("Region is required")
+	}
+
+	if !lambdaRegionRegexp.MatchString(p.Region) {
+		return fmt.Errorf("Region %q is not a valid AWS region", p.Region)
+	}
+
+	if arnRegion != "" && p.Region != arnRegion {
+		return fmt.Errorf("Region %q does not match the region %q of the ARN", p.Region, arnRegion)
+	}
+
+	switch p.Protocol {
+	case "", "http", "http2":
+	default:
+		return fmt.Errorf(`Protocol %q is not supported; must be "http" or "http2"`, p.Protocol)
+	}
+
+	if _, err := p.idleTimeout(); err != nil {
+		return err
+	}
+
+	switch p.InvocationMode {
+	case "", "synchronous", "asynchronous":
+	default:
+		return fmt.Errorf(`InvocationMode %q is not supported; must be "synchronous" or "asynchronous"`, p.InvocationMode)
+	}
+
+	return nil
+}
+
+// accessLogsConfig returns the access log configuration of the Lambda HTTP
+// filter chain.
+func (p lambdaPatcher) accessLogsConfig() *structs.AccessLogsConfig {
+	logs := &structs.AccessLogsConfig{
+		Enabled:    p.AccessLog,
+		TextFormat: p.AccessLogFormat,
+	}
+	if p.AccessLogPath != "" {
+		logs.Type = structs.FileLogSinkType
+		logs.Path = p.AccessLogPath
+	}
+	return logs
+}
+
+// idleTimeout returns the parsed IdleTimeout, or zero if it isn't set.
+func (p lambdaPatcher) idleTimeout() (time.Duration, error) {
+	if p.IdleTimeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(p.IdleTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("IdleTimeout %q is not a valid duration", p.IdleTimeout)
+	}
+	if d <= 0 {
+		return 0, errors.New("IdleTimeout must be positive")
+	}
+	return d, nil
+}
+
+// httpProtocolOptions returns the typed extension protocol options of the
+// Lambda cluster, or nil if the envoy defaults are used.
+func (p lambdaPatcher) httpProtocolOptions() (map[string]*anypb.Any, error) {
+	idleTimeout, err := p.idleTimeout()
+	if err != nil {
+		return nil, err
+	}
+	if p.Protocol != "http2" && idleTimeout == 0 {
+		return nil, nil
+	}
+
+	explicitConfig := &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig{
+		ProtocolConfig: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig_HttpProtocolOptions{
+			HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{},
+		},
+	}
+	if p.Protocol == "http2" {
+		explicitConfig.ProtocolConfig = &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{
+			Http2ProtocolOptions: &envoy_core_v3.Http2ProtocolOptions{},
+		}
+	}
+
+	cfg := &envoy_upstreams_v3.HttpProtocolOptions{
+		UpstreamProtocolOptions: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+			ExplicitHttpConfig: explicitConfig,
+		},
+	}
+	if idleTimeout > 0 {
+		cfg.CommonHttpProtocolOptions = &envoy_core_v3.HttpProtocolOptions{
+			IdleTimeout: durationpb.New(idleTimeout),
+		}
+	}
 
-	return patcher, true
+	any, err := anypb.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]*anypb.Any{
+		"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": any,
+	}, nil
 }
 
 func toEnvoyInvocationMode(s string) envoy_lambda_v3.Config_InvocationMode {
@@ -65,14 +243,38 @@ func toEnvoyInvocationMode(s string) envoy_lambda_v3.Config_InvocationMode {
 }
 
 func (p lambdaPatcher) CanPatch(kind api.ServiceKind) bool {
-	return kind == p.Kind
+	return canPatch(p.Kind, kind)
 }
 
-func (p lambdaPatcher) PatchRoute(route *envoy_route_v3.RouteConfiguration) (*envoy_route_v3.RouteConfiguration, bool, error) {
-	if p.Kind != api.ServiceKindTerminatingGateway {
-		return route, false, nil
+// retryPolicy returns the retry policy for routes to the Lambda or nil if no
+// retries were configured. Requests are retried on 5xx responses unless
+// RetryOn is set.
+func (p lambdaPatcher) retryPolicy() *envoy_route_v3.RetryPolicy {
+	if p.NumRetries == 0 && len(p.RetryOn) == 0 {
+		return nil
+	}
+
+	retryOn := "5xx"
+	if len(p.RetryOn) > 0 {
+		retryOn = strings.Join(p.RetryOn, ",")
+	}
+
+	retryPolicy := &envoy_route_v3.RetryPolicy{
+		RetryOn: retryOn,
 	}
+	if p.NumRetries > 0 {
+		retryPolicy.NumRetries = &wrappers.UInt32Value{Value: p.NumRetries}
+	}
+	return retryPolicy
+}
 
+// PatchRoute patches the routes to the Lambda's clusters, in the route
+// configurations of terminating gateways as well as those of connect proxies.
+// It returns false if none of the routes target the Lambda.
+func (p lambdaPatcher) PatchRoute(config xdscommon.ExtensionConfiguration, route *envoy_route_v3.RouteConfiguration) (*envoy_route_v3.RouteConfiguration, bool, error) {
+	retryPolicy := p.retryPolicy()
+
+	var patched bool
 	for _, virtualHost := range route.VirtualHosts {
 		for _, route := range virtualHost.Routes {
 			action, ok := route.Action.(*envoy_route_v3.Route_Route)
@@ -81,19 +283,93 @@ func (p lambdaPatcher) PatchRoute(route *envoy_route_v3.RouteConfiguration) (*en
 				continue
 			}
 
+			matched := matchesSNI(config, action.Route.GetCluster())
+
+			// Routes using weighted clusters, such as canary rollouts, can
+			// override the host per cluster so each of the clusters targeting
+			// the Lambda needs to be patched as well. Only the host rewrite is
+			// modified so the weights are preserved.
+			for _, cluster := range action.Route.GetWeightedClusters().GetClusters() {
+				if !matchesSNI(config, cluster.Name) {
+					continue
+				}
+				cluster.HostRewriteSpecifier = nil
+				matched = true
+			}
+
+			if !matched {
+				continue
+			}
+
 			// When auto_host_rewrite is set it conflicts with strip_any_host_port
 			// on the http_connection_manager filter.
 			action.Route.HostRewriteSpecifier = nil
+
+			if retryPolicy != nil {
+				action.Route.RetryPolicy = proto.Clone(retryPolicy).(*envoy_route_v3.RetryPolicy)
+			}
+			p.injectHeaders(route)
+			patched = true
 		}
 	}
 
-	return route, true, nil
+	return route, patched, nil
 }
 
-func (p lambdaPatcher) PatchCluster(c *envoy_cluster_v3.Cluster) (*envoy_cluster_v3.Cluster, bool, error) {
-	transportSocket, err := makeUpstreamTLSTransportSocket(&envoy_tls_v3.UpstreamTlsContext{
-		Sni: "*.amazonaws.com",
-	})
+// injectHeaders adds the configured header manipulation to the route.
+func (p lambdaPatcher) injectHeaders(route *envoy_route_v3.Route) {
+	route.RequestHeadersToAdd = append(route.RequestHeadersToAdd, makeHeaderValueOptions(p.RequestHeadersToAdd)...)
+	route.RequestHeadersToRemove = append(route.RequestHeadersToRemove, p.RequestHeadersToRemove...)
+	route.ResponseHeadersToAdd = append(route.ResponseHeadersToAdd, makeHeaderValueOptions(p.ResponseHeadersToAdd)...)
+	route.ResponseHeadersToRemove = append(route.ResponseHeadersToRemove, p.ResponseHeadersToRemove...)
+}
+
+// makeHeaderValueOptions returns options replacing the values of the headers.
+// The headers are sorted by name so that the generated config is stable.
+func makeHeaderValueOptions(headers map[string]string) []*envoy_core_v3.HeaderValueOption {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	opts := make([]*envoy_core_v3.HeaderValueOption, 0, len(headers))
+	for _, name := range names {
+		opts = append(opts, &envoy_core_v3.HeaderValueOption{
+			Header: &envoy_core_v3.HeaderValue{
+				Key:   name,
+				Value: headers[name],
+			},
+			Append: &wrappers.BoolValue{Value: false},
+		})
+	}
+	return opts
+}
+
+// PatchCluster replaces the cluster with one whose only endpoint is the Lambda
+// API, keeping just the name and connect timeout of the original. Since its
+// endpoints are replaced, the transport socket matches of the original are
+// dropped along with them and the Lambda TLS transport socket is used instead.
+func (p lambdaPatcher) PatchCluster(config xdscommon.ExtensionConfiguration, c *envoy_cluster_v3.Cluster) (*envoy_cluster_v3.Cluster, bool, error) {
+	sni := "*.amazonaws.com"
+	if p.SNI != "" {
+		sni = p.SNI
+	}
+
+	tlsContext := &envoy_tls_v3.UpstreamTlsContext{
+		Sni: sni,
+	}
+	if p.Protocol == "http2" {
+		tlsContext.CommonTlsContext = &envoy_tls_v3.CommonTlsContext{
+			AlpnProtocols: []string{"h2"},
+		}
+	}
+
+	transportSocket, err := makeUpstreamTLSTransportSocket(tlsContext)
 
 	if err != nil {
 		return c, false, fmt.Errorf("failed to make transport socket: %w", err)
@@ -103,7 +379,7 @@ func (p lambdaPatcher) PatchCluster(c *envoy_cluster_v3.Cluster) (*envoy_cluster
 		Name:                 c.Name,
 		ConnectTimeout:       c.ConnectTimeout,
 		ClusterDiscoveryType: &envoy_cluster_v3.Cluster_Type{Type: envoy_cluster_v3.Cluster_LOGICAL_DNS},
-		DnsLookupFamily:      envoy_cluster_v3.Cluster_V4_ONLY,
+		DnsLookupFamily:      envoy_cluster_v3.Cluster_AUTO,
 		LbPolicy:             envoy_cluster_v3.Cluster_ROUND_ROBIN,
 		Metadata: &envoy_core_v3.Metadata{
 			FilterMetadata: map[string]*pstruct.Struct{
@@ -141,22 +417,39 @@ func (p lambdaPatcher) PatchCluster(c *envoy_cluster_v3.Cluster) (*envoy_cluster
 		},
 		TransportSocket: transportSocket,
 	}
+
+	if p.ServiceStatName {
+		cluster.AltStatName = lambdaStatName(config.ServiceName)
+	}
+
+	protocolOptions, err := p.httpProtocolOptions()
+	if err != nil {
+		return c, false, fmt.Errorf("failed to make protocol options: %w", err)
+	}
+	cluster.TypedExtensionProtocolOptions = protocolOptions
+
 	return cluster, true, nil
 }
 
-func (p lambdaPatcher) PatchFilter(filter *envoy_listener_v3.Filter) (*envoy_listener_v3.Filter, bool, error) {
-	if filter.Name != "envoy.filters.network.http_connection_manager" {
-		return filter, false, nil
-	}
-	if typedConfig := filter.GetTypedConfig(); typedConfig == nil {
-		return filter, false, errors.New("error getting typed config for http filter")
+// lambdaStatName returns the stat name of the cluster of the given Lambda
+// service, lambda.<name>.<namespace>[.<partition>], so that the stats of
+// several Lambdas can be told apart.
+func lambdaStatName(svc api.CompoundServiceName) string {
+	parts := []string{"lambda", svc.Name}
+	for _, part := range []string{svc.Namespace, svc.Partition} {
+		if part != "" {
+			parts = append(parts, part)
+		}
 	}
+	return strings.Join(parts, ".")
+}
 
-	config := envoy_resource_v3.GetHTTPConnectionManager(filter)
-	if config == nil {
-		return filter, false, errors.New("error unmarshalling filter")
+func (p lambdaPatcher) PatchFilter(filter *envoy_listener_v3.Filter) (*envoy_listener_v3.Filter, bool, error) {
+	config, err := xdscommon.GetHTTPConnectionManager(filter)
+	if err != nil || config == nil {
+		return filter, false, err
 	}
-	lambdaHttpFilter, err := makeEnvoyHTTPFilter(
+	lambdaHttpFilter, err := xdscommon.MakeEnvoyHTTPFilter(
 		"envoy.filters.http.aws_lambda",
 		&envoy_lambda_v3.Config{
 			Arn:                p.ARN,
@@ -168,30 +461,20 @@ func (p lambdaPatcher) PatchFilter(filter *envoy_listener_v3.Filter) (*envoy_lis
 		return filter, false, err
 	}
 
-	var (
-		changedFilters = make([]*envoy_http_v3.HttpFilter, 0, len(config.HttpFilters)+1)
-		changed        bool
-	)
-
-	// We need to be careful about overwriting http filters completely because
-	// http filters validates intentions with the RBAC filter. This inserts the
-	// lambda filter before `envoy.filters.http.router` while keeping everything
-	// else intact.
-	for _, httpFilter := range config.HttpFilters {
-		if httpFilter.Name == "envoy.filters.http.router" {
-			changedFilters = append(changedFilters, lambdaHttpFilter)
-			changed = true
-		}
-		changedFilters = append(changedFilters, httpFilter)
-	}
-	if changed {
-		config.HttpFilters = changedFilters
-	}
+	xdscommon.InsertHTTPFilterBeforeRouter(config, lambdaHttpFilter)
 
 	config.StripPortMode = &envoy_http_v3.HttpConnectionManager_StripAnyHostPort{
 		StripAnyHostPort: true,
 	}
-	newFilter, err := makeFilter("envoy.filters.network.http_connection_manager", config)
+
+	// Access logs configured in the proxy defaults are kept.
+	accessLogs, err := accesslogs.MakeAccessLogs(p.accessLogsConfig(), false)
+	if err != nil {
+		return filter, false, fmt.Errorf("failed to make access logs: %w", err)
+	}
+	config.AccessLog = append(config.AccessLog, accessLogs...)
+
+	newFilter, err := xdscommon.MakeFilter(xdscommon.HTTPConnectionManagerFilterName, config)
 	if err != nil {
 		return filter, false, errors.New("error making new filter")
 	}
@@ -2,46 +2,176 @@ package serverlessplugin
 
 import (
 	"testing"
+	"time"
 
+	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_lambda_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/aws_lambda/v3"
+	envoy_lua_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
+	envoy_http_router_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	envoy_upstreams_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	envoy_resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/golang/protobuf/jsonpb"
+	pstruct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/xds/xdscommon"
+	"github.com/hashicorp/consul/proto/prototest"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/hashicorp/consul/api"
 )
 
 func TestMakeLambdaPatcher(t *testing.T) {
 	kind := api.ServiceKindTerminatingGateway
+	arn := "arn:aws:lambda:us-east-1:111111111111:function:lambda-1234"
 	cases := []struct {
 		name               string
+		extensionName      string
 		arn                string
 		payloadPassthrough bool
 		region             string
+		invocationMode     string
 		expected           lambdaPatcher
 		ok                 bool
+		expectedErr        string
 	}{
 		{
-			name: "no extension",
-			ok:   false,
+			name:          "unknown extension",
+			extensionName: "builtin/unknown",
+			ok:            false,
 		},
 		{
-			name:   "missing arn",
-			region: "blah",
-			ok:     false,
+			name:        "missing arn",
+			region:      "us-east-1",
+			ok:          false,
+			expectedErr: "ARN is required",
 		},
 		{
-			name: "missing region",
-			arn:  "arn",
-			ok:   false,
+			name:        "malformed arn",
+			arn:         "arn",
+			region:      "us-east-1",
+			ok:          false,
+			expectedErr: `ARN "arn" is not a valid Lambda function ARN`,
+		},
+		{
+			name: "version qualified arn",
+			arn:  arn + ":3",
+			expected: lambdaPatcher{
+				ARN:    arn + ":3",
+				Region: "us-east-1",
+				Kind:   kind,
+			},
+			ok: true,
+		},
+		{
+			name: "alias qualified arn",
+			arn:  arn + ":PROD",
+			expected: lambdaPatcher{
+				ARN:    arn + ":PROD",
+				Region: "us-east-1",
+				Kind:   kind,
+			},
+			ok: true,
+		},
+		{
+			name: "latest qualified arn",
+			arn:  arn + ":$LATEST",
+			expected: lambdaPatcher{
+				ARN:    arn + ":$LATEST",
+				Region: "us-east-1",
+				Kind:   kind,
+			},
+			ok: true,
+		},
+		{
+			name:        "empty qualifier",
+			arn:         arn + ":",
+			ok:          false,
+			expectedErr: `ARN "` + arn + `:" has an invalid qualifier ""`,
+		},
+		{
+			name:        "too many arn parts",
+			arn:         arn + ":PROD:extra",
+			ok:          false,
+			expectedErr: `ARN "` + arn + `:PROD:extra" is not a valid Lambda function ARN`,
+		},
+		{
+			name:        "missing region",
+			arn:         "arn:aws:lambda::111111111111:function:lambda-1234",
+			ok:          false,
+			expectedErr: "Region is required",
+		},
+		{
+			name: "region from arn",
+			arn:  arn,
+			expected: lambdaPatcher{
+				ARN:    arn,
+				Region: "us-east-1",
+				Kind:   kind,
+			},
+			ok: true,
+		},
+		{
+			name:   "region matching arn",
+			arn:    arn,
+			region: "us-east-1",
+			expected: lambdaPatcher{
+				ARN:    arn,
+				Region: "us-east-1",
+				Kind:   kind,
+			},
+			ok: true,
+		},
+		{
+			name:        "region contradicting arn",
+			arn:         arn,
+			region:      "us-west-2",
+			ok:          false,
+			expectedErr: `Region "us-west-2" does not match the region "us-east-1" of the ARN`,
+		},
+		{
+			name:        "invalid region",
+			arn:         arn,
+			region:      "blah",
+			ok:          false,
+			expectedErr: `Region "blah" is not a valid AWS region`,
+		},
+		{
+			name:           "invalid invocation mode",
+			arn:            arn,
+			region:         "us-east-1",
+			invocationMode: "Event",
+			ok:             false,
+			expectedErr:    `InvocationMode "Event" is not supported; must be "synchronous" or "asynchronous"`,
+		},
+		{
+			name:           "asynchronous invocation mode",
+			arn:            arn,
+			region:         "us-east-1",
+			invocationMode: "asynchronous",
+			expected: lambdaPatcher{
+				ARN:            arn,
+				Region:         "us-east-1",
+				InvocationMode: "asynchronous",
+				Kind:           kind,
+			},
+			ok: true,
 		},
 		{
 			name:               "including payload passthrough",
-			arn:                "arn",
-			region:             "blah",
+			arn:                arn,
+			region:             "us-east-1",
 			payloadPassthrough: true,
 			expected: lambdaPatcher{
-				ARN:                "arn",
+				ARN:                arn,
 				PayloadPassthrough: true,
-				Region:             "blah",
+				Region:             "us-east-1",
 				Kind:               kind,
 			},
 			ok: true,
@@ -50,16 +180,27 @@ func TestMakeLambdaPatcher(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
+			extensionName := tc.extensionName
+			if extensionName == "" {
+				extensionName = structs.BuiltinAWSLambdaExtension
+			}
 			ext := api.EnvoyExtension{
-				Name: structs.BuiltinAWSLambdaExtension,
+				Name: extensionName,
 				Arguments: map[string]interface{}{
 					"ARN":                tc.arn,
 					"Region":             tc.region,
 					"PayloadPassthrough": tc.payloadPassthrough,
+					"InvocationMode":     tc.invocationMode,
 				},
 			}
 
-			patcher, ok := makeLambdaPatcher(ext, kind)
+			patcher, ok, err := makeLambdaPatcher(ext, kind)
+
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
 
 			require.Equal(t, tc.ok, ok)
 
@@ -69,3 +210,882 @@ func TestMakeLambdaPatcher(t *testing.T) {
 		})
 	}
 }
+
+func TestLambdaPatcher_PatchFilter_PayloadPassthrough(t *testing.T) {
+	cases := []struct {
+		name               string
+		payloadPassthrough bool
+	}{
+		{
+			name: "default",
+		},
+		{
+			name:               "payload passthrough enabled",
+			payloadPassthrough: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := lambdaPatcher{
+				ARN:                "arn",
+				PayloadPassthrough: tc.payloadPassthrough,
+				Region:             "us-east-1",
+				Kind:               api.ServiceKindConnectProxy,
+			}
+
+			filter, ok, err := p.PatchFilter(makeTestHTTPConnectionManagerFilter(t))
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			lambdaFilter := getTestLambdaHTTPFilter(t, filter)
+			dump, err := (&jsonpb.Marshaler{}).MarshalToString(lambdaFilter)
+			require.NoError(t, err)
+
+			if tc.payloadPassthrough {
+				require.Contains(t, dump, `"payloadPassthrough":true`)
+			} else {
+				require.NotContains(t, dump, "payloadPassthrough")
+			}
+		})
+	}
+}
+
+func TestLambdaPatcher_PatchFilter_QualifiedARN(t *testing.T) {
+	const arn = "arn:aws:lambda:us-east-1:111111111111:function:lambda-1234"
+
+	cases := map[string]string{
+		"unqualified":       arn,
+		"version qualified": arn + ":3",
+		"alias qualified":   arn + ":PROD",
+	}
+
+	for name, arn := range cases {
+		t.Run(name, func(t *testing.T) {
+			ext := api.EnvoyExtension{
+				Name:      structs.BuiltinAWSLambdaExtension,
+				Arguments: map[string]interface{}{"ARN": arn},
+			}
+			p, ok, err := makeLambdaPatcher(ext, api.ServiceKindConnectProxy)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			filter, ok, err := p.PatchFilter(makeTestHTTPConnectionManagerFilter(t))
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, arn, getTestLambdaHTTPFilter(t, filter).Arn)
+		})
+	}
+}
+
+func TestLambdaPatcher_PatchFilter_InvocationMode(t *testing.T) {
+	cases := []struct {
+		mode     string
+		expected envoy_lambda_v3.Config_InvocationMode
+	}{
+		{
+			mode:     "",
+			expected: envoy_lambda_v3.Config_SYNCHRONOUS,
+		},
+		{
+			mode:     "synchronous",
+			expected: envoy_lambda_v3.Config_SYNCHRONOUS,
+		},
+		{
+			mode:     "asynchronous",
+			expected: envoy_lambda_v3.Config_ASYNCHRONOUS,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.mode, func(t *testing.T) {
+			p := lambdaPatcher{
+				ARN:            "arn",
+				Region:         "us-east-1",
+				InvocationMode: tc.mode,
+				Kind:           api.ServiceKindConnectProxy,
+			}
+
+			filter, ok, err := p.PatchFilter(makeTestHTTPConnectionManagerFilter(t))
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, tc.expected, getTestLambdaHTTPFilter(t, filter).InvocationMode)
+		})
+	}
+}
+
+func TestLambdaPatcher_PatchFilter_AccessLog(t *testing.T) {
+	cases := []struct {
+		name       string
+		accessLog  bool
+		path       string
+		format     string
+		expectLogs []string
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name:      "stdout with default format",
+			accessLog: true,
+			expectLogs: []string{
+				`"@type":"type.googleapis.com/envoy.extensions.access_loggers.stream.v3.StdoutAccessLog"`,
+				`"jsonFormat":{`,
+				`"upstream_cluster":"%UPSTREAM_CLUSTER%"`,
+			},
+		},
+		{
+			name:      "file with custom format",
+			accessLog: true,
+			path:      "/var/log/lambda.log",
+			format:    "[%START_TIME%] %RESPONSE_CODE%",
+			expectLogs: []string{
+				`"@type":"type.googleapis.com/envoy.extensions.access_loggers.file.v3.FileAccessLog"`,
+				`"path":"/var/log/lambda.log"`,
+				`"inlineString":"[%START_TIME%] %RESPONSE_CODE%\n"`,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := lambdaPatcher{
+				ARN:             "arn",
+				Region:          "us-east-1",
+				Kind:            api.ServiceKindConnectProxy,
+				AccessLog:       tc.accessLog,
+				AccessLogPath:   tc.path,
+				AccessLogFormat: tc.format,
+			}
+
+			filter, ok, err := p.PatchFilter(makeTestHTTPConnectionManagerFilter(t))
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			hcm := envoy_resource_v3.GetHTTPConnectionManager(filter)
+			require.NotNil(t, hcm)
+
+			if !tc.accessLog {
+				require.Empty(t, hcm.AccessLog)
+				return
+			}
+
+			require.Len(t, hcm.AccessLog, 1)
+			dump, err := (&jsonpb.Marshaler{}).MarshalToString(hcm.AccessLog[0])
+			require.NoError(t, err)
+			for _, expected := range tc.expectLogs {
+				require.Contains(t, dump, expected)
+			}
+		})
+	}
+}
+
+func makeTestHTTPConnectionManagerFilter(t *testing.T) *envoy_listener_v3.Filter {
+	router, err := xdscommon.MakeEnvoyHTTPFilter("envoy.filters.http.router", &envoy_http_router_v3.Router{})
+	require.NoError(t, err)
+
+	filter, err := xdscommon.MakeFilter("envoy.filters.network.http_connection_manager", &envoy_http_v3.HttpConnectionManager{
+		StatPrefix:  "upstream.db.default.default.dc1",
+		HttpFilters: []*envoy_http_v3.HttpFilter{router},
+	})
+	require.NoError(t, err)
+
+	return filter
+}
+
+func getTestLambdaHTTPFilter(t *testing.T, filter *envoy_listener_v3.Filter) *envoy_lambda_v3.Config {
+	hcm := envoy_resource_v3.GetHTTPConnectionManager(filter)
+	require.NotNil(t, hcm)
+
+	for _, httpFilter := range hcm.HttpFilters {
+		if httpFilter.Name != "envoy.filters.http.aws_lambda" {
+			continue
+		}
+		var config envoy_lambda_v3.Config
+		require.NoError(t, httpFilter.GetTypedConfig().UnmarshalTo(&config))
+		return &config
+	}
+
+	t.Fatal("aws_lambda http filter not found")
+	return nil
+}
+
+func TestLambdaPatcher_PatchRoute_RetryPolicy(t *testing.T) {
+	lambdaSNI := "lambda.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"
+	siblingSNI := "web.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"
+
+	makeRoute := func(cluster string) *envoy_route_v3.Route {
+		return &envoy_route_v3.Route{
+			Action: &envoy_route_v3.Route_Route{
+				Route: &envoy_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{Cluster: cluster},
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		name       string
+		numRetries uint32
+		retryOn    []string
+		expected   *envoy_route_v3.RetryPolicy
+	}{
+		{
+			name: "no retries",
+		},
+		{
+			name:       "retries",
+			numRetries: 3,
+			retryOn:    []string{"5xx", "connect-failure"},
+			expected: &envoy_route_v3.RetryPolicy{
+				RetryOn:    "5xx,connect-failure",
+				NumRetries: &wrappers.UInt32Value{Value: 3},
+			},
+		},
+		{
+			name:       "retries on 5xx by default",
+			numRetries: 3,
+			expected: &envoy_route_v3.RetryPolicy{
+				RetryOn:    "5xx",
+				NumRetries: &wrappers.UInt32Value{Value: 3},
+			},
+		},
+	}
+
+	for _, kind := range []api.ServiceKind{api.ServiceKindConnectProxy, api.ServiceKindTerminatingGateway} {
+		lambdaService := api.CompoundServiceName{Name: "lambda", Namespace: "default", Partition: "default"}
+		config := xdscommon.ExtensionConfiguration{
+			ServiceName: lambdaService,
+			Kind:        kind,
+			Upstreams: map[api.CompoundServiceName]xdscommon.UpstreamData{
+				lambdaService: {
+					SNI:               map[string]struct{}{lambdaSNI: {}},
+					OutgoingProxyKind: kind,
+				},
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(string(kind)+"/"+tc.name, func(t *testing.T) {
+				p := lambdaPatcher{
+					ARN:        "arn",
+					Region:     "us-east-1",
+					Kind:       kind,
+					NumRetries: tc.numRetries,
+					RetryOn:    tc.retryOn,
+				}
+
+				route, patched, err := p.PatchRoute(config, &envoy_route_v3.RouteConfiguration{
+					Name: lambdaSNI,
+					VirtualHosts: []*envoy_route_v3.VirtualHost{
+						{
+							Name:   lambdaSNI,
+							Routes: []*envoy_route_v3.Route{makeRoute(lambdaSNI), makeRoute(siblingSNI)},
+						},
+					},
+				})
+				require.NoError(t, err)
+				require.True(t, patched)
+
+				routes := route.VirtualHosts[0].Routes
+				if tc.expected == nil {
+					require.Nil(t, routes[0].GetRoute().RetryPolicy)
+				} else {
+					prototest.AssertDeepEqual(t, tc.expected, routes[0].GetRoute().RetryPolicy)
+				}
+				require.Nil(t, routes[1].GetRoute().RetryPolicy)
+
+				// Route configurations without a route to the Lambda aren't
+				// patched.
+				_, patched, err = p.PatchRoute(config, &envoy_route_v3.RouteConfiguration{
+					Name: siblingSNI,
+					VirtualHosts: []*envoy_route_v3.VirtualHost{
+						{
+							Name:   siblingSNI,
+							Routes: []*envoy_route_v3.Route{makeRoute(siblingSNI)},
+						},
+					},
+				})
+				require.NoError(t, err)
+				require.False(t, patched)
+			})
+		}
+	}
+}
+
+func TestExtend_LambdaConnectProxyInlineRoute(t *testing.T) {
+	config := makeTestLambdaExtensionConfiguration(api.ServiceKindConnectProxy)
+	config.EnvoyExtension.Arguments["NumRetries"] = 2
+
+	hcmConfig := envoy_resource_v3.GetHTTPConnectionManager(makeTestHTTPConnectionManagerFilter(t))
+	hcmConfig.RouteSpecifier = &envoy_http_v3.HttpConnectionManager_RouteConfig{
+		RouteConfig: &envoy_route_v3.RouteConfiguration{
+			VirtualHosts: []*envoy_route_v3.VirtualHost{{
+				Routes: []*envoy_route_v3.Route{{
+					Action: &envoy_route_v3.Route_Route{
+						Route: &envoy_route_v3.RouteAction{
+							ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{Cluster: testLambdaSNI},
+						},
+					},
+				}},
+			}},
+		},
+	}
+	hcm, err := xdscommon.MakeFilter(xdscommon.HTTPConnectionManagerFilterName, hcmConfig)
+	require.NoError(t, err)
+
+	listener := &envoy_listener_v3.Listener{
+		Name: "lambda:127.0.0.1:9191",
+		FilterChains: []*envoy_listener_v3.FilterChain{
+			{Filters: []*envoy_listener_v3.Filter{hcm}},
+		},
+	}
+	resources := xdscommon.EmptyIndexedResources()
+	resources.Index[xdscommon.ListenerType][listener.Name] = listener
+
+	resources, err = Extend(resources, config)
+	require.NoError(t, err)
+
+	patched := resources.Index[xdscommon.ListenerType][listener.Name].(*envoy_listener_v3.Listener).FilterChains[0].Filters[0]
+	getTestLambdaHTTPFilter(t, patched)
+
+	patchedHCM := envoy_resource_v3.GetHTTPConnectionManager(patched)
+	prototest.AssertDeepEqual(t, &envoy_route_v3.RetryPolicy{
+		RetryOn:    "5xx",
+		NumRetries: &wrappers.UInt32Value{Value: 2},
+	}, patchedHCM.GetRouteConfig().VirtualHosts[0].Routes[0].GetRoute().RetryPolicy)
+}
+
+func TestLambdaPatcher_PatchRoute_Headers(t *testing.T) {
+	lambdaSNI := "lambda.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"
+	siblingSNI := "web.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"
+
+	makeRoute := func(cluster string) *envoy_route_v3.Route {
+		return &envoy_route_v3.Route{
+			Action: &envoy_route_v3.Route_Route{
+				Route: &envoy_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{Cluster: cluster},
+				},
+			},
+		}
+	}
+	makeRouteConfig := func() *envoy_route_v3.RouteConfiguration {
+		return &envoy_route_v3.RouteConfiguration{
+			Name: lambdaSNI,
+			VirtualHosts: []*envoy_route_v3.VirtualHost{
+				{
+					Name:   lambdaSNI,
+					Routes: []*envoy_route_v3.Route{makeRoute(lambdaSNI), makeRoute(siblingSNI)},
+				},
+			},
+		}
+	}
+	makeHeader := func(key, value string) *envoy_core_v3.HeaderValueOption {
+		return &envoy_core_v3.HeaderValueOption{
+			Header: &envoy_core_v3.HeaderValue{Key: key, Value: value},
+			Append: &wrappers.BoolValue{Value: false},
+		}
+	}
+
+	for _, kind := range []api.ServiceKind{api.ServiceKindConnectProxy, api.ServiceKindTerminatingGateway} {
+		lambdaService := api.CompoundServiceName{Name: "lambda", Namespace: "default", Partition: "default"}
+		config := xdscommon.ExtensionConfiguration{
+			ServiceName: lambdaService,
+			Kind:        kind,
+			Upstreams: map[api.CompoundServiceName]xdscommon.UpstreamData{
+				lambdaService: {
+					SNI:               map[string]struct{}{lambdaSNI: {}},
+					OutgoingProxyKind: kind,
+				},
+			},
+		}
+
+		t.Run(string(kind)+"/headers", func(t *testing.T) {
+			p := lambdaPatcher{
+				ARN:    "arn",
+				Region: "us-east-1",
+				Kind:   kind,
+				RequestHeadersToAdd: map[string]string{
+					"x-api-key":    "secret",
+					"x-api-client": "consul",
+				},
+				RequestHeadersToRemove:  []string{"authorization"},
+				ResponseHeadersToAdd:    map[string]string{"x-served-by": "lambda"},
+				ResponseHeadersToRemove: []string{"x-amzn-requestid"},
+			}
+
+			route, patched, err := p.PatchRoute(config, makeRouteConfig())
+			require.NoError(t, err)
+			require.True(t, patched)
+
+			routes := route.VirtualHosts[0].Routes
+			prototest.AssertDeepEqual(t, []*envoy_core_v3.HeaderValueOption{
+				makeHeader("x-api-client", "consul"),
+				makeHeader("x-api-key", "secret"),
+			}, routes[0].RequestHeadersToAdd)
+			require.Equal(t, []string{"authorization"}, routes[0].RequestHeadersToRemove)
+			prototest.AssertDeepEqual(t, []*envoy_core_v3.HeaderValueOption{
+				makeHeader("x-served-by", "lambda"),
+			}, routes[0].ResponseHeadersToAdd)
+			require.Equal(t, []string{"x-amzn-requestid"}, routes[0].ResponseHeadersToRemove)
+
+			prototest.AssertDeepEqual(t, makeRoute(siblingSNI), routes[1])
+		})
+
+		t.Run(string(kind)+"/no headers", func(t *testing.T) {
+			p := lambdaPatcher{
+				ARN:    "arn",
+				Region: "us-east-1",
+				Kind:   kind,
+			}
+
+			route, patched, err := p.PatchRoute(config, makeRouteConfig())
+			require.NoError(t, err)
+			require.True(t, patched)
+			prototest.AssertDeepEqual(t, makeRouteConfig(), route)
+		})
+	}
+}
+
+func TestLambdaPatcher_PatchRoute_HostRewrite(t *testing.T) {
+	config := makeTestLambdaExtensionConfiguration(api.ServiceKindTerminatingGateway)
+
+	makeRoute := func(cluster string) *envoy_route_v3.Route {
+		return &envoy_route_v3.Route{
+			Action: &envoy_route_v3.Route_Route{
+				Route: &envoy_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{Cluster: cluster},
+					HostRewriteSpecifier: &envoy_route_v3.RouteAction_AutoHostRewrite{
+						AutoHostRewrite: &wrappers.BoolValue{Value: true},
+					},
+				},
+			},
+		}
+	}
+
+	p := lambdaPatcher{
+		ARN:    "arn",
+		Region: "us-east-1",
+		Kind:   api.ServiceKindTerminatingGateway,
+	}
+
+	route, patched, err := p.PatchRoute(config, &envoy_route_v3.RouteConfiguration{
+		Name: testLambdaSNI,
+		VirtualHosts: []*envoy_route_v3.VirtualHost{
+			{
+				Name:   testLambdaSNI,
+				Routes: []*envoy_route_v3.Route{makeRoute(testLambdaSNI), makeRoute(testSiblingSNI)},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, patched)
+
+	// Only the route to the Lambda loses its host rewrite.
+	routes := route.VirtualHosts[0].Routes
+	require.Nil(t, routes[0].GetRoute().HostRewriteSpecifier)
+	require.True(t, routes[1].GetRoute().GetAutoHostRewrite().GetValue())
+}
+
+func TestLambdaPatcher_PatchRoute_WeightedClusters(t *testing.T) {
+	config := makeTestLambdaExtensionConfiguration(api.ServiceKindTerminatingGateway)
+
+	makeClusterWeight := func(name string, weight uint32) *envoy_route_v3.WeightedCluster_ClusterWeight {
+		return &envoy_route_v3.WeightedCluster_ClusterWeight{
+			Name:   name,
+			Weight: &wrappers.UInt32Value{Value: weight},
+			HostRewriteSpecifier: &envoy_route_v3.WeightedCluster_ClusterWeight_HostRewriteLiteral{
+				HostRewriteLiteral: name,
+			},
+		}
+	}
+
+	p := lambdaPatcher{
+		ARN:        "arn",
+		Region:     "us-east-1",
+		Kind:       api.ServiceKindTerminatingGateway,
+		NumRetries: 2,
+	}
+
+	route, patched, err := p.PatchRoute(config, &envoy_route_v3.RouteConfiguration{
+		Name: testLambdaSNI,
+		VirtualHosts: []*envoy_route_v3.VirtualHost{
+			{
+				Name: testLambdaSNI,
+				Routes: []*envoy_route_v3.Route{
+					{
+						Action: &envoy_route_v3.Route_Route{
+							Route: &envoy_route_v3.RouteAction{
+								ClusterSpecifier: &envoy_route_v3.RouteAction_WeightedClusters{
+									WeightedClusters: &envoy_route_v3.WeightedCluster{
+										Clusters: []*envoy_route_v3.WeightedCluster_ClusterWeight{
+											makeClusterWeight(testLambdaSNI, 9000),
+											makeClusterWeight(testSiblingSNI, 1000),
+										},
+										TotalWeight: &wrappers.UInt32Value{Value: 10000},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, patched)
+
+	action := route.VirtualHosts[0].Routes[0].GetRoute()
+	require.NotNil(t, action.RetryPolicy)
+
+	clusters := action.GetWeightedClusters().Clusters
+	require.Len(t, clusters, 2)
+	require.Nil(t, clusters[0].HostRewriteSpecifier)
+	require.Equal(t, testSiblingSNI, clusters[1].GetHostRewriteLiteral())
+
+	var sum uint32
+	for _, cluster := range clusters {
+		sum += cluster.Weight.Value
+	}
+	require.Equal(t, action.GetWeightedClusters().TotalWeight.Value, sum)
+}
+
+func TestLambdaPatcher_PatchFilter_PreservesHTTPFilters(t *testing.T) {
+	lua, err := xdscommon.MakeEnvoyHTTPFilter("envoy.filters.http.lua", &envoy_lua_v3.Lua{
+		InlineCode: "function envoy_on_request(request_handle) end",
+	})
+	require.NoError(t, err)
+	router, err := xdscommon.MakeEnvoyHTTPFilter("envoy.filters.http.router", &envoy_http_router_v3.Router{})
+	require.NoError(t, err)
+
+	filter, err := xdscommon.MakeFilter("envoy.filters.network.http_connection_manager", &envoy_http_v3.HttpConnectionManager{
+		StatPrefix:  "upstream.db.default.default.dc1",
+		HttpFilters: []*envoy_http_v3.HttpFilter{lua, router},
+	})
+	require.NoError(t, err)
+
+	p := lambdaPatcher{
+		ARN:    "arn",
+		Region: "us-east-1",
+		Kind:   api.ServiceKindConnectProxy,
+	}
+
+	newFilter, ok, err := p.PatchFilter(filter)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	hcm := envoy_resource_v3.GetHTTPConnectionManager(newFilter)
+	require.NotNil(t, hcm)
+
+	var names []string
+	for _, httpFilter := range hcm.HttpFilters {
+		names = append(names, httpFilter.Name)
+	}
+	require.Equal(t, []string{
+		"envoy.filters.http.lua",
+		"envoy.filters.http.aws_lambda",
+		"envoy.filters.http.router",
+	}, names)
+
+	var luaConfig envoy_lua_v3.Lua
+	require.NoError(t, hcm.HttpFilters[0].GetTypedConfig().UnmarshalTo(&luaConfig))
+	require.Equal(t, "function envoy_on_request(request_handle) end", luaConfig.InlineCode)
+}
+
+func TestLambdaPatcher_PatchCluster(t *testing.T) {
+	p := lambdaPatcher{
+		ARN:    "arn:aws:lambda:us-east-1:111111111111:function:lambda",
+		Region: "us-east-1",
+		Kind:   api.ServiceKindTerminatingGateway,
+	}
+
+	cluster, patched, err := p.PatchCluster(xdscommon.ExtensionConfiguration{}, &envoy_cluster_v3.Cluster{Name: testLambdaSNI})
+	require.NoError(t, err)
+	require.True(t, patched)
+
+	require.Equal(t, testLambdaSNI, cluster.Name)
+	require.Equal(t, envoy_cluster_v3.Cluster_LOGICAL_DNS, cluster.GetType())
+	require.Equal(t, envoy_cluster_v3.Cluster_AUTO, cluster.DnsLookupFamily)
+
+	addr := cluster.LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress()
+	require.Equal(t, "lambda.us-east-1.amazonaws.com", addr.Address)
+	require.Equal(t, uint32(443), addr.GetPortValue())
+}
+
+func TestLambdaPatcher_PatchCluster_ServiceStatName(t *testing.T) {
+	cases := map[string]struct {
+		statName bool
+		service  api.CompoundServiceName
+		expected string
+	}{
+		"default": {
+			service: api.CompoundServiceName{Name: "db", Namespace: "default"},
+		},
+		"service stat name": {
+			statName: true,
+			service:  api.CompoundServiceName{Name: "db", Namespace: "default"},
+			expected: "lambda.db.default",
+		},
+		"partition": {
+			statName: true,
+			service:  api.CompoundServiceName{Name: "db", Namespace: "ns", Partition: "part"},
+			expected: "lambda.db.ns.part",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ext := api.EnvoyExtension{
+				Name: structs.BuiltinAWSLambdaExtension,
+				Arguments: map[string]interface{}{
+					"ARN":             "arn:aws:lambda:us-east-1:111111111111:function:lambda",
+					"ServiceStatName": tc.statName,
+				},
+			}
+
+			p, ok, err := makeLambdaPatcher(ext, api.ServiceKindTerminatingGateway)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			config := xdscommon.ExtensionConfiguration{EnvoyExtension: ext, ServiceName: tc.service}
+			cluster, patched, err := p.PatchCluster(config, &envoy_cluster_v3.Cluster{Name: testLambdaSNI})
+			require.NoError(t, err)
+			require.True(t, patched)
+			require.Equal(t, testLambdaSNI, cluster.Name)
+			require.Equal(t, tc.expected, cluster.AltStatName)
+		})
+	}
+}
+
+func TestLambdaPatcher_PatchCluster_SNI(t *testing.T) {
+	cases := map[string]struct {
+		sni      string
+		expected string
+	}{
+		"default": {
+			expected: "*.amazonaws.com",
+		},
+		"override": {
+			sni:      "vpce-1234.lambda.us-east-1.vpce.amazonaws.com",
+			expected: "vpce-1234.lambda.us-east-1.vpce.amazonaws.com",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ext := api.EnvoyExtension{
+				Name: structs.BuiltinAWSLambdaExtension,
+				Arguments: map[string]interface{}{
+					"ARN":    "arn:aws:lambda:us-east-1:111111111111:function:lambda",
+					"Region": "us-east-1",
+					"SNI":    tc.sni,
+				},
+			}
+
+			p, ok, err := makeLambdaPatcher(ext, api.ServiceKindTerminatingGateway)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			cluster, patched, err := p.PatchCluster(xdscommon.ExtensionConfiguration{}, &envoy_cluster_v3.Cluster{Name: testLambdaSNI})
+			require.NoError(t, err)
+			require.True(t, patched)
+
+			var tlsContext envoy_tls_v3.UpstreamTlsContext
+			require.NoError(t, cluster.TransportSocket.GetTypedConfig().UnmarshalTo(&tlsContext))
+			require.Equal(t, tc.expected, tlsContext.Sni)
+		})
+	}
+}
+
+func TestLambdaPatcher_PatchCluster_Protocol(t *testing.T) {
+	cases := map[string]struct {
+		protocol    string
+		http2       bool
+		expectedErr string
+	}{
+		"default": {},
+		"http": {
+			protocol: "http",
+		},
+		"http2": {
+			protocol: "http2",
+			http2:    true,
+		},
+		"unsupported": {
+			protocol:    "grpc",
+			expectedErr: `Protocol "grpc" is not supported; must be "http" or "http2"`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ext := api.EnvoyExtension{
+				Name: structs.BuiltinAWSLambdaExtension,
+				Arguments: map[string]interface{}{
+					"ARN":      "arn:aws:lambda:us-east-1:111111111111:function:lambda",
+					"Region":   "us-east-1",
+					"Protocol": tc.protocol,
+				},
+			}
+
+			p, ok, err := makeLambdaPatcher(ext, api.ServiceKindTerminatingGateway)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			cluster, patched, err := p.PatchCluster(xdscommon.ExtensionConfiguration{}, &envoy_cluster_v3.Cluster{Name: testLambdaSNI})
+			require.NoError(t, err)
+			require.True(t, patched)
+
+			var tlsContext envoy_tls_v3.UpstreamTlsContext
+			require.NoError(t, cluster.TransportSocket.GetTypedConfig().UnmarshalTo(&tlsContext))
+
+			if !tc.http2 {
+				require.Empty(t, tlsContext.GetCommonTlsContext().GetAlpnProtocols())
+				require.Empty(t, cluster.TypedExtensionProtocolOptions)
+				return
+			}
+
+			require.Equal(t, []string{"h2"}, tlsContext.GetCommonTlsContext().GetAlpnProtocols())
+
+			protocolOptions, ok := cluster.TypedExtensionProtocolOptions["envoy.extensions.upstreams.http.v3.HttpProtocolOptions"]
+			require.True(t, ok)
+			var httpOptions envoy_upstreams_v3.HttpProtocolOptions
+			require.NoError(t, protocolOptions.UnmarshalTo(&httpOptions))
+			require.NotNil(t, httpOptions.GetExplicitHttpConfig().GetHttp2ProtocolOptions())
+		})
+	}
+}
+
+func TestLambdaPatcher_PatchCluster_TransportSocketMatches(t *testing.T) {
+	p := lambdaPatcher{
+		ARN:    "arn:aws:lambda:us-east-1:111111111111:function:lambda",
+		Region: "us-east-1",
+		Kind:   api.ServiceKindTerminatingGateway,
+	}
+
+	mtlsSocket := &envoy_core_v3.TransportSocket{Name: "envoy.transport_sockets.tls"}
+	existing := &envoy_cluster_v3.Cluster_TransportSocketMatch{
+		Name: "mtls",
+		Match: &pstruct.Struct{
+			Fields: map[string]*pstruct.Value{
+				"mtls": {Kind: &pstruct.Value_BoolValue{BoolValue: true}},
+			},
+		},
+		TransportSocket: mtlsSocket,
+	}
+
+	cluster, patched, err := p.PatchCluster(xdscommon.ExtensionConfiguration{}, &envoy_cluster_v3.Cluster{
+		Name:                   testLambdaSNI,
+		TransportSocket:        mtlsSocket,
+		TransportSocketMatches: []*envoy_cluster_v3.Cluster_TransportSocketMatch{existing},
+	})
+	require.NoError(t, err)
+	require.True(t, patched)
+
+	// The Lambda TLS config is applied to the cluster.
+	var tlsContext envoy_tls_v3.UpstreamTlsContext
+	require.NoError(t, cluster.TransportSocket.GetTypedConfig().UnmarshalTo(&tlsContext))
+	require.Equal(t, "*.amazonaws.com", tlsContext.Sni)
+
+	// The cluster is replaced, so the matches selecting its original
+	// endpoints are dropped and the Lambda endpoint uses the Lambda TLS
+	// transport socket.
+	require.Empty(t, cluster.TransportSocketMatches)
+	require.Len(t, cluster.LoadAssignment.Endpoints, 1)
+	require.Len(t, cluster.LoadAssignment.Endpoints[0].LbEndpoints, 1)
+	endpoint := cluster.LoadAssignment.Endpoints[0].LbEndpoints[0]
+	require.Equal(t, "lambda.us-east-1.amazonaws.com", endpoint.GetEndpoint().GetAddress().GetSocketAddress().GetAddress())
+	require.Nil(t, endpoint.Metadata)
+}
+
+func TestLambdaPatcher_PatchCluster_IdleTimeout(t *testing.T) {
+	cases := map[string]struct {
+		idleTimeout string
+		protocol    string
+		expected    *envoy_upstreams_v3.HttpProtocolOptions
+		expectedErr string
+	}{
+		"default": {},
+		"idle timeout": {
+			idleTimeout: "5m",
+			expected: &envoy_upstreams_v3.HttpProtocolOptions{
+				CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{
+					IdleTimeout: durationpb.New(5 * time.Minute),
+				},
+				UpstreamProtocolOptions: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+					ExplicitHttpConfig: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig{
+						ProtocolConfig: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig_HttpProtocolOptions{
+							HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{},
+						},
+					},
+				},
+			},
+		},
+		"idle timeout with http2": {
+			idleTimeout: "90s",
+			protocol:    "http2",
+			expected: &envoy_upstreams_v3.HttpProtocolOptions{
+				CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{
+					IdleTimeout: durationpb.New(90 * time.Second),
+				},
+				UpstreamProtocolOptions: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+					ExplicitHttpConfig: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig{
+						ProtocolConfig: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{
+							Http2ProtocolOptions: &envoy_core_v3.Http2ProtocolOptions{},
+						},
+					},
+				},
+			},
+		},
+		"invalid": {
+			idleTimeout: "forever",
+			expectedErr: `IdleTimeout "forever" is not a valid duration`,
+		},
+		"negative": {
+			idleTimeout: "-1s",
+			expectedErr: "IdleTimeout must be positive",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ext := api.EnvoyExtension{
+				Name: structs.BuiltinAWSLambdaExtension,
+				Arguments: map[string]interface{}{
+					"ARN":         "arn:aws:lambda:us-east-1:111111111111:function:lambda",
+					"Protocol":    tc.protocol,
+					"IdleTimeout": tc.idleTimeout,
+				},
+			}
+
+			p, ok, err := makeLambdaPatcher(ext, api.ServiceKindTerminatingGateway)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			cluster, patched, err := p.PatchCluster(xdscommon.ExtensionConfiguration{}, &envoy_cluster_v3.Cluster{Name: testLambdaSNI})
+			require.NoError(t, err)
+			require.True(t, patched)
+
+			if tc.expected == nil {
+				require.Empty(t, cluster.TypedExtensionProtocolOptions)
+				return
+			}
+
+			protocolOptions, ok := cluster.TypedExtensionProtocolOptions["envoy.extensions.upstreams.http.v3.HttpProtocolOptions"]
+			require.True(t, ok)
+			var httpOptions envoy_upstreams_v3.HttpProtocolOptions
+			require.NoError(t, protocolOptions.UnmarshalTo(&httpOptions))
+			prototest.AssertDeepEqual(t, tc.expected, &httpOptions)
+		})
+	}
+}
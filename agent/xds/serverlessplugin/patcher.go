@@ -1,7 +1,10 @@
 package serverlessplugin
 
 import (
+	"fmt"
+
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 
@@ -16,37 +19,72 @@ type patcher interface {
 	// CanPatch determines if the patcher can mutate resources for the given api.ServiceKind
 	CanPatch(api.ServiceKind) bool
 
-	// patchRoute patches a route to include the custom Envoy configuration
-	// PatchCluster patches a cluster to include the custom Envoy configuration
-	// required to integrate with the serverless integration.
-	PatchRoute(*envoy_route_v3.RouteConfiguration) (*envoy_route_v3.RouteConfiguration, bool, error)
+	// PatchRoute patches a route to include the custom Envoy configuration
+	// required to integrate with the serverless integration. The extension
+	// configuration is used to determine which routes within the route
+	// configuration target the upstream service.
+	PatchRoute(xdscommon.ExtensionConfiguration, *envoy_route_v3.RouteConfiguration) (*envoy_route_v3.RouteConfiguration, bool, error)
 
 	// PatchCluster patches a cluster to include the custom Envoy configuration
-	// required to integrate with the serverless integration.
-	PatchCluster(*envoy_cluster_v3.Cluster) (*envoy_cluster_v3.Cluster, bool, error)
+	// required to integrate with the serverless integration. The extension
+	// configuration identifies the upstream service the cluster targets.
+	PatchCluster(xdscommon.ExtensionConfiguration, *envoy_cluster_v3.Cluster) (*envoy_cluster_v3.Cluster, bool, error)
 
 	// PatchFilter patches an Envoy filter to include the custom Envoy
 	// configuration required to integrate with the serverless integration.
 	PatchFilter(*envoy_listener_v3.Filter) (*envoy_listener_v3.Filter, bool, error)
+
+	// PatchEndpoints patches the endpoints of a cluster to include the custom
+	// Envoy configuration required to integrate with the serverless
+	// integration. Patchers that don't modify endpoints can embed
+	// noopEndpointsPatcher.
+	PatchEndpoints(*envoy_endpoint_v3.ClusterLoadAssignment) (*envoy_endpoint_v3.ClusterLoadAssignment, bool, error)
+}
+
+// noopEndpointsPatcher implements PatchEndpoints for patchers that leave
+// endpoints untouched.
+type noopEndpointsPatcher struct{}
+
+func (noopEndpointsPatcher) PatchEndpoints(cla *envoy_endpoint_v3.ClusterLoadAssignment) (*envoy_endpoint_v3.ClusterLoadAssignment, bool, error) {
+	return cla, false, nil
 }
 
 type patchers map[api.CompoundServiceName]patcher
 
-func makePatcher(config xdscommon.ExtensionConfiguration) patcher {
+// canPatch implements CanPatch for patchers created for the given upstream
+// kind. Mesh gateways only route traffic by SNI without terminating it, so
+// there is nothing they could do with the serverless configuration and they
+// are never patched, whatever the upstream kind.
+func canPatch(upstreamKind, kind api.ServiceKind) bool {
+	if kind == api.ServiceKindMeshGateway {
+		return false
+	}
+	return kind == upstreamKind
+}
+
+// makePatcher returns the patcher for the extension. A nil patcher is returned
+// if no patcher handles the extension, and an error is returned if a patcher
+// handles the extension but its arguments are invalid.
+func makePatcher(config xdscommon.ExtensionConfiguration) (patcher, error) {
 	for _, constructor := range patchConstructors {
-		patcher, ok := constructor(config.EnvoyExtension, config.OutgoingProxyKind())
+		patcher, ok, err := constructor(config.EnvoyExtension, config.OutgoingProxyKind())
+		if err != nil {
+			return nil, fmt.Errorf("invalid arguments for extension %q: %w", config.EnvoyExtension.Name, err)
+		}
 		if ok {
-			return patcher
+			return patcher, nil
 		}
 	}
 
-	return nil
+	return nil, nil
 }
 
 // patchConstructor is used to construct patchers based on
 // xdscommon.ServiceConfig. This function contains all of the logic around
-// turning Meta data into the patcher.
-type patchConstructor func(extension api.EnvoyExtension, upstreamKind api.ServiceKind) (patcher, bool)
+// turning Meta data into the patcher. It returns false if the extension is
+// not handled by the patcher and an error if the extension's arguments are
+// invalid.
+type patchConstructor func(extension api.EnvoyExtension, upstreamKind api.ServiceKind) (patcher, bool, error)
 
 // patchConstructors contains all patchers that getPatchers tries to create.
-var patchConstructors = []patchConstructor{makeLambdaPatcher}
+var patchConstructors = []patchConstructor{makeLambdaPatcher, makeCloudRunPatcher}
@@ -5,23 +5,73 @@ import (
 	"strings"
 
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	"github.com/golang/protobuf/proto"
 	"github.com/hashicorp/go-multierror"
+	"github.com/mitchellh/mapstructure"
 
 	"github.com/hashicorp/consul/agent/xds/xdscommon"
 	"github.com/hashicorp/consul/api"
 )
 
+// PatchResult records a failure to patch a single xDS resource.
+type PatchResult struct {
+	// IndexType is the xDS type URL of the resource, e.g. xdscommon.ClusterType.
+	IndexType string
+
+	// NameOrSNI is the key of the resource in the xdscommon.IndexedResources.
+	NameOrSNI string
+
+	// Err is the error encountered while patching the resource.
+	Err error
+}
+
+// PatchError is returned by Extend when one or more resources could not be
+// patched. Callers can use errors.As to inspect the individual results.
+type PatchError struct {
+	Results []PatchResult
+}
+
+func (e *PatchError) Error() string {
+	var err error
+	for _, r := range e.Results {
+		err = multierror.Append(err, fmt.Errorf("error patching %s %q: %w", resourceTypeName(r.IndexType), r.NameOrSNI, r.Err))
+	}
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func resourceTypeName(indexType string) string {
+	switch indexType {
+	case xdscommon.ClusterType:
+		return "cluster"
+	case xdscommon.ListenerType:
+		return "listener"
+	case xdscommon.RouteType:
+		return "route"
+	case xdscommon.EndpointType:
+		return "endpoint"
+	}
+	return indexType
+}
+
 // Extend updates indexed xDS structures to include patches for
 // serverless integrations. It is responsible for constructing all of the
 // patchers and forwarding xDS structs onto the appropriate patcher. If any
 // portion of this function fails, it will record the error and continue. The
 // behavior is appropriate since the unpatched xDS structures this receives are
-// typically invalid.
+// typically invalid. Failures are returned as a *PatchError.
 func Extend(resources *xdscommon.IndexedResources, config xdscommon.ExtensionConfiguration) (*xdscommon.IndexedResources, error) {
-	var resultErr error
+	var results []PatchResult
+	recordErr := func(indexType, nameOrSNI string, err error) {
+		results = append(results, PatchResult{IndexType: indexType, NameOrSNI: nameOrSNI, Err: err})
+	}
 
 	switch config.Kind {
 	case api.ServiceKindTerminatingGateway, api.ServiceKindConnectProxy:
@@ -33,7 +83,10 @@ func Extend(resources *xdscommon.IndexedResources, config xdscommon.ExtensionCon
 		return resources, nil
 	}
 
-	patcher := makePatcher(config)
+	patcher, err := makePatcher(config)
+	if err != nil {
+		return resources, err
+	}
 	if patcher == nil {
 		return resources, nil
 	}
@@ -42,21 +95,28 @@ func Extend(resources *xdscommon.IndexedResources, config xdscommon.ExtensionCon
 		return resources, nil
 	}
 
+	exclude, err := excludeArgument(config.EnvoyExtension)
+	if err != nil {
+		return resources, fmt.Errorf("invalid arguments for extension %q: %w", config.EnvoyExtension.Name, err)
+	}
+	config.Exclude = append(config.Exclude, exclude...)
+
 	for _, indexType := range []string{
 		xdscommon.ClusterType,
 		xdscommon.ListenerType,
 		xdscommon.RouteType,
+		xdscommon.EndpointType,
 	} {
 		for nameOrSNI, msg := range resources.Index[indexType] {
 			switch resource := msg.(type) {
 			case *envoy_cluster_v3.Cluster:
-				if !config.MatchesUpstreamServiceSNI(nameOrSNI) {
+				if !matchesSNI(config, nameOrSNI) {
 					continue
 				}
 
-				newCluster, patched, err := patcher.PatchCluster(resource)
+				newCluster, patched, err := patcher.PatchCluster(config, resource)
 				if err != nil {
-					resultErr = multierror.Append(resultErr, fmt.Errorf("error patching cluster: %w", err))
+					recordErr(xdscommon.ClusterType, nameOrSNI, err)
 					continue
 				}
 				if patched {
@@ -66,7 +126,7 @@ func Extend(resources *xdscommon.IndexedResources, config xdscommon.ExtensionCon
 			case *envoy_listener_v3.Listener:
 				newListener, patched, err := patchListener(config, resource, patcher)
 				if err != nil {
-					resultErr = multierror.Append(resultErr, fmt.Errorf("error patching listener: %w", err))
+					recordErr(xdscommon.ListenerType, nameOrSNI, err)
 					continue
 				}
 				if patched {
@@ -74,26 +134,59 @@ func Extend(resources *xdscommon.IndexedResources, config xdscommon.ExtensionCon
 				}
 
 			case *envoy_route_v3.RouteConfiguration:
-				if !config.MatchesUpstreamServiceSNI(nameOrSNI) {
+				if !matchesRouteConfig(config, nameOrSNI) {
 					continue
 				}
 
-				newRoute, patched, err := patcher.PatchRoute(resource)
+				newRoute, patched, err := patcher.PatchRoute(config, resource)
 				if err != nil {
-					resultErr = multierror.Append(resultErr, fmt.Errorf("error patching route: %w", err))
+					recordErr(xdscommon.RouteType, nameOrSNI, err)
 					continue
 				}
 				if patched {
 					resources.Index[xdscommon.RouteType][nameOrSNI] = newRoute
 				}
 
+			case *envoy_endpoint_v3.ClusterLoadAssignment:
+				if !matchesSNI(config, nameOrSNI) {
+					continue
+				}
+
+				newEndpoints, patched, err := patcher.PatchEndpoints(resource)
+				if err != nil {
+					recordErr(xdscommon.EndpointType, nameOrSNI, err)
+					continue
+				}
+				if patched {
+					resources.Index[xdscommon.EndpointType][nameOrSNI] = newEndpoints
+				}
+
 			default:
-				resultErr = multierror.Append(resultErr, fmt.Errorf("unsupported type was skipped: %T", resource))
+				// Resources this plugin doesn't patch, like those of another
+				// Envoy API version, are left as they are.
+				config.GetLogger().Debug("skipping resource of unsupported type",
+					"index_type", indexType, "name", nameOrSNI, "type", fmt.Sprintf("%T", resource))
 			}
 		}
 	}
 
-	return resources, resultErr
+	if len(results) > 0 {
+		return resources, &PatchError{Results: results}
+	}
+
+	return resources, nil
+}
+
+// excludeArgument decodes the SNIs and service names listed in the Exclude
+// argument of the extension, which are added to those of the configuration.
+func excludeArgument(ext api.EnvoyExtension) ([]string, error) {
+	var args struct {
+		Exclude []string `mapstructure:"Exclude"`
+	}
+	if err := mapstructure.Decode(ext.Arguments, &args); err != nil {
+		return nil, err
+	}
+	return args.Exclude, nil
 }
 
 func patchListener(config xdscommon.ExtensionConfiguration, l *envoy_listener_v3.Listener, p patcher) (proto.Message, bool, error) {
@@ -116,25 +209,17 @@ func patchTerminatingGatewayListener(config xdscommon.ExtensionConfiguration, l
 			continue
 		}
 
-		if !config.MatchesUpstreamServiceSNI(sni) {
+		if !matchesSNI(config, sni) {
 			continue
 		}
 
-		var filters []*envoy_listener_v3.Filter
-
-		for _, filter := range filterChain.Filters {
-			newFilter, ok, err := p.PatchFilter(filter)
-
-			if err != nil {
-				resultErr = multierror.Append(resultErr, fmt.Errorf("error patching listener filter: %w", err))
-				filters = append(filters, filter)
-			}
-			if ok {
-				filters = append(filters, newFilter)
-				patched = true
-			}
+		chainPatched, err := xdscommon.PatchFilterChain(filterChain, filterPatcher(config, p))
+		if err != nil {
+			resultErr = multierror.Append(resultErr, fmt.Errorf("error patching filter chain for %q: %w", sni, err))
+		}
+		if chainPatched {
+			patched = true
 		}
-		filterChain.Filters = filters
 	}
 
 	return l, patched, resultErr
@@ -148,6 +233,14 @@ func patchConnectProxyListener(config xdscommon.ExtensionConfiguration, l *envoy
 		envoyID = l.Name[:i]
 	}
 
+	if config.IsExcluded("") {
+		return l, false, nil
+	}
+
+	if envoyID == outboundListenerName {
+		return patchOutboundListener(config, l, p)
+	}
+
 	if envoyID != config.EnvoyID() {
 		return l, false, nil
 	}
@@ -155,24 +248,136 @@ func patchConnectProxyListener(config xdscommon.ExtensionConfiguration, l *envoy
 	var patched bool
 
 	for _, filterChain := range l.FilterChains {
-		var filters []*envoy_listener_v3.Filter
+		chainPatched, err := xdscommon.PatchFilterChain(filterChain, filterPatcher(config, p))
+		if err != nil {
+			resultErr = multierror.Append(resultErr, err)
+		}
+		if chainPatched {
+			patched = true
+		}
+	}
+
+	return l, patched, resultErr
+}
+
+// filterPatcher returns the function patching the filters of the upstream's
+// filter chains. The route configuration inlined in an HTTP connection
+// manager, which connect proxies use for upstreams without L7 routing, is
+// patched with PatchRoute like the route configurations served through RDS,
+// before PatchFilter patches the filter itself.
+func filterPatcher(config xdscommon.ExtensionConfiguration, p patcher) func(*envoy_listener_v3.Filter) (*envoy_listener_v3.Filter, bool, error) {
+	return func(filter *envoy_listener_v3.Filter) (*envoy_listener_v3.Filter, bool, error) {
+		filter, routePatched, err := patchInlineRouteConfig(config, filter, p)
+		if err != nil {
+			return filter, false, err
+		}
+
+		newFilter, patched, err := p.PatchFilter(filter)
+		if err != nil {
+			return filter, false, err
+		}
+		return newFilter, patched || routePatched, nil
+	}
+}
+
+// patchInlineRouteConfig patches the route configuration inlined in the filter
+// if it is an HTTP connection manager.
+func patchInlineRouteConfig(config xdscommon.ExtensionConfiguration, filter *envoy_listener_v3.Filter, p patcher) (*envoy_listener_v3.Filter, bool, error) {
+	hcm, err := xdscommon.GetHTTPConnectionManager(filter)
+	if err != nil || hcm == nil || hcm.GetRouteConfig() == nil {
+		return filter, false, err
+	}
+
+	routeConfig, patched, err := p.PatchRoute(config, hcm.GetRouteConfig())
+	if err != nil || !patched {
+		return filter, false, err
+	}
+	hcm.RouteSpecifier = &envoy_http_v3.HttpConnectionManager_RouteConfig{RouteConfig: routeConfig}
+
+	newFilter, err := xdscommon.MakeFilter(xdscommon.HTTPConnectionManagerFilterName, hcm)
+	if err != nil {
+		return filter, false, fmt.Errorf("error making new filter: %w", err)
+	}
+	return newFilter, true, nil
+}
+
+// patchOutboundListener patches the transparent proxy outbound listener.
+// Rather than having its own listener, each upstream gets a filter chain on
+// that listener which is matched on the upstream's virtual IPs, so the chains
+// are selected by where their filters route to.
+func patchOutboundListener(config xdscommon.ExtensionConfiguration, l *envoy_listener_v3.Listener, p patcher) (proto.Message, bool, error) {
+	var (
+		resultErr error
+		patched   bool
+	)
+	for _, filterChain := range l.FilterChains {
+		if !routesToUpstream(config, filterChain) {
+			continue
+		}
 
-		for _, filter := range filterChain.Filters {
-			newFilter, ok, err := p.PatchFilter(filter)
-			if err != nil {
-				resultErr = multierror.Append(resultErr, fmt.Errorf("error patching listener filter: %w", err))
-				filters = append(filters, filter)
-			}
+		chainPatched, err := xdscommon.PatchFilterChain(filterChain, filterPatcher(config, p))
+		if err != nil {
+			resultErr = multierror.Append(resultErr, err)
+		}
+		if chainPatched {
+			patched = true
+		}
+	}
+
+	return l, patched, resultErr
+}
+
+// routesToUpstream returns true if an HTTP connection manager of the filter
+// chain uses the route config of the upstream, which is named after its Envoy
+// ID, or has an inline route to one of the upstream's clusters.
+func routesToUpstream(config xdscommon.ExtensionConfiguration, filterChain *envoy_listener_v3.FilterChain) bool {
+	for _, filter := range filterChain.Filters {
+		if filter.Name != "envoy.filters.network.http_connection_manager" {
+			continue
+		}
+		hcm := envoy_resource_v3.GetHTTPConnectionManager(filter)
+		if hcm == nil {
+			continue
+		}
 
-			if ok {
-				filters = append(filters, newFilter)
-				patched = true
+		if rds := hcm.GetRds(); rds != nil && rds.RouteConfigName == config.EnvoyID() {
+			return true
+		}
+		for _, virtualHost := range hcm.GetRouteConfig().GetVirtualHosts() {
+			for _, route := range virtualHost.Routes {
+				action := route.GetRoute()
+				if action == nil {
+					continue
+				}
+				if matchesSNI(config, action.GetCluster()) {
+					return true
+				}
+				for _, cluster := range action.GetWeightedClusters().GetClusters() {
+					if matchesSNI(config, cluster.Name) {
+						return true
+					}
+				}
 			}
 		}
-		filterChain.Filters = filters
 	}
+	return false
+}
 
-	return l, patched, resultErr
+// matchesRouteConfig returns true if the route configuration with the given
+// name may route to the upstream. Terminating gateways name it after the SNI of
+// the upstream and connect proxies after its Envoy ID. Only the routes to the
+// upstream's clusters are patched by PatchRoute.
+func matchesRouteConfig(config xdscommon.ExtensionConfiguration, name string) bool {
+	if config.Kind == api.ServiceKindConnectProxy && name == config.EnvoyID() {
+		return !config.IsExcluded("")
+	}
+	return matchesSNI(config, name)
+}
+
+// matchesSNI returns true if the resource for the SNI belongs to the upstream
+// and has not been excluded from patching.
+func matchesSNI(config xdscommon.ExtensionConfiguration, sni string) bool {
+	return config.MatchesUpstreamServiceSNI(sni) && !config.IsExcluded(sni)
 }
 
 func getSNI(chain *envoy_listener_v3.FilterChain) string {
@@ -0,0 +1,478 @@
+package serverlessplugin
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_http_router_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/xds/xdscommon"
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	testLambdaSNI  = "lambda.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"
+	testSiblingSNI = "web.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"
+)
+
+func makeTestLambdaExtensionConfiguration(kind api.ServiceKind) xdscommon.ExtensionConfiguration {
+	lambdaService := api.CompoundServiceName{Name: "lambda", Namespace: "default", Partition: "default"}
+	return xdscommon.ExtensionConfiguration{
+		EnvoyExtension: api.EnvoyExtension{
+			Name: structs.BuiltinAWSLambdaExtension,
+			Arguments: map[string]interface{}{
+				"ARN":    "arn:aws:lambda:us-east-1:111111111111:function:lambda",
+				"Region": "us-east-1",
+			},
+		},
+		ServiceName: lambdaService,
+		Kind:        kind,
+		Upstreams: map[api.CompoundServiceName]xdscommon.UpstreamData{
+			lambdaService: {
+				SNI:               map[string]struct{}{testLambdaSNI: {}},
+				EnvoyID:           "lambda",
+				OutgoingProxyKind: kind,
+			},
+		},
+	}
+}
+
+func makeTestFilterChain(sni string, filters ...*envoy_listener_v3.Filter) *envoy_listener_v3.FilterChain {
+	return &envoy_listener_v3.FilterChain{
+		FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+			ServerNames: []string{sni},
+		},
+		Filters: filters,
+	}
+}
+
+func TestExtend_TerminatingGatewayDuplicateSNIs(t *testing.T) {
+	config := makeTestLambdaExtensionConfiguration(api.ServiceKindTerminatingGateway)
+
+	tcpProxy := &envoy_listener_v3.Filter{Name: "envoy.filters.network.tcp_proxy"}
+	listener := &envoy_listener_v3.Listener{
+		Name: "default:1.2.3.4:8443",
+		FilterChains: []*envoy_listener_v3.FilterChain{
+			makeTestFilterChain(testLambdaSNI, makeTestHTTPConnectionManagerFilter(t)),
+			makeTestFilterChain(testLambdaSNI, makeTestHTTPConnectionManagerFilter(t)),
+			makeTestFilterChain(testSiblingSNI, tcpProxy),
+		},
+	}
+
+	resources := xdscommon.EmptyIndexedResources()
+	resources.Index[xdscommon.ListenerType][listener.Name] = listener
+
+	resources, err := Extend(resources, config)
+	require.NoError(t, err)
+
+	patchedListener := resources.Index[xdscommon.ListenerType][listener.Name].(*envoy_listener_v3.Listener)
+	require.Len(t, patchedListener.FilterChains, 3)
+
+	for _, filterChain := range patchedListener.FilterChains[:2] {
+		require.Len(t, filterChain.Filters, 1)
+		getTestLambdaHTTPFilter(t, filterChain.Filters[0])
+	}
+
+	require.Equal(t, []*envoy_listener_v3.Filter{tcpProxy}, patchedListener.FilterChains[2].Filters)
+}
+
+func TestExtend_TerminatingGatewayFilterChainError(t *testing.T) {
+	config := makeTestLambdaExtensionConfiguration(api.ServiceKindTerminatingGateway)
+
+	// The http_connection_manager filter without a typed config can't be patched.
+	invalid := &envoy_listener_v3.Filter{Name: "envoy.filters.network.http_connection_manager"}
+	listener := &envoy_listener_v3.Listener{
+		Name: "default:1.2.3.4:8443",
+		FilterChains: []*envoy_listener_v3.FilterChain{
+			makeTestFilterChain(testLambdaSNI, invalid),
+			makeTestFilterChain(testLambdaSNI, makeTestHTTPConnectionManagerFilter(t)),
+		},
+	}
+
+	resources := xdscommon.EmptyIndexedResources()
+	resources.Index[xdscommon.ListenerType][listener.Name] = listener
+
+	resources, err := Extend(resources, config)
+	require.Error(t, err)
+
+	patchedListener := resources.Index[xdscommon.ListenerType][listener.Name].(*envoy_listener_v3.Listener)
+	require.Len(t, patchedListener.FilterChains, 2)
+	require.Equal(t, []*envoy_listener_v3.Filter{invalid}, patchedListener.FilterChains[0].Filters)
+
+	require.Len(t, patchedListener.FilterChains[1].Filters, 1)
+	getTestLambdaHTTPFilter(t, patchedListener.FilterChains[1].Filters[0])
+}
+
+func TestExtend_MeshGateway(t *testing.T) {
+	config := makeTestLambdaExtensionConfiguration(api.ServiceKindMeshGateway)
+
+	p, err := makePatcher(config)
+	require.NoError(t, err)
+	require.False(t, p.CanPatch(api.ServiceKindMeshGateway))
+
+	cluster := &envoy_cluster_v3.Cluster{Name: testLambdaSNI}
+	listener := &envoy_listener_v3.Listener{
+		Name:         "default:1.2.3.4:8443",
+		FilterChains: []*envoy_listener_v3.FilterChain{makeTestFilterChain(testLambdaSNI, makeTestHTTPConnectionManagerFilter(t))},
+	}
+	resources := xdscommon.EmptyIndexedResources()
+	resources.Index[xdscommon.ClusterType][testLambdaSNI] = cluster
+	resources.Index[xdscommon.ListenerType][listener.Name] = listener
+	expected := proto.Clone(cluster)
+	expectedListener := proto.Clone(listener)
+
+	resources, err = Extend(resources, config)
+	require.NoError(t, err)
+	require.Same(t, cluster, resources.Index[xdscommon.ClusterType][testLambdaSNI])
+	require.True(t, proto.Equal(expected, cluster))
+	require.Same(t, listener, resources.Index[xdscommon.ListenerType][listener.Name])
+	require.True(t, proto.Equal(expectedListener, listener))
+}
+
+func TestExtend_Exclude(t *testing.T) {
+	snis := []string{
+		"lambda.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul",
+		"lambda.default.dc2.internal.11111111-2222-3333-4444-555555555555.consul",
+		"lambda.default.dc3.internal.11111111-2222-3333-4444-555555555555.consul",
+	}
+
+	config := makeTestLambdaExtensionConfiguration(api.ServiceKindTerminatingGateway)
+	upstream := config.Upstreams[config.ServiceName]
+	upstream.SNI = make(map[string]struct{})
+	for _, sni := range snis {
+		upstream.SNI[sni] = struct{}{}
+	}
+	config.Upstreams[config.ServiceName] = upstream
+	config.Exclude = []string{snis[1]}
+
+	listener := &envoy_listener_v3.Listener{Name: "default:1.2.3.4:8443"}
+	filters := make([]*envoy_listener_v3.Filter, len(snis))
+	resources := xdscommon.EmptyIndexedResources()
+	for i, sni := range snis {
+		filters[i] = makeTestHTTPConnectionManagerFilter(t)
+		resources.Index[xdscommon.ClusterType][sni] = &envoy_cluster_v3.Cluster{Name: sni}
+		listener.FilterChains = append(listener.FilterChains, makeTestFilterChain(sni, filters[i]))
+	}
+	resources.Index[xdscommon.ListenerType][listener.Name] = listener
+
+	resources, err := Extend(resources, config)
+	require.NoError(t, err)
+
+	var patchedClusters int
+	for _, sni := range snis {
+		cluster := resources.Index[xdscommon.ClusterType][sni].(*envoy_cluster_v3.Cluster)
+		if cluster.TransportSocket != nil {
+			patchedClusters++
+			require.NotEqual(t, snis[1], sni)
+		}
+	}
+	require.Equal(t, 2, patchedClusters)
+
+	patchedListener := resources.Index[xdscommon.ListenerType][listener.Name].(*envoy_listener_v3.Listener)
+	getTestLambdaHTTPFilter(t, patchedListener.FilterChains[0].Filters[0])
+	getTestLambdaHTTPFilter(t, patchedListener.FilterChains[2].Filters[0])
+	require.Equal(t, []*envoy_listener_v3.Filter{filters[1]}, patchedListener.FilterChains[1].Filters)
+
+	t.Run("service name", func(t *testing.T) {
+		config.Exclude = []string{"lambda"}
+		require.True(t, config.IsExcluded(""))
+		for _, sni := range snis {
+			require.False(t, matchesSNI(config, sni))
+		}
+	})
+}
+
+func TestExtend_ExcludeArgument(t *testing.T) {
+	makeResources := func() *xdscommon.IndexedResources {
+		resources := xdscommon.EmptyIndexedResources()
+		resources.Index[xdscommon.ClusterType][testLambdaSNI] = &envoy_cluster_v3.Cluster{Name: testLambdaSNI}
+		return resources
+	}
+
+	t.Run("SNI", func(t *testing.T) {
+		config := makeTestLambdaExtensionConfiguration(api.ServiceKindConnectProxy)
+		// Arguments decoded from JSON hold lists as []interface{}.
+		config.EnvoyExtension.Arguments["Exclude"] = []interface{}{testLambdaSNI}
+
+		resources, err := Extend(makeResources(), config)
+		require.NoError(t, err)
+		require.Nil(t, resources.Index[xdscommon.ClusterType][testLambdaSNI].(*envoy_cluster_v3.Cluster).TransportSocket)
+	})
+
+	t.Run("service name", func(t *testing.T) {
+		config := makeTestLambdaExtensionConfiguration(api.ServiceKindConnectProxy)
+		config.EnvoyExtension.Arguments["Exclude"] = []interface{}{"lambda"}
+
+		resources, err := Extend(makeResources(), config)
+		require.NoError(t, err)
+		require.Nil(t, resources.Index[xdscommon.ClusterType][testLambdaSNI].(*envoy_cluster_v3.Cluster).TransportSocket)
+	})
+
+	t.Run("other service", func(t *testing.T) {
+		config := makeTestLambdaExtensionConfiguration(api.ServiceKindConnectProxy)
+		config.EnvoyExtension.Arguments["Exclude"] = []interface{}{"other"}
+
+		resources, err := Extend(makeResources(), config)
+		require.NoError(t, err)
+		require.NotNil(t, resources.Index[xdscommon.ClusterType][testLambdaSNI].(*envoy_cluster_v3.Cluster).TransportSocket)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		config := makeTestLambdaExtensionConfiguration(api.ServiceKindConnectProxy)
+		config.EnvoyExtension.Arguments["Exclude"] = map[string]interface{}{"SNI": testLambdaSNI}
+
+		_, err := Extend(makeResources(), config)
+		require.ErrorContains(t, err, "invalid arguments for extension")
+	})
+}
+
+func TestExtend_TransparentProxyOutboundListener(t *testing.T) {
+	makeHCMFilter := func(hcm *envoy_http_v3.HttpConnectionManager) *envoy_listener_v3.Filter {
+		router, err := xdscommon.MakeEnvoyHTTPFilter("envoy.filters.http.router", &envoy_http_router_v3.Router{})
+		require.NoError(t, err)
+		hcm.HttpFilters = []*envoy_http_v3.HttpFilter{router}
+
+		filter, err := xdscommon.MakeFilter("envoy.filters.network.http_connection_manager", hcm)
+		require.NoError(t, err)
+		return filter
+	}
+	routeTo := func(cluster string) *envoy_http_v3.HttpConnectionManager_RouteConfig {
+		return &envoy_http_v3.HttpConnectionManager_RouteConfig{
+			RouteConfig: &envoy_route_v3.RouteConfiguration{
+				VirtualHosts: []*envoy_route_v3.VirtualHost{{
+					Routes: []*envoy_route_v3.Route{{
+						Action: &envoy_route_v3.Route_Route{
+							Route: &envoy_route_v3.RouteAction{
+								ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{Cluster: cluster},
+							},
+						},
+					}},
+				}},
+			},
+		}
+	}
+
+	// Transparent proxy upstreams are filter chains of the outbound listener
+	// matched on virtual IPs, without an SNI.
+	lambdaRoute := makeHCMFilter(&envoy_http_v3.HttpConnectionManager{
+		StatPrefix:     "upstream.lambda.default.default.dc1",
+		RouteSpecifier: routeTo(testLambdaSNI),
+	})
+	lambdaRDS := makeHCMFilter(&envoy_http_v3.HttpConnectionManager{
+		StatPrefix: "upstream.lambda.default.default.dc1",
+		RouteSpecifier: &envoy_http_v3.HttpConnectionManager_Rds{
+			Rds: &envoy_http_v3.Rds{RouteConfigName: "lambda"},
+		},
+	})
+	sibling := makeHCMFilter(&envoy_http_v3.HttpConnectionManager{
+		StatPrefix:     "upstream.web.default.default.dc1",
+		RouteSpecifier: routeTo(testSiblingSNI),
+	})
+
+	listener := &envoy_listener_v3.Listener{
+		Name: "outbound_listener:127.0.0.1:15001",
+		FilterChains: []*envoy_listener_v3.FilterChain{
+			{Filters: []*envoy_listener_v3.Filter{lambdaRoute}},
+			{Filters: []*envoy_listener_v3.Filter{lambdaRDS}},
+			{Filters: []*envoy_listener_v3.Filter{sibling}},
+		},
+	}
+	resources := xdscommon.EmptyIndexedResources()
+	resources.Index[xdscommon.ListenerType][listener.Name] = listener
+
+	resources, err := Extend(resources, makeTestLambdaExtensionConfiguration(api.ServiceKindConnectProxy))
+	require.NoError(t, err)
+
+	patchedListener := resources.Index[xdscommon.ListenerType][listener.Name].(*envoy_listener_v3.Listener)
+	require.Len(t, patchedListener.FilterChains, 3)
+	getTestLambdaHTTPFilter(t, patchedListener.FilterChains[0].Filters[0])
+	getTestLambdaHTTPFilter(t, patchedListener.FilterChains[1].Filters[0])
+	require.Equal(t, []*envoy_listener_v3.Filter{sibling}, patchedListener.FilterChains[2].Filters)
+}
+
+// errPatcher is a patcher that fails to patch every cluster.
+type errPatcher struct {
+	lambdaPatcher
+}
+
+func (errPatcher) PatchCluster(_ xdscommon.ExtensionConfiguration, c *envoy_cluster_v3.Cluster) (*envoy_cluster_v3.Cluster, bool, error) {
+	return c, false, errors.New("cluster patch failure")
+}
+
+func TestExtend_PatchError(t *testing.T) {
+	original := patchConstructors
+	t.Cleanup(func() { patchConstructors = original })
+	patchConstructors = []patchConstructor{
+		func(ext api.EnvoyExtension, upstreamKind api.ServiceKind) (patcher, bool, error) {
+			return errPatcher{lambdaPatcher{Kind: upstreamKind}}, true, nil
+		},
+	}
+
+	config := makeTestLambdaExtensionConfiguration(api.ServiceKindTerminatingGateway)
+
+	resources := xdscommon.EmptyIndexedResources()
+	resources.Index[xdscommon.ClusterType][testLambdaSNI] = &envoy_cluster_v3.Cluster{Name: testLambdaSNI}
+	resources.Index[xdscommon.ClusterType][testSiblingSNI] = &envoy_cluster_v3.Cluster{Name: testSiblingSNI}
+
+	_, err := Extend(resources, config)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cluster patch failure")
+
+	var patchErr *PatchError
+	require.True(t, errors.As(err, &patchErr))
+	require.Len(t, patchErr.Results, 1)
+
+	result := patchErr.Results[0]
+	require.Equal(t, xdscommon.ClusterType, result.IndexType)
+	require.Equal(t, testLambdaSNI, result.NameOrSNI)
+	require.EqualError(t, result.Err, "cluster patch failure")
+}
+
+func TestExtend_SkipsUnknownTypes(t *testing.T) {
+	config := makeTestLambdaExtensionConfiguration(api.ServiceKindTerminatingGateway)
+
+	var logs bytes.Buffer
+	config.Logger = hclog.New(&hclog.LoggerOptions{Level: hclog.Debug, Output: &logs})
+
+	resources := xdscommon.EmptyIndexedResources()
+	secret := &envoy_tls_v3.Secret{Name: testLambdaSNI}
+	resources.Index[xdscommon.ClusterType][testLambdaSNI] = secret
+
+	_, err := Extend(resources, config)
+	require.NoError(t, err)
+	require.Same(t, secret, resources.Index[xdscommon.ClusterType][testLambdaSNI])
+	require.Contains(t, logs.String(), "skipping resource of unsupported type")
+	require.Contains(t, logs.String(), `type="*tlsv3.Secret"`)
+}
+
+// endpointsPatcher is a patcher that rewrites the address of every endpoint.
+type endpointsPatcher struct {
+	lambdaPatcher
+	address string
+}
+
+func (p endpointsPatcher) PatchEndpoints(cla *envoy_endpoint_v3.ClusterLoadAssignment) (*envoy_endpoint_v3.ClusterLoadAssignment, bool, error) {
+	for _, localityEndpoints := range cla.Endpoints {
+		for _, endpoint := range localityEndpoints.LbEndpoints {
+			endpoint.GetEndpoint().GetAddress().GetSocketAddress().Address = p.address
+		}
+	}
+	return cla, true, nil
+}
+
+func TestExtend_PatchEndpoints(t *testing.T) {
+	original := patchConstructors
+	t.Cleanup(func() { patchConstructors = original })
+	patchConstructors = []patchConstructor{
+		func(ext api.EnvoyExtension, upstreamKind api.ServiceKind) (patcher, bool, error) {
+			return endpointsPatcher{lambdaPatcher: lambdaPatcher{Kind: upstreamKind}, address: "lambda.example.com"}, true, nil
+		},
+	}
+
+	makeEndpoints := func(name string) *envoy_endpoint_v3.ClusterLoadAssignment {
+		return &envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: name,
+			Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{{
+				LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{{
+					HostIdentifier: &envoy_endpoint_v3.LbEndpoint_Endpoint{
+						Endpoint: &envoy_endpoint_v3.Endpoint{
+							Address: &envoy_core_v3.Address{
+								Address: &envoy_core_v3.Address_SocketAddress{
+									SocketAddress: &envoy_core_v3.SocketAddress{Address: "10.0.0.1"},
+								},
+							},
+						},
+					},
+				}},
+			}},
+		}
+	}
+
+	config := makeTestLambdaExtensionConfiguration(api.ServiceKindTerminatingGateway)
+
+	resources := xdscommon.EmptyIndexedResources()
+	resources.Index[xdscommon.EndpointType][testLambdaSNI] = makeEndpoints(testLambdaSNI)
+	resources.Index[xdscommon.EndpointType][testSiblingSNI] = makeEndpoints(testSiblingSNI)
+
+	resources, err := Extend(resources, config)
+	require.NoError(t, err)
+
+	address := func(sni string) string {
+		cla := resources.Index[xdscommon.EndpointType][sni].(*envoy_endpoint_v3.ClusterLoadAssignment)
+		return cla.Endpoints[0].LbEndpoints[0].GetEndpoint().GetAddress().GetSocketAddress().GetAddress()
+	}
+	require.Equal(t, "lambda.example.com", address(testLambdaSNI))
+	require.Equal(t, "10.0.0.1", address(testSiblingSNI))
+}
+
+func TestExtend_ValidatesArguments(t *testing.T) {
+	cases := []struct {
+		name          string
+		extensionName string
+		arguments     map[string]interface{}
+		expectedErr   string
+	}{
+		{
+			name:          "unknown extension",
+			extensionName: "builtin/unknown",
+		},
+		{
+			name:        "missing arn",
+			arguments:   map[string]interface{}{"Region": "us-east-1"},
+			expectedErr: `invalid arguments for extension "builtin/aws/lambda": ARN is required`,
+		},
+		{
+			name: "empty region",
+			arguments: map[string]interface{}{
+				"ARN":    "arn:aws:lambda::111111111111:function:lambda",
+				"Region": "",
+			},
+			expectedErr: `invalid arguments for extension "builtin/aws/lambda": Region is required`,
+		},
+		{
+			name: "well-formed",
+			arguments: map[string]interface{}{
+				"ARN":    "arn:aws:lambda:us-east-1:111111111111:function:lambda",
+				"Region": "us-east-1",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := makeTestLambdaExtensionConfiguration(api.ServiceKindTerminatingGateway)
+			if tc.extensionName != "" {
+				config.EnvoyExtension.Name = tc.extensionName
+			}
+			if tc.arguments != nil {
+				config.EnvoyExtension.Arguments = tc.arguments
+			}
+
+			cluster := &envoy_cluster_v3.Cluster{Name: testLambdaSNI}
+			resources := xdscommon.EmptyIndexedResources()
+			resources.Index[xdscommon.ClusterType][testLambdaSNI] = cluster
+
+			resources, err := Extend(resources, config)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			patched := tc.expectedErr == "" && tc.extensionName == ""
+			require.Equal(t, !patched, cluster == resources.Index[xdscommon.ClusterType][testLambdaSNI])
+		})
+	}
+}
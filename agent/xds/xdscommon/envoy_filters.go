@@ -0,0 +1,122 @@
+package xdscommon
+
+import (
+	"errors"
+	"fmt"
+
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+)
+
+// These helpers are shared by the extensions patching the listener filters of
+// the proxy. They are copied from xds, which the extensions can't import
+// without an import cycle.
+
+const (
+	// HTTPConnectionManagerFilterName is the name of the network filter of the
+	// HTTP listeners.
+	HTTPConnectionManagerFilterName = "envoy.filters.network.http_connection_manager"
+
+	// RouterHTTPFilterName is the name of the HTTP filter forwarding the
+	// requests, which is the last HTTP filter of the HTTP connection managers.
+	RouterHTTPFilterName = "envoy.filters.http.router"
+)
+
+// MakeEnvoyHTTPFilter returns the HTTP filter with the given name and config.
+func MakeEnvoyHTTPFilter(name string, cfg proto.Message) (*envoy_http_v3.HttpFilter, error) {
+	any, err := ptypes.MarshalAny(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &envoy_http_v3.HttpFilter{
+		Name:       name,
+		ConfigType: &envoy_http_v3.HttpFilter_TypedConfig{TypedConfig: any},
+	}, nil
+}
+
+// MakeFilter returns the network filter with the given name and config.
+func MakeFilter(name string, cfg proto.Message) (*envoy_listener_v3.Filter, error) {
+	any, err := ptypes.MarshalAny(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &envoy_listener_v3.Filter{
+		Name:       name,
+		ConfigType: &envoy_listener_v3.Filter_TypedConfig{TypedConfig: any},
+	}, nil
+}
+
+// PatchFilterChain patches each of the filters in the filter chain with
+// patchFilter. The filters are only replaced if every filter was patched
+// without error, otherwise the filter chain keeps its original filters.
+func PatchFilterChain(filterChain *envoy_listener_v3.FilterChain, patchFilter func(*envoy_listener_v3.Filter) (*envoy_listener_v3.Filter, bool, error)) (bool, error) {
+	var (
+		filters = make([]*envoy_listener_v3.Filter, 0, len(filterChain.Filters))
+		patched bool
+	)
+
+	for _, filter := range filterChain.Filters {
+		newFilter, ok, err := patchFilter(filter)
+		if err != nil {
+			return false, fmt.Errorf("error patching listener filter: %w", err)
+		}
+
+		if ok {
+			filters = append(filters, newFilter)
+			patched = true
+		} else {
+			filters = append(filters, filter)
+		}
+	}
+
+	if patched {
+		filterChain.Filters = filters
+	}
+
+	return patched, nil
+}
+
+// GetHTTPConnectionManager returns the config of the filter if it is an HTTP
+// connection manager, and nil otherwise.
+func GetHTTPConnectionManager(filter *envoy_listener_v3.Filter) (*envoy_http_v3.HttpConnectionManager, error) {
+	if filter.Name != HTTPConnectionManagerFilterName {
+		return nil, nil
+	}
+	if typedConfig := filter.GetTypedConfig(); typedConfig == nil {
+		return nil, errors.New("error getting typed config for http filter")
+	}
+
+	config := envoy_resource_v3.GetHTTPConnectionManager(filter)
+	if config == nil {
+		return nil, errors.New("error unmarshalling filter")
+	}
+	return config, nil
+}
+
+// InsertHTTPFilterBeforeRouter inserts httpFilter right before the router
+// filter of the HTTP connection manager, keeping every other filter intact so
+// the RBAC filter enforcing intentions still runs first. It returns false and
+// leaves the connection manager unchanged if it has no router filter.
+func InsertHTTPFilterBeforeRouter(config *envoy_http_v3.HttpConnectionManager, httpFilter *envoy_http_v3.HttpFilter) bool {
+	var (
+		changedFilters = make([]*envoy_http_v3.HttpFilter, 0, len(config.HttpFilters)+1)
+		changed        bool
+	)
+
+	for _, f := range config.HttpFilters {
+		if f.Name == RouterHTTPFilterName {
+			changedFilters = append(changedFilters, httpFilter)
+			changed = true
+		}
+		changedFilters = append(changedFilters, f)
+	}
+	if changed {
+		config.HttpFilters = changedFilters
+	}
+	return changed
+}
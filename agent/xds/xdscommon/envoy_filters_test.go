@@ -0,0 +1,75 @@
+package xdscommon
+
+import (
+	"errors"
+	"testing"
+
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_http_router_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertHTTPFilterBeforeRouter(t *testing.T) {
+	router, err := MakeEnvoyHTTPFilter(RouterHTTPFilterName, &envoy_http_router_v3.Router{})
+	require.NoError(t, err)
+	rbac := &envoy_http_v3.HttpFilter{Name: "envoy.filters.http.rbac"}
+	lua := &envoy_http_v3.HttpFilter{Name: "envoy.filters.http.lua"}
+
+	config := &envoy_http_v3.HttpConnectionManager{HttpFilters: []*envoy_http_v3.HttpFilter{rbac, router}}
+	require.True(t, InsertHTTPFilterBeforeRouter(config, lua))
+	require.Equal(t, []*envoy_http_v3.HttpFilter{rbac, lua, router}, config.HttpFilters)
+
+	config = &envoy_http_v3.HttpConnectionManager{HttpFilters: []*envoy_http_v3.HttpFilter{rbac}}
+	require.False(t, InsertHTTPFilterBeforeRouter(config, lua))
+	require.Equal(t, []*envoy_http_v3.HttpFilter{rbac}, config.HttpFilters)
+}
+
+func TestGetHTTPConnectionManager(t *testing.T) {
+	hcm := &envoy_http_v3.HttpConnectionManager{StatPrefix: "upstream.db"}
+	filter, err := MakeFilter(HTTPConnectionManagerFilterName, hcm)
+	require.NoError(t, err)
+
+	config, err := GetHTTPConnectionManager(filter)
+	require.NoError(t, err)
+	require.Equal(t, "upstream.db", config.StatPrefix)
+
+	config, err = GetHTTPConnectionManager(&envoy_listener_v3.Filter{Name: "envoy.filters.network.tcp_proxy"})
+	require.NoError(t, err)
+	require.Nil(t, config)
+
+	_, err = GetHTTPConnectionManager(&envoy_listener_v3.Filter{Name: HTTPConnectionManagerFilterName})
+	require.Error(t, err)
+}
+
+func TestPatchFilterChain(t *testing.T) {
+	a := &envoy_listener_v3.Filter{Name: "a"}
+	b := &envoy_listener_v3.Filter{Name: "b"}
+	patchedB := &envoy_listener_v3.Filter{Name: "patched-b"}
+
+	patchB := func(f *envoy_listener_v3.Filter) (*envoy_listener_v3.Filter, bool, error) {
+		if f.Name == "b" {
+			return patchedB, true, nil
+		}
+		return f, false, nil
+	}
+
+	chain := &envoy_listener_v3.FilterChain{Filters: []*envoy_listener_v3.Filter{a, b}}
+	patched, err := PatchFilterChain(chain, patchB)
+	require.NoError(t, err)
+	require.True(t, patched)
+	require.Equal(t, []*envoy_listener_v3.Filter{a, patchedB}, chain.Filters)
+
+	// The filter chain is left unchanged if any of its filters fails to be
+	// patched.
+	chain = &envoy_listener_v3.FilterChain{Filters: []*envoy_listener_v3.Filter{b, a}}
+	patched, err = PatchFilterChain(chain, func(f *envoy_listener_v3.Filter) (*envoy_listener_v3.Filter, bool, error) {
+		if f.Name == "a" {
+			return f, false, errors.New("boom")
+		}
+		return patchB(f)
+	})
+	require.ErrorContains(t, err, "boom")
+	require.False(t, patched)
+	require.Equal(t, []*envoy_listener_v3.Filter{b, a}, chain.Filters)
+}
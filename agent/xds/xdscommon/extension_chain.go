@@ -0,0 +1,75 @@
+package xdscommon
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// ExtendFunc patches the resources for the given extension configuration, such as the Extend function of the
+// serverless and Lua plugins.
+type ExtendFunc func(*IndexedResources, ExtensionConfiguration) (*IndexedResources, error)
+
+type extensionLink struct {
+	config ExtensionConfiguration
+	extend ExtendFunc
+}
+
+// ExtensionChain applies a set of extensions to indexed resources in a deterministic order. This matters when
+// several extensions patch the same resource, e.g. when they insert HTTP filters into the same filter chain.
+type ExtensionChain struct {
+	links   []extensionLink
+	results []ExtensionResult
+}
+
+// ExtensionResult is the outcome of applying one extension of a chain.
+type ExtensionResult struct {
+	Config ExtensionConfiguration
+
+	// Err is the error returned by the extension, whether or not the extension
+	// is required.
+	Err error
+}
+
+// Add appends an extension to the chain.
+func (c *ExtensionChain) Add(config ExtensionConfiguration, extend ExtendFunc) {
+	c.links = append(c.links, extensionLink{config: config, extend: extend})
+}
+
+// Len returns the number of extensions in the chain.
+func (c *ExtensionChain) Len() int {
+	return len(c.links)
+}
+
+// Apply runs the extensions in order of their priority, passing the resources patched by an extension to the
+// next one. Errors from extensions that are required are accumulated and returned once every extension has been
+// applied; errors from the other extensions are ignored.
+func (c *ExtensionChain) Apply(resources *IndexedResources) (*IndexedResources, error) {
+	links := make([]extensionLink, len(c.links))
+	copy(links, c.links)
+	sort.SliceStable(links, func(i, j int) bool {
+		return links[i].config.Priority < links[j].config.Priority
+	})
+
+	var resultErr error
+	c.results = make([]ExtensionResult, 0, len(links))
+	for _, link := range links {
+		patched, err := link.extend(resources, link.config)
+		if patched != nil {
+			resources = patched
+		}
+		c.results = append(c.results, ExtensionResult{Config: link.config, Err: err})
+		if err != nil && link.config.EnvoyExtension.Required {
+			resultErr = multierror.Append(resultErr, fmt.Errorf("failed to apply extension %q: %w", link.config.EnvoyExtension.Name, err))
+		}
+	}
+
+	return resources, resultErr
+}
+
+// Results returns the outcome of each extension, in the order they were
+// applied by the last call to Apply.
+func (c *ExtensionChain) Results() []ExtensionResult {
+	return c.results
+}
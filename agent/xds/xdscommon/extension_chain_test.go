@@ -0,0 +1,104 @@
+package xdscommon
+
+import (
+	"errors"
+	"testing"
+
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// appendFilter returns an ExtendFunc that appends a filter named after the extension to the test listener.
+func appendFilter(listenerName string) ExtendFunc {
+	return func(resources *IndexedResources, config ExtensionConfiguration) (*IndexedResources, error) {
+		listener := resources.Index[ListenerType][listenerName].(*envoy_listener_v3.Listener)
+		chain := listener.FilterChains[0]
+		chain.Filters = append(chain.Filters, &envoy_listener_v3.Filter{Name: config.EnvoyExtension.Name})
+		return resources, nil
+	}
+}
+
+func TestExtensionChain_Apply(t *testing.T) {
+	const listenerName = "db:127.0.0.1:9191"
+
+	newResources := func() *IndexedResources {
+		resources := EmptyIndexedResources()
+		resources.Index[ListenerType][listenerName] = &envoy_listener_v3.Listener{
+			Name:         listenerName,
+			FilterChains: []*envoy_listener_v3.FilterChain{{}},
+		}
+		return resources
+	}
+	filterNames := func(resources *IndexedResources) []string {
+		listener := resources.Index[ListenerType][listenerName].(*envoy_listener_v3.Listener)
+		var names []string
+		for _, filter := range listener.FilterChains[0].Filters {
+			names = append(names, filter.Name)
+		}
+		return names
+	}
+	config := func(name string, priority int) ExtensionConfiguration {
+		return ExtensionConfiguration{
+			EnvoyExtension: api.EnvoyExtension{Name: name, Required: true},
+			Priority:       priority,
+		}
+	}
+
+	t.Run("priority order", func(t *testing.T) {
+		chain := &ExtensionChain{}
+		chain.Add(config("builtin/lua", 10), appendFilter(listenerName))
+		chain.Add(config("builtin/aws/lambda", 0), appendFilter(listenerName))
+		require.Equal(t, 2, chain.Len())
+
+		resources, err := chain.Apply(newResources())
+		require.NoError(t, err)
+		require.Equal(t, []string{"builtin/aws/lambda", "builtin/lua"}, filterNames(resources))
+	})
+
+	t.Run("same priority keeps insertion order", func(t *testing.T) {
+		chain := &ExtensionChain{}
+		chain.Add(config("first", 0), appendFilter(listenerName))
+		chain.Add(config("second", 0), appendFilter(listenerName))
+		chain.Add(config("third", 0), appendFilter(listenerName))
+
+		resources, err := chain.Apply(newResources())
+		require.NoError(t, err)
+		require.Equal(t, []string{"first", "second", "third"}, filterNames(resources))
+	})
+
+	t.Run("errors are accumulated", func(t *testing.T) {
+		failing := func(resources *IndexedResources, config ExtensionConfiguration) (*IndexedResources, error) {
+			return resources, errors.New("patch failure")
+		}
+
+		optional := config("optional", 0)
+		optional.EnvoyExtension.Required = false
+
+		chain := &ExtensionChain{}
+		chain.Add(config("first", 0), failing)
+		chain.Add(optional, failing)
+		chain.Add(config("patched", 1), appendFilter(listenerName))
+		chain.Add(config("second", 2), failing)
+
+		resources, err := chain.Apply(newResources())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `failed to apply extension "first": patch failure`)
+		require.Contains(t, err.Error(), `failed to apply extension "second": patch failure`)
+		require.NotContains(t, err.Error(), "optional")
+
+		// The error of the optional extension is still reported in the results.
+		var failed []string
+		for _, result := range chain.Results() {
+			if result.Err != nil {
+				failed = append(failed, result.Config.EnvoyExtension.Name)
+			}
+		}
+		require.Equal(t, []string{"first", "optional", "second"}, failed)
+		require.Len(t, chain.Results(), 4)
+
+		// Extensions after a failing one are still applied.
+		require.Equal(t, []string{"patched"}, filterNames(resources))
+	})
+}
@@ -1,7 +1,11 @@
 package xdscommon
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-hclog"
 
 	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/proxycfg"
@@ -79,6 +83,18 @@ type ExtensionConfiguration struct {
 	// Kind is mode the local Envoy proxy is running in. For now, only connect proxy and
 	// terminating gateways are supported.
 	Kind api.ServiceKind
+
+	// Priority determines the order in which an ExtensionChain applies the extension. Extensions with a lower
+	// priority are applied first and extensions with the same priority are applied in the order they were added.
+	Priority int
+
+	// Exclude lists SNIs and service names whose resources must not be patched, even if they match the upstream. The
+	// serverless extensions add those listed in their Exclude argument.
+	Exclude []string
+
+	// Logger is the logger of the xDS stream the extension is applied for. Use GetLogger, which falls back to a
+	// logger discarding everything when it is unset.
+	Logger hclog.Logger
 }
 
 // UpstreamData has the SNI, EnvoyID, and OutgoingProxyKind of the upstream services for the local proxy and this data
@@ -105,6 +121,25 @@ func (ec ExtensionConfiguration) MatchesUpstreamServiceSNI(sni string) bool {
 	return match
 }
 
+// IsExcluded returns true if the resources for the given SNI must not be patched because either the SNI or the name of
+// the service is in the exclude list. An empty SNI only checks the service name.
+func (ec ExtensionConfiguration) IsExcluded(sni string) bool {
+	for _, exclude := range ec.Exclude {
+		if exclude == ec.ServiceName.Name || (sni != "" && exclude == sni) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetLogger returns the logger the extension should log with.
+func (ec ExtensionConfiguration) GetLogger() hclog.Logger {
+	if ec.Logger == nil {
+		return hclog.NewNullLogger()
+	}
+	return ec.Logger
+}
+
 func (ec ExtensionConfiguration) EnvoyID() string {
 	u := ec.Upstreams[ec.ServiceName]
 	return u.EnvoyID
@@ -187,6 +222,7 @@ func GetExtensionConfigurations(cfgSnap *proxycfg.ConfigSnapshot) map[api.Compou
 				// Upstreams is nil to signify this extension is not being applied to an upstream service, but rather to the local service.
 				Upstreams: nil,
 				Kind:      kind,
+				Priority:  ext.Priority,
 			}
 			extensionConfigurationsMap[localSvc] = append(extensionConfigurationsMap[localSvc], extCfg)
 		}
@@ -209,6 +245,15 @@ func GetExtensionConfigurations(cfgSnap *proxycfg.ConfigSnapshot) map[api.Compou
 				}
 			}
 
+			// Destinations have no instances, the terminating gateway instead has a
+			// cluster and filter chain for each of the destination's addresses.
+			if _, isDestination := cfgSnap.TerminatingGateway.DestinationServices[svc]; isDestination {
+				for _, address := range c.Destination.Addresses {
+					sni := DestinationSNI(svc.Name, address, svc.NamespaceOrDefault(), svc.PartitionOrDefault(), cfgSnap.Datacenter, trustDomain)
+					snis[sni] = struct{}{}
+				}
+			}
+
 			upstreamMap[compoundServiceName] = UpstreamData{
 				SNI:               snis,
 				EnvoyID:           envoyID.EnvoyID(),
@@ -226,6 +271,7 @@ func GetExtensionConfigurations(cfgSnap *proxycfg.ConfigSnapshot) map[api.Compou
 				Kind:           kind,
 				ServiceName:    svc,
 				Upstreams:      upstreamMap,
+				Priority:       ext.Priority,
 			}
 			extensionConfigurationsMap[svc] = append(extensionConfigurationsMap[svc], extCfg)
 		}
@@ -234,6 +280,19 @@ func GetExtensionConfigurations(cfgSnap *proxycfg.ConfigSnapshot) map[api.Compou
 	return extensionConfigurationsMap
 }
 
+// DestinationSNI returns the SNI used to reach a single address of a
+// destination service through a terminating gateway. It is also the name of
+// the terminating gateway's cluster for that address.
+func DestinationSNI(name, address, namespace, partition, datacenter, trustDomain string) string {
+	address = strings.ReplaceAll(address, ":", "-")
+	address = strings.ReplaceAll(address, ".", "-")
+	name = fmt.Sprintf("%s.%s", address, name)
+	sni := connect.ServiceSNI(name, "", namespace, partition, datacenter, trustDomain)
+
+	// Prefixed with destination to distinguish from non-passthrough clusters for the same upstream.
+	return "destination." + sni
+}
+
 func serviceNameToCompoundServiceName(svc structs.ServiceName) api.CompoundServiceName {
 	return api.CompoundServiceName{
 		Name:      svc.Name,
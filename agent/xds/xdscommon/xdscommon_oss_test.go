@@ -46,7 +46,7 @@ func TestGetExtensionConfigurations_TerminatingGateway(t *testing.T) {
 				EnvoyExtension: api.EnvoyExtension{
 					Name: structs.BuiltinAWSLambdaExtension,
 					Arguments: map[string]interface{}{
-						"ARN":                "lambda-arn",
+						"ARN":                "arn:aws:lambda:us-east-1:111111111111:function:lambda-1234",
 						"PayloadPassthrough": true,
 						"Region":             "us-east-1",
 					},
@@ -92,6 +92,38 @@ func TestGetExtensionConfigurations_TerminatingGateway(t *testing.T) {
 	require.Equal(t, expected, GetExtensionConfigurations(snap))
 }
 
+func TestGetExtensionConfigurations_TerminatingGatewayDestination(t *testing.T) {
+	snap := proxycfg.TestConfigSnapshotTerminatingGatewayWithLambdaDestination(t)
+
+	lambdaDestination := api.CompoundServiceName{
+		Name:      "external-IP-HTTP",
+		Namespace: "default",
+		Partition: "default",
+	}
+
+	cfgs := GetExtensionConfigurations(snap)
+	require.Len(t, cfgs[lambdaDestination], 1)
+
+	cfg := cfgs[lambdaDestination][0]
+	require.Equal(t, structs.BuiltinAWSLambdaExtension, cfg.EnvoyExtension.Name)
+	require.True(t, cfg.IsUpstream())
+	require.Equal(t, api.ServiceKindTerminatingGateway, cfg.OutgoingProxyKind())
+
+	// The terminating gateway's filter chain and cluster for the destination's
+	// address are named after the destination SNI.
+	require.True(t, cfg.MatchesUpstreamServiceSNI("destination.192-168-0-2.external-IP-HTTP.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"))
+	require.False(t, cfg.MatchesUpstreamServiceSNI("destination.192-168-0-2.external-IP-TCP.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"))
+}
+
+func TestDestinationSNI(t *testing.T) {
+	require.Equal(t,
+		"destination.192-168-0-1.external.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul",
+		DestinationSNI("external", "192.168.0.1", "default", "default", "dc1", "11111111-2222-3333-4444-555555555555.consul"))
+	require.Equal(t,
+		"destination.api-hashicorp-com.external.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul",
+		DestinationSNI("external", "api.hashicorp.com", "default", "default", "dc1", "11111111-2222-3333-4444-555555555555.consul"))
+}
+
 func TestGetExtensionConfigurations_ConnectProxy(t *testing.T) {
 	dbService := api.CompoundServiceName{
 		Name:      "db",
@@ -109,7 +141,7 @@ func TestGetExtensionConfigurations_ConnectProxy(t *testing.T) {
 		{
 			Name: structs.BuiltinAWSLambdaExtension,
 			Arguments: map[string]interface{}{
-				"ARN":                "lambda-arn",
+				"ARN":                "arn:aws:lambda:us-east-1:111111111111:function:lambda-1234",
 				"PayloadPassthrough": true,
 				"Region":             "us-east-1",
 			},
@@ -152,7 +184,7 @@ func TestGetExtensionConfigurations_ConnectProxy(t *testing.T) {
 						EnvoyExtension: api.EnvoyExtension{
 							Name: structs.BuiltinAWSLambdaExtension,
 							Arguments: map[string]interface{}{
-								"ARN":                "lambda-arn",
+								"ARN":                "arn:aws:lambda:us-east-1:111111111111:function:lambda-1234",
 								"PayloadPassthrough": true,
 								"Region":             "us-east-1",
 							},
@@ -201,7 +233,7 @@ func TestGetExtensionConfigurations_ConnectProxy(t *testing.T) {
 						EnvoyExtension: api.EnvoyExtension{
 							Name: structs.BuiltinAWSLambdaExtension,
 							Arguments: map[string]interface{}{
-								"ARN":                "lambda-arn",
+								"ARN":                "arn:aws:lambda:us-east-1:111111111111:function:lambda-1234",
 								"PayloadPassthrough": true,
 								"Region":             "us-east-1",
 							},
@@ -251,7 +283,7 @@ func TestGetExtensionConfigurations_ConnectProxy(t *testing.T) {
 						EnvoyExtension: api.EnvoyExtension{
 							Name: structs.BuiltinAWSLambdaExtension,
 							Arguments: map[string]interface{}{
-								"ARN":                "lambda-arn",
+								"ARN":                "arn:aws:lambda:us-east-1:111111111111:function:lambda-1234",
 								"PayloadPassthrough": true,
 								"Region":             "us-east-1",
 							},
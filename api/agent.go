@@ -109,6 +109,26 @@ type AgentServiceChecksInfo struct {
 	Checks           HealthChecks
 }
 
+// AgentServiceExtension is the status of an Envoy extension configured for a
+// proxy registered with the agent.
+type AgentServiceExtension struct {
+	// Name is the name of the extension, e.g. builtin/aws/lambda.
+	Name     string
+	Required bool
+
+	// Service is the service the extension is configured for: either the
+	// proxy's destination service, or one of its upstreams.
+	Service CompoundServiceName
+
+	// Applied is true once the extension patched the xDS resources sent to the
+	// proxy without error. It is false with no Error while Envoy hasn't been
+	// configured by the agent yet.
+	Applied bool
+
+	// Error is the error the extension failed with, if any.
+	Error string `json:",omitempty"`
+}
+
 // AgentServiceConnect represents the Connect configuration of a service.
 type AgentServiceConnect struct {
 	Native         bool                      `json:",omitempty"`
@@ -733,6 +753,32 @@ func (a *Agent) Service(serviceID string, q *QueryOptions) (*AgentService, *Quer
 	return out, qm, nil
 }
 
+// ServiceExtensions returns the Envoy extensions configured for the proxy with
+// the given service ID, and whether each of them was applied cleanly.
+func (a *Agent) ServiceExtensions(serviceID string) ([]*AgentServiceExtension, error) {
+	return a.ServiceExtensionsOpts(serviceID, nil)
+}
+
+// ServiceExtensionsOpts is like ServiceExtensions but with query options.
+func (a *Agent) ServiceExtensionsOpts(serviceID string, q *QueryOptions) ([]*AgentServiceExtension, error) {
+	r := a.c.newRequest("GET", "/v1/agent/service/extensions/"+serviceID)
+	r.setQueryOptions(q)
+	_, resp, err := a.c.doRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, err
+	}
+
+	var out []*AgentServiceExtension
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Members returns the known gossip members. The WAN
 // flag can be used to query a server for WAN members.
 func (a *Agent) Members(wan bool) ([]*AgentMember, error) {
@@ -2078,3 +2078,32 @@ func TestMemberIsConsulServer(t *testing.T) {
 		})
 	}
 }
+
+func TestAPI_AgentServiceExtensions(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	reply := []*AgentServiceExtension{
+		{
+			Name:    "builtin/aws/lambda",
+			Service: CompoundServiceName{Name: "db", Namespace: "default"},
+			Applied: true,
+		},
+		{
+			Name:     "builtin/lua",
+			Required: true,
+			Service:  CompoundServiceName{Name: "web", Namespace: "default"},
+			Error:    "Script is required",
+		},
+	}
+	mapi.withReply("GET", "/v1/agent/service/extensions/web-sidecar-proxy", nil, 200, reply).Once()
+
+	exts, err := client.Agent().ServiceExtensions("web-sidecar-proxy")
+	require.NoError(t, err)
+	require.Equal(t, reply, exts)
+
+	mapi.withReply("GET", "/v1/agent/service/extensions/unknown", nil, 404, strings.NewReader("unknown service ID: unknown")).Once()
+
+	_, err = client.Agent().ServiceExtensions("unknown")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "404")
+}
@@ -109,6 +109,9 @@ type EnvoyExtension struct {
 	Name      string
 	Required  bool
 	Arguments map[string]interface{} `bexpr:"-"`
+	// Priority orders the extensions patching the resources of a proxy, lower
+	// priorities being applied first.
+	Priority int `json:",omitempty"`
 }
 
 type ExposePath struct {
@@ -1,6 +1,9 @@
 package api
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // ExportedServicesConfigEntry manages the exported services for a single admin partition.
 // Admin Partitions are a Consul Enterprise feature.
@@ -74,3 +77,81 @@ func (e *ExportedServicesConfigEntry) MarshalJSON() ([]byte, error) {
 	}
 	return json.Marshal(source)
 }
+
+// NewExportedServicesConfigEntry returns an empty exported-services config
+// entry for the given partition. An empty partition uses the default one.
+func NewExportedServicesConfigEntry(partition string) *ExportedServicesConfigEntry {
+	if partition == "" {
+		partition = PartitionDefaultName
+	}
+	return &ExportedServicesConfigEntry{Name: partition}
+}
+
+// ExportToPeers exports the service in the default namespace to the given
+// peers, adding it to the entry if it isn't exported yet. Peers the service is
+// already exported to are skipped. The entry is returned so calls can be
+// chained.
+func (e *ExportedServicesConfigEntry) ExportToPeers(service string, peers ...string) *ExportedServicesConfigEntry {
+	idx := -1
+	for i, svc := range e.Services {
+		if svc.Name == service && svc.Namespace == "" {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		e.Services = append(e.Services, ExportedService{Name: service})
+		idx = len(e.Services) - 1
+	}
+
+	svc := &e.Services[idx]
+	for _, peer := range peers {
+		var found bool
+		for _, consumer := range svc.Consumers {
+			if consumer.Peer == peer {
+				found = true
+				break
+			}
+		}
+		if !found {
+			svc.Consumers = append(svc.Consumers, ServiceConsumer{Peer: peer})
+		}
+	}
+	return e
+}
+
+// WriteExportedServices writes the exported-services config entry after
+// checking that every peer it exports services to has a peering in the
+// entry's partition.
+func (conf *ConfigEntries) WriteExportedServices(entry *ExportedServicesConfigEntry, w *WriteOptions) (bool, *WriteMeta, error) {
+	q := &QueryOptions{Partition: entry.Name}
+	if w != nil {
+		q.Datacenter = w.Datacenter
+		q.Token = w.Token
+		if q.Partition == "" {
+			q.Partition = w.Partition
+		}
+	}
+
+	peerings, _, err := conf.c.Peerings().List(w.Context(), q)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to list peerings: %w", err)
+	}
+	known := make(map[string]struct{}, len(peerings))
+	for _, peering := range peerings {
+		known[peering.Name] = struct{}{}
+	}
+
+	for _, svc := range entry.Services {
+		for _, consumer := range svc.Consumers {
+			if consumer.Peer == "" {
+				continue
+			}
+			if _, ok := known[consumer.Peer]; !ok {
+				return false, nil, fmt.Errorf("exported service %q references unknown peer %q", svc.Name, consumer.Peer)
+			}
+		}
+	}
+
+	return conf.Set(entry, w)
+}
@@ -1,9 +1,12 @@
 package api
 
 import (
+	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/consul/sdk/testutil"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -101,3 +104,81 @@ func TestAPI_ConfigEntries_ExportedServices(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestAPI_ConfigEntries_ExportToPeers(t *testing.T) {
+	entry := NewExportedServicesConfigEntry("").
+		ExportToPeers("web", "peer1", "peer2").
+		ExportToPeers("db", "peer1").
+		ExportToPeers("web", "peer2", "peer3")
+
+	expected := &ExportedServicesConfigEntry{
+		Name: "default",
+		Services: []ExportedService{
+			{
+				Name: "web",
+				Consumers: []ServiceConsumer{
+					{Peer: "peer1"},
+					{Peer: "peer2"},
+					{Peer: "peer3"},
+				},
+			},
+			{
+				Name:      "db",
+				Consumers: []ServiceConsumer{{Peer: "peer1"}},
+			},
+		},
+	}
+	require.Equal(t, expected, entry)
+}
+
+func TestAPI_ConfigEntries_WriteExportedServices(t *testing.T) {
+	peerings := []*Peering{{Name: "peer1"}, {Name: "peer2"}}
+
+	t.Run("known peers", func(t *testing.T) {
+		mapi, client := setupMockAPI(t)
+
+		entry := NewExportedServicesConfigEntry("").
+			ExportToPeers("web", "peer1", "peer2")
+
+		mapi.withReply("GET", "/v1/peerings", nil, 200, peerings).Once()
+		body := mock.MatchedBy(func(b []byte) bool {
+			var raw map[string]interface{}
+			if err := json.Unmarshal(b, &raw); err != nil {
+				return false
+			}
+			expected := map[string]interface{}{
+				"Kind": "exported-services",
+				"Name": "default",
+				"Services": []interface{}{
+					map[string]interface{}{
+						"Name": "web",
+						"Consumers": []interface{}{
+							map[string]interface{}{"Peer": "peer1"},
+							map[string]interface{}{"Peer": "peer2"},
+						},
+					},
+				},
+				"CreateIndex": float64(0),
+				"ModifyIndex": float64(0),
+			}
+			return reflect.DeepEqual(expected, raw)
+		})
+		mapi.withReply("PUT", "/v1/config", body, 200, true).Once()
+
+		ok, _, err := client.ConfigEntries().WriteExportedServices(entry, nil)
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("unknown peer", func(t *testing.T) {
+		mapi, client := setupMockAPI(t)
+
+		entry := NewExportedServicesConfigEntry("").
+			ExportToPeers("web", "peer1", "peer3")
+
+		mapi.withReply("GET", "/v1/peerings", nil, 200, peerings).Once()
+
+		_, _, err := client.ConfigEntries().WriteExportedServices(entry, nil)
+		require.EqualError(t, err, `exported service "web" references unknown peer "peer3"`)
+	})
+}
@@ -2,6 +2,9 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
 )
 
 // MeshConfigEntry manages the global configuration for all service mesh
@@ -81,3 +84,62 @@ func (e *MeshConfigEntry) MarshalJSON() ([]byte, error) {
 	}
 	return json.Marshal(source)
 }
+
+// MeshPeering returns the peering configuration of the mesh config entry, or
+// nil if the entry does not exist or has no peering configuration.
+func (conf *ConfigEntries) MeshPeering(q *QueryOptions) (*PeeringMeshConfig, *QueryMeta, error) {
+	entry, qm, err := conf.Get(MeshConfig, MeshConfigMesh, q)
+	if err != nil {
+		var statusE StatusError
+		if errors.As(err, &statusE) && statusE.Code == http.StatusNotFound {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	mesh, ok := entry.(*MeshConfigEntry)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected config entry type %T", entry)
+	}
+	return mesh.Peering, qm, nil
+}
+
+// SetMeshPeering sets the peering configuration of the mesh config entry,
+// creating the entry if it does not exist. The other fields of an existing
+// entry are preserved. The write is a Check-And-Set against the entry that
+// was read, so it fails rather than overwriting a concurrent modification.
+func (conf *ConfigEntries) SetMeshPeering(peering *PeeringMeshConfig, w *WriteOptions) (*WriteMeta, error) {
+	var q *QueryOptions
+	if w != nil {
+		q = (&QueryOptions{
+			Partition:  w.Partition,
+			Datacenter: w.Datacenter,
+			Token:      w.Token,
+		}).WithContext(w.Context())
+	}
+
+	mesh := &MeshConfigEntry{}
+	entry, _, err := conf.Get(MeshConfig, MeshConfigMesh, q)
+	if err == nil {
+		existing, ok := entry.(*MeshConfigEntry)
+		if !ok {
+			return nil, fmt.Errorf("unexpected config entry type %T", entry)
+		}
+		mesh = existing
+	} else {
+		var statusE StatusError
+		if !errors.As(err, &statusE) || statusE.Code != http.StatusNotFound {
+			return nil, err
+		}
+	}
+
+	mesh.Peering = peering
+	ok, wm, err := conf.CAS(mesh, mesh.ModifyIndex, w)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("mesh config entry was modified concurrently")
+	}
+	return wm, nil
+}
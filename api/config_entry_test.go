@@ -266,6 +266,56 @@ func TestAPI_ConfigEntries(t *testing.T) {
 		})
 	})
 
+	t.Run("Mesh peering", func(t *testing.T) {
+		ce := c.ConfigEntries()
+
+		testutil.RunStep(t, "read missing", func(t *testing.T) {
+			peering, _, err := ce.MeshPeering(nil)
+			require.NoError(t, err)
+			require.Nil(t, peering)
+		})
+
+		testutil.RunStep(t, "set and read", func(t *testing.T) {
+			_, err := ce.SetMeshPeering(&PeeringMeshConfig{PeerThroughMeshGateways: true}, nil)
+			require.NoError(t, err)
+
+			peering, qm, err := ce.MeshPeering(nil)
+			require.NoError(t, err)
+			require.NotNil(t, qm)
+			require.Equal(t, &PeeringMeshConfig{PeerThroughMeshGateways: true}, peering)
+		})
+
+		testutil.RunStep(t, "preserves other fields", func(t *testing.T) {
+			mesh := &MeshConfigEntry{
+				TransparentProxy: TransparentProxyMeshConfig{MeshDestinationsOnly: true},
+				Peering:          &PeeringMeshConfig{PeerThroughMeshGateways: true},
+				Partition:        defaultPartition,
+				Namespace:        defaultNamespace,
+			}
+			_, _, err := ce.Set(mesh, nil)
+			require.NoError(t, err)
+
+			_, err = ce.SetMeshPeering(&PeeringMeshConfig{PeerThroughMeshGateways: false}, nil)
+			require.NoError(t, err)
+
+			entry, _, err := ce.Get(MeshConfig, MeshConfigMesh, nil)
+			require.NoError(t, err)
+			result, ok := entry.(*MeshConfigEntry)
+			require.True(t, ok)
+
+			// ignore indexes
+			result.CreateIndex = 0
+			result.ModifyIndex = 0
+			mesh.Peering = &PeeringMeshConfig{}
+			require.Equal(t, mesh, result)
+		})
+
+		testutil.RunStep(t, "delete", func(t *testing.T) {
+			_, err := ce.Delete(MeshConfig, MeshConfigMesh, nil)
+			require.NoError(t, err)
+		})
+	})
+
 	t.Run("CAS deletion", func(t *testing.T) {
 
 		entry := &ProxyConfigEntry{
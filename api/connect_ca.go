@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -94,6 +95,11 @@ type CARoot struct {
 	// RootCertPEM is the PEM-encoded public certificate.
 	RootCertPEM string `json:"RootCert"`
 
+	// IntermediateCerts is a list of PEM-encoded intermediate certs attached to
+	// leaf certs signed by this CA. After a root rotation this includes the new
+	// root cross-signed by the previous root.
+	IntermediateCerts []string `json:",omitempty"`
+
 	// Active is true if this is the current active CA. This must only
 	// be true for exactly one CA. For any method that modifies roots in the
 	// state store, tests should be written to verify that multiple roots
@@ -154,6 +160,55 @@ func (h *Connect) CARoots(q *QueryOptions) (*CARootList, *QueryMeta, error) {
 	return &out, qm, nil
 }
 
+// WaitForCARoots blocks until the list of CA roots contains at least minRoots
+// roots, one of which is active, or until ctx is done. During a root rotation
+// the previous root is kept in the list alongside the new active one, so
+// waiting for two roots is a way to detect that a rotation has completed.
+//
+// The last root list observed is returned, even when ctx expires, so callers
+// can inspect it.
+func (h *Connect) WaitForCARoots(ctx context.Context, minRoots int) (*CARootList, error) {
+	var (
+		index uint64
+		last  *CARootList
+	)
+	for {
+		q := &QueryOptions{WaitIndex: index}
+		list, qm, err := h.CARoots(q.WithContext(ctx))
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return last, ctxErr
+			}
+			return last, err
+		}
+		last = list
+
+		if caRootsReady(list, minRoots) {
+			return list, nil
+		}
+
+		// Reset the index if it goes backwards, as documented for blocking
+		// queries.
+		if qm.LastIndex < index {
+			index = 0
+		} else {
+			index = qm.LastIndex
+		}
+	}
+}
+
+func caRootsReady(list *CARootList, minRoots int) bool {
+	if len(list.Roots) < minRoots {
+		return false
+	}
+	for _, root := range list.Roots {
+		if root.Active && root.ID == list.ActiveRootID {
+			return true
+		}
+	}
+	return false
+}
+
 // CAGetConfig returns the current CA configuration.
 func (h *Connect) CAGetConfig(q *QueryOptions) (*CAConfig, *QueryMeta, error) {
 	r := h.c.newRequest("GET", "/v1/connect/ca/configuration")
@@ -196,3 +251,36 @@ func (h *Connect) CASetConfig(conf *CAConfig, q *WriteOptions) (*WriteMeta, erro
 	wm.RequestTime = rtt
 	return wm, nil
 }
+
+// CARotateRootResponse is the response from a forced CA root rotation.
+type CARotateRootResponse struct {
+	// RootID is the ID of the newly generated active root.
+	RootID string
+}
+
+// CARotateRoot forces the CA to generate a new root certificate using the
+// existing provider configuration and begin a root rotation, as if the
+// configuration had been changed. The previous root is kept and cross-signs
+// the new one, following the same process as CASetConfig. The ID of the new
+// active root is returned.
+func (h *Connect) CARotateRoot(q *WriteOptions) (string, *WriteMeta, error) {
+	r := h.c.newRequest("PUT", "/v1/connect/ca/rotate")
+	r.setWriteOptions(q)
+	rtt, resp, err := h.c.doRequest(r)
+	if err != nil {
+		return "", nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return "", nil, err
+	}
+
+	wm := &WriteMeta{}
+	wm.RequestTime = rtt
+
+	var out CARotateRootResponse
+	if err := decodeBody(resp, &out); err != nil {
+		return "", nil, err
+	}
+	return out.RootID, wm, nil
+}
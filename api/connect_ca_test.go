@@ -1,6 +1,10 @@
 package api
 
 import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
 	"testing"
 	"time"
 
@@ -56,6 +60,108 @@ func TestAPI_ConnectCARoots_list(t *testing.T) {
 
 }
 
+func TestAPI_ConnectCAWaitForCARoots(t *testing.T) {
+	t.Parallel()
+
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+	connect := c.Connect()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	list, err := connect.WaitForCARoots(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, list.Roots, 1)
+	require.True(t, list.Roots[0].Active)
+	require.Equal(t, list.ActiveRootID, list.Roots[0].ID)
+
+	t.Run("context expires", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		list, err := connect.WaitForCARoots(ctx, 2)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.NotNil(t, list)
+		require.Len(t, list.Roots, 1)
+	})
+
+	t.Run("root rotation", func(t *testing.T) {
+		conf, _, err := connect.CAGetConfig(nil)
+		require.NoError(t, err)
+
+		conf.Config["PrivateKeyType"] = "ec"
+		conf.Config["PrivateKeyBits"] = 384
+		_, err = connect.CASetConfig(conf, nil)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		list, err := connect.WaitForCARoots(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, list.Roots, 2)
+		require.NotEqual(t, list.Roots[0].ID, list.Roots[1].ID)
+	})
+}
+
+func TestAPI_ConnectCARoots_IntermediateCerts(t *testing.T) {
+	t.Parallel()
+
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+	connect := c.Connect()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	list, err := connect.WaitForCARoots(ctx, 1)
+	require.NoError(t, err)
+	oldRoot := list.Roots[0]
+	require.Empty(t, oldRoot.IntermediateCerts)
+
+	conf, _, err := connect.CAGetConfig(nil)
+	require.NoError(t, err)
+	conf.Config["PrivateKeyType"] = "ec"
+	conf.Config["PrivateKeyBits"] = 384
+	_, err = connect.CASetConfig(conf, nil)
+	require.NoError(t, err)
+
+	list, err = connect.WaitForCARoots(ctx, 2)
+	require.NoError(t, err)
+
+	var newRoot *CARoot
+	for _, root := range list.Roots {
+		if root.ID == list.ActiveRootID {
+			newRoot = root
+		}
+	}
+	require.NotNil(t, newRoot)
+	require.NotEqual(t, oldRoot.ID, newRoot.ID)
+	require.NotEmpty(t, newRoot.IntermediateCerts)
+
+	// The new root is cross-signed by the previous root.
+	oldCert := parseTestCert(t, oldRoot.RootCertPEM)
+	newCert := parseTestCert(t, newRoot.RootCertPEM)
+	crossSigned := parseTestCert(t, newRoot.IntermediateCerts[0])
+	require.NoError(t, crossSigned.CheckSignatureFrom(oldCert))
+	require.Equal(t, newCert.PublicKey, crossSigned.PublicKey)
+}
+
+func parseTestCert(t *testing.T, certPEM string) *x509.Certificate {
+	t.Helper()
+
+	block, _ := pem.Decode([]byte(certPEM))
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	return cert
+}
+
 func TestAPI_ConnectCAConfig_get_set(t *testing.T) {
 	t.Parallel()
 
@@ -105,3 +211,76 @@ func TestAPI_ConnectCAConfig_get_set(t *testing.T) {
 		require.Equal(r, "bar", updated.State["foo"])
 	})
 }
+
+func TestAPI_ConnectCAGetConfig_State(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	mapi.withReply("GET", "/v1/connect/ca/configuration", nil, 200, strings.NewReader(`{
+		"Provider": "vault",
+		"Config": {"Address": "http://vault:8200"},
+		"State": {"intermediate_pki_namespace": "admin", "rotation": "complete"},
+		"CreateIndex": 5,
+		"ModifyIndex": 9
+	}`)).Once()
+
+	conf, _, err := client.Connect().CAGetConfig(nil)
+	require.NoError(t, err)
+	require.Equal(t, &CAConfig{
+		Provider: "vault",
+		Config:   map[string]interface{}{"Address": "http://vault:8200"},
+		State: map[string]string{
+			"intermediate_pki_namespace": "admin",
+			"rotation":                   "complete",
+		},
+		CreateIndex: 5,
+		ModifyIndex: 9,
+	}, conf)
+
+	t.Run("missing state", func(t *testing.T) {
+		mapi.withReply("GET", "/v1/connect/ca/configuration", nil, 200, strings.NewReader(`{
+			"Provider": "consul",
+			"Config": {"LeafCertTTL": "72h"}
+		}`)).Once()
+
+		conf, _, err := client.Connect().CAGetConfig(nil)
+		require.NoError(t, err)
+		require.Equal(t, "consul", conf.Provider)
+		require.Nil(t, conf.State)
+	})
+}
+
+func TestAPI_ConnectCARotateRoot(t *testing.T) {
+	t.Parallel()
+
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+	connect := c.Connect()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	list, err := connect.WaitForCARoots(ctx, 1)
+	require.NoError(t, err)
+	oldRoot := list.Roots[0]
+
+	rootID, _, err := connect.CARotateRoot(nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, rootID)
+	require.NotEqual(t, oldRoot.ID, rootID)
+
+	list, err = connect.WaitForCARoots(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, rootID, list.ActiveRootID)
+
+	var newRoot *CARoot
+	for _, root := range list.Roots {
+		if root.ID == rootID {
+			newRoot = root
+		}
+	}
+	require.NotNil(t, newRoot)
+	require.NotEqual(t, oldRoot.RootCertPEM, newRoot.RootCertPEM)
+	require.Len(t, newRoot.IntermediateCerts, 1)
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -295,6 +296,65 @@ func (h *Health) ServiceMultipleTags(service string, tags []string, passingOnly
 	return h.service(service, tags, passingOnly, q, serviceHealth)
 }
 
+// PeeredService is equivalent to Service except that it returns the instances
+// of the service imported from the given peer instead of local ones.
+func (h *Health) PeeredService(service, peerName string, passingOnly bool, q *QueryOptions) ([]*ServiceEntry, *QueryMeta, error) {
+	if peerName == "" {
+		return nil, nil, fmt.Errorf("peer name is required")
+	}
+
+	var opts QueryOptions
+	if q != nil {
+		opts = *q
+	}
+	opts.Peer = peerName
+	return h.service(service, nil, passingOnly, &opts, serviceHealth)
+}
+
+// WaitForInstances blocks until exactly want passing instances of the service
+// are registered, and returns them. It watches the service with blocking
+// queries, so q can be used to set the datacenter, filter or maximum wait of
+// each query. An error reporting the last number of passing instances is
+// returned if ctx is done first.
+func (h *Health) WaitForInstances(ctx context.Context, service string, want int, q *QueryOptions) ([]*ServiceEntry, *QueryMeta, error) {
+	if want < 0 {
+		return nil, nil, fmt.Errorf("want must not be negative")
+	}
+
+	var opts QueryOptions
+	if q != nil {
+		opts = *q
+	}
+	opts.WaitIndex = 0
+
+	last := -1
+	for {
+		entries, qm, err := h.service(service, nil, true, opts.WithContext(ctx), serviceHealth)
+		if err != nil {
+			if ctx.Err() != nil && last >= 0 {
+				return nil, nil, fmt.Errorf("service %q has %d passing instances, want %d: %w", service, last, want, ctx.Err())
+			}
+			return nil, nil, err
+		}
+		if len(entries) == want {
+			return entries, qm, nil
+		}
+		last = len(entries)
+
+		// Start over if the index went backwards, e.g. after a snapshot
+		// restore, as the blocking query would otherwise wait for the max
+		// wait time. The index is at least 1 so that queries always block.
+		switch {
+		case qm.LastIndex < opts.WaitIndex:
+			opts.WaitIndex = 0
+		case qm.LastIndex == 0:
+			opts.WaitIndex = 1
+		default:
+			opts.WaitIndex = qm.LastIndex
+		}
+	}
+}
+
 // Connect is equivalent to Service except that it will only return services
 // which are Connect-enabled and will returns the connection address for Connect
 // client's to use which may be a proxy in front of the named service. If
@@ -1,8 +1,12 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/sdk/testutil"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
@@ -335,6 +339,56 @@ func TestAPI_HealthService(t *testing.T) {
 	})
 }
 
+func TestAPI_HealthWaitForInstances(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+
+	register := func(i int) {
+		_, err := c.Catalog().Register(&CatalogRegistration{
+			Node:    fmt.Sprintf("node%d", i),
+			Address: fmt.Sprintf("10.0.0.%d", i),
+			Service: &AgentService{
+				ID:      fmt.Sprintf("web%d", i),
+				Service: "web",
+				Port:    8080,
+			},
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	register(1)
+	entries, _, err := c.Health().WaitForInstances(ctx, "web", 1, nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	go func() {
+		for i := 2; i <= 3; i++ {
+			time.Sleep(100 * time.Millisecond)
+			register(i)
+		}
+	}()
+
+	entries, meta, err := c.Health().WaitForInstances(ctx, "web", 3, nil)
+	require.NoError(t, err)
+	require.NotZero(t, meta.LastIndex)
+	require.Len(t, entries, 3)
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		_, _, err := c.Health().WaitForInstances(ctx, "web", 5, nil)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Contains(t, err.Error(), `service "web" has 3 passing instances, want 5`)
+	})
+}
+
 func TestAPI_HealthService_SingleTag(t *testing.T) {
 	t.Parallel()
 	c, s := makeClientWithConfig(t, nil, func(conf *testutil.TestServerConfig) {
@@ -670,3 +724,30 @@ func TestAPI_HealthState_Filter(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, checks, 1)
 }
+
+func TestAPI_HealthPeeredService(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	mapi.static("GET", "/v1/health/service/web", nil).Return(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "peer1", r.URL.Query().Get("peer"))
+		require.Equal(t, "1", r.URL.Query().Get(HealthPassing))
+		require.Equal(t, "dc2", r.URL.Query().Get("dc"))
+
+		require.NoError(t, json.NewEncoder(w).Encode([]*ServiceEntry{{
+			Node:    &Node{Node: "node1", PeerName: "peer1"},
+			Service: &AgentService{Service: "web", PeerName: "peer1"},
+		}}))
+	}).Once()
+
+	q := &QueryOptions{Datacenter: "dc2"}
+	entries, _, err := client.Health().PeeredService("web", "peer1", true, q)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "peer1", entries[0].Service.PeerName)
+
+	// The caller's options are not modified.
+	require.Empty(t, q.Peer)
+
+	_, _, err = client.Health().PeeredService("web", "", false, nil)
+	require.EqualError(t, err, "peer name is required")
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -68,7 +70,13 @@ type Peering struct {
 	// PeerServerName is the name of the remote server as it relates to TLS.
 	PeerServerName string `json:",omitempty"`
 	// PeerServerAddresses contains all the connection addresses for the remote peer.
+	// These are initially taken from the peering token and are superseded by
+	// the addresses learned from the peering stream.
 	PeerServerAddresses []string `json:",omitempty"`
+	// ManualServerAddresses contains the server addresses that were manually
+	// specified when generating the peering token, for example through
+	// ServerExternalAddresses. If set, they are used instead of PeerServerAddresses.
+	ManualServerAddresses []string `json:",omitempty"`
 	// StreamStatus contains information computed on read based on the state of the stream.
 	StreamStatus PeeringStreamStatus
 	// CreateIndex is the Raft index at which the Peering was created.
@@ -77,6 +85,20 @@ type Peering struct {
 	ModifyIndex uint64
 	// Remote contains metadata for the remote peer.
 	Remote PeeringRemoteInfo
+	// HasEstablishmentSecret is true if a peering token generated for this
+	// peering can still be used to establish it.
+	HasEstablishmentSecret bool `json:",omitempty"`
+	// HasActiveStreamSecret is true if a secret is in use to authenticate the
+	// peering stream. The secret itself is never returned.
+	HasActiveStreamSecret bool `json:",omitempty"`
+	// HasPendingStreamSecret is true if a new stream secret was exchanged but
+	// not yet used by the peer.
+	HasPendingStreamSecret bool `json:",omitempty"`
+
+	// LastStreamErr is the error that disconnected the peering stream, which
+	// tells why a peering is failing. It is empty while the stream is
+	// connected.
+	LastStreamErr string `json:",omitempty"`
 }
 
 type PeeringStreamStatus struct {
@@ -90,6 +112,30 @@ type PeeringStreamStatus struct {
 	LastReceive *time.Time
 	// LastSend represents when any message was last sent, regardless of success or error.
 	LastSend *time.Time
+	// MaxMessageSize is the largest replication message, in bytes, sent to the
+	// peer on the current stream. Exported service updates above it are split
+	// across several messages when the peer supports it. It is zero while the
+	// stream isn't established.
+	MaxMessageSize uint64 `json:",omitempty"`
+}
+
+// PeeringTrustBundle holds the root certificates a peer shares with this
+// cluster, which are used to validate the requests coming from the peer.
+type PeeringTrustBundle struct {
+	// TrustDomain is the domain of the bundle, without the "spiffe://" prefix.
+	TrustDomain string
+	// PeerName is the name of the peering the bundle was imported from.
+	PeerName string
+	// Partition is the local partition of the peering.
+	Partition string `json:",omitempty"`
+	// RootPEMs are the PEM encoded root certificates of the peer.
+	RootPEMs []string
+	// ExportedPartition is the partition of the peer which sent the bundle.
+	ExportedPartition string `json:",omitempty"`
+	// CreateIndex is the Raft index at which the bundle was created.
+	CreateIndex uint64
+	// ModifyIndex is the latest Raft index at which the bundle was modified.
+	ModifyIndex uint64
 }
 
 type PeeringReadResponse struct {
@@ -107,6 +153,9 @@ type PeeringGenerateTokenRequest struct {
 	// load balancer(s) or external IPs to reach the servers from the dialing side, and will override any server
 	// addresses obtained from the "consul" service.
 	ServerExternalAddresses []string `json:",omitempty"`
+	// ExpiryTime is the time after which the generated token can no longer be used
+	// to establish a peering. Tokens do not expire when this is unset.
+	ExpiryTime *time.Time `json:",omitempty"`
 }
 
 type PeeringGenerateTokenResponse struct {
@@ -115,6 +164,43 @@ type PeeringGenerateTokenResponse struct {
 	PeeringToken string
 }
 
+// PeeringToken is the decoded content of a peering token returned by
+// GenerateToken. It is only meant for inspecting tokens, the establishment
+// secret embedded in the token is not exposed.
+type PeeringToken struct {
+	// CA contains the CA certificates of the servers that generated the token.
+	CA []string
+	// ManualServerAddresses contains the addresses that were set through
+	// ServerExternalAddresses when generating the token. If set, the dialing
+	// cluster uses them instead of ServerAddresses.
+	ManualServerAddresses []string `json:",omitempty"`
+	// ServerAddresses contains the addresses of the servers that generated the token.
+	ServerAddresses []string
+	// ServerName is the name of the servers as it relates to TLS.
+	ServerName string
+	// PeerID is the ID of the peering in the cluster that generated the token.
+	PeerID string
+	// Remote contains metadata for the cluster that generated the token.
+	Remote PeeringRemoteInfo
+	// ExpiryTime is the time after which the token can no longer be used.
+	ExpiryTime *time.Time `json:",omitempty"`
+}
+
+// ParsePeeringToken decodes a peering token generated by GenerateToken. It
+// does not contact Consul, and does not validate the token beyond decoding it.
+func ParsePeeringToken(token string) (*PeeringToken, error) {
+	raw, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode peering token: %w", err)
+	}
+
+	var out PeeringToken
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal peering token: %w", err)
+	}
+	return &out, nil
+}
+
 type PeeringEstablishRequest struct {
 	// Name of the remote peer.
 	PeerName string
@@ -124,6 +210,11 @@ type PeeringEstablishRequest struct {
 	Partition string `json:",omitempty"`
 	// Meta is a mapping of some string value to any other string value
 	Meta map[string]string `json:",omitempty"`
+	// DialTimeout bounds how long the servers try to reach the servers of the
+	// remote peer to establish the peering. Establish fails with a timeout
+	// error once it elapses. It is encoded as a duration string such as "30s";
+	// leaving it unset keeps the establishment unbounded.
+	DialTimeout *ReadableDuration `json:",omitempty"`
 }
 
 type PeeringEstablishResponse struct {
@@ -177,6 +268,144 @@ func (p *Peerings) Read(ctx context.Context, name string, q *QueryOptions) (*Pee
 	return &out, qm, nil
 }
 
+// ReadMany returns the peerings with the given names, reading all of them in
+// a single request. The peerings are filtered by name by the agent, so only
+// the requested ones are sent back. The returned map has an entry for each
+// name, which is nil when the peering does not exist.
+func (p *Peerings) ReadMany(ctx context.Context, names []string, q *QueryOptions) (map[string]*Peering, *QueryMeta, error) {
+	out := make(map[string]*Peering, len(names))
+	for _, name := range names {
+		if name == "" {
+			return nil, nil, fmt.Errorf("peering name cannot be empty")
+		}
+		out[name] = nil
+	}
+
+	req := p.c.newRequest("GET", "/v1/peerings")
+	req.setQueryOptions(q)
+	req.ctx = ctx
+	for name := range out {
+		req.params.Add("name", name)
+	}
+
+	rtt, resp, err := p.c.doRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, nil, err
+	}
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var peerings []*Peering
+	if err := decodeBody(resp, &peerings); err != nil {
+		return nil, nil, err
+	}
+
+	for _, peering := range peerings {
+		if _, ok := out[peering.Name]; ok {
+			out[peering.Name] = peering
+		}
+	}
+	return out, qm, nil
+}
+
+// TrustBundleRead returns the trust bundle imported from the peer with the
+// given name, or nil if the peer hasn't sent one yet.
+func (p *Peerings) TrustBundleRead(ctx context.Context, peerName string, q *QueryOptions) (*PeeringTrustBundle, *QueryMeta, error) {
+	if peerName == "" {
+		return nil, nil, fmt.Errorf("peering name cannot be empty")
+	}
+
+	req := p.c.newRequest("GET", fmt.Sprintf("/v1/peering/%s/trust-bundle", peerName))
+	req.setQueryOptions(q)
+	req.ctx = ctx
+
+	rtt, resp, err := p.c.doRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	found, resp, err := requireNotFoundOrOK(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	if !found {
+		return nil, qm, nil
+	}
+
+	var out PeeringTrustBundle
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+
+	return &out, qm, nil
+}
+
+// TrustBundleList returns the trust bundles imported from all peers. Peerings
+// which haven't received a trust bundle yet are left out. It is built from
+// several requests, so the bundles may be read at different indexes; the
+// returned QueryMeta is the one of the peering list.
+func (p *Peerings) TrustBundleList(ctx context.Context, q *QueryOptions) ([]*PeeringTrustBundle, *QueryMeta, error) {
+	peerings, qm, err := p.List(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out []*PeeringTrustBundle
+	for _, peering := range peerings {
+		bundle, _, err := p.TrustBundleRead(ctx, peering.Name, q)
+		if err != nil {
+			return nil, nil, err
+		}
+		if bundle != nil {
+			out = append(out, bundle)
+		}
+	}
+	return out, qm, nil
+}
+
+// ListExportedServices returns the names of the services exported to the peer
+// with the given name.
+func (p *Peerings) ListExportedServices(ctx context.Context, peerName string, q *QueryOptions) ([]CompoundServiceName, *QueryMeta, error) {
+	if peerName == "" {
+		return nil, nil, fmt.Errorf("peering name cannot be empty")
+	}
+
+	req := p.c.newRequest("GET", fmt.Sprintf("/v1/peering/%s/exported-services", peerName))
+	req.setQueryOptions(q)
+	req.ctx = ctx
+
+	rtt, resp, err := p.c.doRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, nil, err
+	}
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out []CompoundServiceName
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+
+	return out, qm, nil
+}
+
 func (p *Peerings) Delete(ctx context.Context, name string, q *WriteOptions) (*WriteMeta, error) {
 	if name == "" {
 		return nil, fmt.Errorf("peering name cannot be empty")
@@ -199,6 +428,53 @@ func (p *Peerings) Delete(ctx context.Context, name string, q *WriteOptions) (*W
 	return wm, nil
 }
 
+// peeringDeleteWaitInterval is how often DeleteAndWait reads a peering while
+// waiting for it to be removed.
+const peeringDeleteWaitInterval = 250 * time.Millisecond
+
+// DeleteAndWait deletes the peering with the given name and blocks until it
+// has been removed. A deleted peering stays in the DELETING state until its
+// imported data has been cleaned up, and an error reporting its state is
+// returned if it still exists once the timeout has elapsed. The timeout must
+// be positive, the peering isn't deleted otherwise.
+func (p *Peerings) DeleteAndWait(ctx context.Context, name string, timeout time.Duration) error {
+	if timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got %s", timeout)
+	}
+
+	if _, err := p.Delete(ctx, name, nil); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		lastState PeeringState
+		lastErr   error
+	)
+	for {
+		peering, _, err := p.Read(ctx, name, nil)
+		switch {
+		case err != nil:
+			lastErr = err
+		case peering == nil:
+			return nil
+		default:
+			lastState = peering.State
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastState != "" {
+				return fmt.Errorf("peering %q is still in the %s state after %s", name, lastState, timeout)
+			}
+			return fmt.Errorf("failed to wait for peering %q to be deleted: %w", name, lastErr)
+		case <-time.After(peeringDeleteWaitInterval):
+		}
+	}
+}
+
 // TODO(peering): verify this is the ultimate signature we want
 func (p *Peerings) GenerateToken(ctx context.Context, g PeeringGenerateTokenRequest, wq *WriteOptions) (*PeeringGenerateTokenResponse, *WriteMeta, error) {
 	if g.PeerName == "" {
@@ -280,3 +556,46 @@ func (p *Peerings) List(ctx context.Context, q *QueryOptions) ([]*Peering, *Quer
 
 	return out, qm, nil
 }
+
+// ListByState returns the peerings that are in the given state. An empty state
+// returns all peerings, like List.
+func (p *Peerings) ListByState(ctx context.Context, state PeeringState, q *QueryOptions) ([]*Peering, *QueryMeta, error) {
+	req := p.c.newRequest("GET", "/v1/peerings")
+	req.setQueryOptions(q)
+	req.ctx = ctx
+	if state != "" {
+		req.params.Set("state", string(state))
+	}
+
+	rtt, resp, err := p.c.doRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, nil, err
+	}
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out []*Peering
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+
+	if state == "" {
+		return out, qm, nil
+	}
+
+	// Older servers ignore the state parameter, so filter the response here
+	// as well.
+	filtered := make([]*Peering, 0, len(out))
+	for _, peering := range out {
+		if peering.State == state {
+			filtered = append(filtered, peering)
+		}
+	}
+	return filtered, qm, nil
+}
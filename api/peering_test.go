@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"net/http"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/hashicorp/consul/sdk/testutil"
@@ -249,14 +252,186 @@ func TestAPI_Peering_GenerateToken_ExternalAddresses(t *testing.T) {
 	require.NotNil(t, wm)
 	require.NotNil(t, resp)
 
-	tokenJSON, err := base64.StdEncoding.DecodeString(resp.PeeringToken)
+	token, err := ParsePeeringToken(resp.PeeringToken)
 	require.NoError(t, err)
+	require.Equal(t, []string{s.GRPCTLSAddr}, token.ServerAddresses)
+	require.Equal(t, []string{externalAddress}, token.ManualServerAddresses)
+}
+
+func TestAPI_Peering_GenerateToken_ServerExternalAddresses_Marshal(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	gatewayAddrs := []string{"198.51.100.1:8443", "198.51.100.2:8443"}
+
+	// The gateway addresses must be threaded through to the request body.
+	body := mock.MatchedBy(func(body []byte) bool {
+		var req PeeringGenerateTokenRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return false
+		}
+		return req.PeerName == "peer1" && reflect.DeepEqual(gatewayAddrs, req.ServerExternalAddresses)
+	})
+
+	// The token of a server behind mesh gateways advertises the gateway
+	// addresses as manual server addresses.
+	tokenJSON, err := json.Marshal(PeeringToken{
+		ServerAddresses:       []string{"10.0.0.1:8503"},
+		ManualServerAddresses: gatewayAddrs,
+		ServerName:            "server.dc1.peering.11111111-2222-3333-4444-555555555555.consul",
+		PeerID:                "9e650110-ac74-4c5a-a6a8-9348b2bed4e9",
+	})
+	require.NoError(t, err)
+	reply := PeeringGenerateTokenResponse{PeeringToken: base64.StdEncoding.EncodeToString(tokenJSON)}
+	mapi.withReply("POST", "/v1/peering/token", body, 200, reply).Once()
+
+	p := PeeringGenerateTokenRequest{
+		PeerName:                "peer1",
+		ServerExternalAddresses: gatewayAddrs,
+	}
 
-	// Put the token in an arbitrary map, because the struct isn't available in the api package.
-	token := make(map[string]interface{})
-	require.NoError(t, json.Unmarshal(tokenJSON, &token))
-	require.Equal(t, []interface{}{s.GRPCTLSAddr}, token["ServerAddresses"])
-	require.Equal(t, []interface{}{externalAddress}, token["ManualServerAddresses"])
+	encoded, err := json.Marshal(p)
+	require.NoError(t, err)
+	require.Contains(t, string(encoded), `"ServerExternalAddresses":["198.51.100.1:8443","198.51.100.2:8443"]`)
+
+	resp, _, err := client.Peerings().GenerateToken(context.Background(), p, nil)
+	require.NoError(t, err)
+
+	token, err := ParsePeeringToken(resp.PeeringToken)
+	require.NoError(t, err)
+	require.Equal(t, gatewayAddrs, token.ManualServerAddresses)
+
+	// The field is omitted entirely when unset.
+	encoded, err = json.Marshal(PeeringGenerateTokenRequest{PeerName: "peer1"})
+	require.NoError(t, err)
+	require.NotContains(t, string(encoded), "ServerExternalAddresses")
+}
+
+func TestAPI_Peering_Establish_DialTimeout(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	// The timeout is sent along with the request and bounds the establishment
+	// on the servers, which report a gateway timeout once it elapses.
+	body := mock.MatchedBy(func(body []byte) bool {
+		var req PeeringEstablishRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return false
+		}
+		return req.PeerName == "peer1" && req.DialTimeout.Duration() == 2*time.Second
+	})
+	reply := strings.NewReader("Timed out after 2s establishing the peering: context deadline exceeded")
+	mapi.withReply("POST", "/v1/peering/establish", body, http.StatusGatewayTimeout, reply).Once()
+
+	_, _, err := client.Peerings().Establish(context.Background(), PeeringEstablishRequest{
+		PeerName:     "peer1",
+		PeeringToken: "abc",
+		DialTimeout:  NewReadableDuration(2 * time.Second),
+	}, nil)
+	require.Error(t, err)
+
+	var statusErr StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, http.StatusGatewayTimeout, statusErr.Code)
+	require.Contains(t, statusErr.Body, "Timed out after 2s establishing the peering")
+
+	// The timeout is encoded as a duration string.
+	encoded, err := json.Marshal(PeeringEstablishRequest{PeerName: "peer1", DialTimeout: NewReadableDuration(2 * time.Second)})
+	require.NoError(t, err)
+	require.Contains(t, string(encoded), `"DialTimeout":"2s"`)
+
+	// The field is omitted entirely when unset.
+	encoded, err = json.Marshal(PeeringEstablishRequest{PeerName: "peer1"})
+	require.NoError(t, err)
+	require.NotContains(t, string(encoded), "DialTimeout")
+}
+
+func TestAPI_Peering_GenerateToken_Parse(t *testing.T) {
+	t.Parallel()
+
+	c, s := makeClient(t)
+	defer s.Stop()
+	s.WaitForSerfCheck(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, _, err := c.Peerings().GenerateToken(ctx, PeeringGenerateTokenRequest{PeerName: "peer1"}, nil)
+	require.NoError(t, err)
+
+	token, err := ParsePeeringToken(resp.PeeringToken)
+	require.NoError(t, err)
+
+	peering, _, err := c.Peerings().Read(ctx, "peer1", nil)
+	require.NoError(t, err)
+	require.NotNil(t, peering)
+
+	require.Equal(t, peering.ID, token.PeerID)
+	require.Equal(t, []string{s.GRPCTLSAddr}, token.ServerAddresses)
+	require.Empty(t, token.ManualServerAddresses)
+	require.NotEmpty(t, token.ServerName)
+	require.Len(t, token.CA, 1)
+	require.Equal(t, "dc1", token.Remote.Datacenter)
+}
+
+func TestAPI_ParsePeeringToken(t *testing.T) {
+	// A token generated by a dc1 server.
+	const fixture = "eyJDQSI6WyItLS0tLUJFR0lOIENFUlRJRklDQVRFLS0tLS1cbk1JSUNEakNDQWJXZ0F3SUJBZ0lCQnpBS0JnZ3Foa2pPUFFRREFqQXhNUzh3TFFZRFZRUURFeVp3Y21rdE1XUmpcbi0tLS0tRU5EIENFUlRJRklDQVRFLS0tLS1cbiJdLCJNYW51YWxTZXJ2ZXJBZGRyZXNzZXMiOm51bGwsIlNlcnZlckFkZHJlc3NlcyI6WyIxMC4wLjAuMTo4NTAzIiwiMTAuMC4wLjI6ODUwMyJdLCJTZXJ2ZXJOYW1lIjoic2VydmVyLmRjMS5wZWVyaW5nLjExMTExMTExLTIyMjItMzMzMy00NDQ0LTU1NTU1NTU1NTU1NS5jb25zdWwiLCJQZWVySUQiOiI5ZTY1MDExMC1hYzc0LTRjNWEtYTZhOC05MzQ4YjJiZWQ0ZTkiLCJFc3RhYmxpc2htZW50U2VjcmV0IjoiNWE4YTJmNWQtNGYyNC1jODRiLThhOGMtZjRiY2Q4ZGJiNmUzIiwiUmVtb3RlIjp7IlBhcnRpdGlvbiI6ImRlZmF1bHQiLCJEYXRhY2VudGVyIjoiZGMxIn19"
+
+	token, err := ParsePeeringToken(fixture)
+	require.NoError(t, err)
+	require.Equal(t, &PeeringToken{
+		CA:              []string{"-----BEGIN CERTIFICATE-----\nMIICDjCCAbWgAwIBAgIBBzAKBggqhkjOPQQDAjAxMS8wLQYDVQQDEyZwcmktMWRj\n-----END CERTIFICATE-----\n"},
+		ServerAddresses: []string{"10.0.0.1:8503", "10.0.0.2:8503"},
+		ServerName:      "server.dc1.peering.11111111-2222-3333-4444-555555555555.consul",
+		PeerID:          "9e650110-ac74-4c5a-a6a8-9348b2bed4e9",
+		Remote: PeeringRemoteInfo{
+			Partition:  "default",
+			Datacenter: "dc1",
+		},
+	}, token)
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := ParsePeeringToken("not-a-token")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to decode peering token")
+
+		_, err = ParsePeeringToken(base64.StdEncoding.EncodeToString([]byte("{not json")))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal peering token")
+	})
+}
+
+func TestAPI_Peering_GenerateToken_ExpiryTime(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	expiry := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+	// The expiry time must be threaded through to the request body.
+	body := mock.MatchedBy(func(body []byte) bool {
+		var req PeeringGenerateTokenRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return false
+		}
+		return req.PeerName == "peer1" && req.ExpiryTime != nil && expiry.Equal(*req.ExpiryTime)
+	})
+	mapi.withReply("POST", "/v1/peering/token", body, 200, PeeringGenerateTokenResponse{PeeringToken: "token"}).Once()
+
+	p := PeeringGenerateTokenRequest{
+		PeerName:   "peer1",
+		ExpiryTime: &expiry,
+	}
+
+	encoded, err := json.Marshal(p)
+	require.NoError(t, err)
+	require.Contains(t, string(encoded), `"ExpiryTime":"2030-01-02T03:04:05Z"`)
+
+	resp, _, err := client.Peerings().GenerateToken(context.Background(), p, nil)
+	require.NoError(t, err)
+	require.Equal(t, "token", resp.PeeringToken)
+
+	// The field is omitted entirely when unset.
+	encoded, err = json.Marshal(PeeringGenerateTokenRequest{PeerName: "peer1"})
+	require.NoError(t, err)
+	require.NotContains(t, string(encoded), "ExpiryTime")
 }
 
 // TestAPI_Peering_GenerateToken_Read_Establish_Delete tests the following use case:
@@ -381,3 +556,374 @@ func TestAPI_Peering_GenerateToken_Read_Establish_Delete(t *testing.T) {
 		})
 	})
 }
+
+func TestAPI_Peering_Read_ServerAddresses(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	body := strings.NewReader(`{
+		"ID": "9e650110-ac74-4c5a-a6a8-9348b2bed4e9",
+		"Name": "peer1",
+		"State": "ACTIVE",
+		"PeerServerAddresses": ["10.0.0.1:8502", "10.0.0.2:8502"],
+		"ManualServerAddresses": ["203.0.113.10:8443"]
+	}`)
+	mapi.withReply("GET", "/v1/peering/peer1", nil, 200, body).Once()
+
+	peering, _, err := client.Peerings().Read(context.Background(), "peer1", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1:8502", "10.0.0.2:8502"}, peering.PeerServerAddresses)
+	require.Equal(t, []string{"203.0.113.10:8443"}, peering.ManualServerAddresses)
+}
+
+func TestAPI_Peering_Read_StreamStatus(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	body := strings.NewReader(`{
+		"ID": "9e650110-ac74-4c5a-a6a8-9348b2bed4e9",
+		"Name": "peer1",
+		"State": "ACTIVE",
+		"StreamStatus": {
+			"ImportedServices": ["api", "db"],
+			"ExportedServices": ["web"],
+			"LastHeartbeat": "2022-12-01T10:00:00Z",
+			"LastReceive": "2022-12-01T10:00:01Z",
+			"LastSend": "2022-12-01T10:00:02Z"
+		}
+	}`)
+	mapi.withReply("GET", "/v1/peering/peer1", nil, 200, body).Once()
+
+	peering, _, err := client.Peerings().Read(context.Background(), "peer1", nil)
+	require.NoError(t, err)
+
+	status := peering.StreamStatus
+	require.Equal(t, []string{"api", "db"}, status.ImportedServices)
+	require.Equal(t, []string{"web"}, status.ExportedServices)
+
+	base := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	require.NotNil(t, status.LastHeartbeat)
+	require.True(t, base.Equal(*status.LastHeartbeat))
+	require.NotNil(t, status.LastReceive)
+	require.True(t, base.Add(time.Second).Equal(*status.LastReceive))
+	require.NotNil(t, status.LastSend)
+	require.True(t, base.Add(2*time.Second).Equal(*status.LastSend))
+}
+
+func TestAPI_Peering_Read_NoStreamStatus(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	body := strings.NewReader(`{"Name": "peer1", "State": "PENDING"}`)
+	mapi.withReply("GET", "/v1/peering/peer1", nil, 200, body).Once()
+
+	peering, _, err := client.Peerings().Read(context.Background(), "peer1", nil)
+	require.NoError(t, err)
+	require.Empty(t, peering.StreamStatus.ImportedServices)
+	require.Empty(t, peering.StreamStatus.ExportedServices)
+	require.Nil(t, peering.StreamStatus.LastHeartbeat)
+	require.Nil(t, peering.StreamStatus.LastReceive)
+	require.Nil(t, peering.StreamStatus.LastSend)
+}
+
+func TestAPI_Peering_Read_SecretsStatus(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	body := strings.NewReader(`{
+		"ID": "9e650110-ac74-4c5a-a6a8-9348b2bed4e9",
+		"Name": "peer1",
+		"State": "ACTIVE",
+		"HasActiveStreamSecret": true,
+		"HasPendingStreamSecret": true
+	}`)
+	mapi.withReply("GET", "/v1/peering/peer1", nil, 200, body).Once()
+
+	peering, _, err := client.Peerings().Read(context.Background(), "peer1", nil)
+	require.NoError(t, err)
+	require.False(t, peering.HasEstablishmentSecret)
+	require.True(t, peering.HasActiveStreamSecret)
+	require.True(t, peering.HasPendingStreamSecret)
+
+	// Only the presence of the secrets is part of the peering.
+	out, err := json.Marshal(peering)
+	require.NoError(t, err)
+	require.NotContains(t, string(out), "SecretID")
+}
+
+func TestAPI_Peering_Read_LastStreamErr(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	body := strings.NewReader(`{
+		"ID": "9e650110-ac74-4c5a-a6a8-9348b2bed4e9",
+		"Name": "peer1",
+		"State": "FAILING",
+		"LastStreamErr": "rpc error: code = Unavailable desc = connection refused"
+	}`)
+	mapi.withReply("GET", "/v1/peering/peer1", nil, 200, body).Once()
+	mapi.withReply("GET", "/v1/peering/peer2", nil, 200, &Peering{Name: "peer2", State: PeeringStateActive}).Once()
+
+	peering, _, err := client.Peerings().Read(context.Background(), "peer1", nil)
+	require.NoError(t, err)
+	require.Equal(t, PeeringStateFailing, peering.State)
+	require.Equal(t, "rpc error: code = Unavailable desc = connection refused", peering.LastStreamErr)
+
+	peering, _, err = client.Peerings().Read(context.Background(), "peer2", nil)
+	require.NoError(t, err)
+	require.Empty(t, peering.LastStreamErr)
+
+	// The field is left out for healthy peerings.
+	out, err := json.Marshal(peering)
+	require.NoError(t, err)
+	require.NotContains(t, string(out), "LastStreamErr")
+}
+
+func TestAPI_Peering_TrustBundleRead(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	body := strings.NewReader(`{
+		"TrustDomain": "11111111-2222-3333-4444-555555555555.consul",
+		"PeerName": "peer1",
+		"RootPEMs": [
+			"-----BEGIN CERTIFICATE-----\nold\n-----END CERTIFICATE-----\n",
+			"-----BEGIN CERTIFICATE-----\nnew\n-----END CERTIFICATE-----\n"
+		],
+		"CreateIndex": 10,
+		"ModifyIndex": 20
+	}`)
+	mapi.withReply("GET", "/v1/peering/peer1/trust-bundle", nil, 200, body).Once()
+	mapi.withReply("GET", "/v1/peering/peer2/trust-bundle", nil, 404, nil).Once()
+
+	bundle, _, err := client.Peerings().TrustBundleRead(context.Background(), "peer1", nil)
+	require.NoError(t, err)
+	require.Equal(t, &PeeringTrustBundle{
+		TrustDomain: "11111111-2222-3333-4444-555555555555.consul",
+		PeerName:    "peer1",
+		RootPEMs: []string{
+			"-----BEGIN CERTIFICATE-----\nold\n-----END CERTIFICATE-----\n",
+			"-----BEGIN CERTIFICATE-----\nnew\n-----END CERTIFICATE-----\n",
+		},
+		CreateIndex: 10,
+		ModifyIndex: 20,
+	}, bundle)
+
+	bundle, _, err = client.Peerings().TrustBundleRead(context.Background(), "peer2", nil)
+	require.NoError(t, err)
+	require.Nil(t, bundle)
+
+	_, _, err = client.Peerings().TrustBundleRead(context.Background(), "", nil)
+	require.EqualError(t, err, "peering name cannot be empty")
+}
+
+func TestAPI_Peering_TrustBundleList(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	mapi.withReply("GET", "/v1/peerings", nil, 200, []*Peering{
+		{Name: "peer1", State: PeeringStateActive},
+		{Name: "peer2", State: PeeringStatePending},
+	}).Once()
+	mapi.withReply("GET", "/v1/peering/peer1/trust-bundle", nil, 200, &PeeringTrustBundle{
+		PeerName: "peer1",
+		RootPEMs: []string{"root-1", "root-2"},
+	}).Once()
+	// The second peering hasn't received a trust bundle yet.
+	mapi.withReply("GET", "/v1/peering/peer2/trust-bundle", nil, 404, nil).Once()
+
+	bundles, _, err := client.Peerings().TrustBundleList(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, bundles, 1)
+	require.Equal(t, "peer1", bundles[0].PeerName)
+	require.Equal(t, []string{"root-1", "root-2"}, bundles[0].RootPEMs)
+}
+
+func TestAPI_Peering_ListByState(t *testing.T) {
+	list := []*Peering{
+		{Name: "peer1", State: PeeringStateActive},
+		{Name: "peer2", State: PeeringStateFailing},
+		{Name: "peer3", State: PeeringStateActive},
+		{Name: "peer4", State: PeeringStateTerminated},
+	}
+
+	names := func(peerings []*Peering) []string {
+		var out []string
+		for _, p := range peerings {
+			out = append(out, p.Name)
+		}
+		return out
+	}
+
+	cases := map[string]struct {
+		state    PeeringState
+		expected []string
+	}{
+		"active": {
+			state:    PeeringStateActive,
+			expected: []string{"peer1", "peer3"},
+		},
+		"failing": {
+			state:    PeeringStateFailing,
+			expected: []string{"peer2"},
+		},
+		"empty state returns all": {
+			expected: []string{"peer1", "peer2", "peer3", "peer4"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mapi, client := setupMockAPI(t)
+
+			// The mock ignores the state parameter like an older server would,
+			// so the client must filter the response itself.
+			mapi.static("GET", "/v1/peerings", nil).Return(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, string(tc.state), r.URL.Query().Get("state"))
+				require.NoError(t, json.NewEncoder(w).Encode(list))
+			}).Once()
+
+			peerings, qm, err := client.Peerings().ListByState(context.Background(), tc.state, nil)
+			require.NoError(t, err)
+			require.NotNil(t, qm)
+			require.Equal(t, tc.expected, names(peerings))
+		})
+	}
+}
+
+func TestAPI_Peering_ReadMany(t *testing.T) {
+	t.Parallel()
+
+	c, s := makeClientWithCA(t)
+	defer s.Stop()
+	s.WaitForSerfCheck(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCtxDuration)
+	defer cancel()
+
+	peerings := c.Peerings()
+
+	for _, name := range []string{"peer1", "peer2", "peer3"} {
+		_, _, err := peerings.GenerateToken(ctx, PeeringGenerateTokenRequest{PeerName: name}, nil)
+		require.NoError(t, err)
+	}
+
+	out, qm, err := peerings.ReadMany(ctx, []string{"peer1", "peer3", "missing"}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, qm)
+
+	require.Len(t, out, 3)
+	require.NotNil(t, out["peer1"])
+	require.Equal(t, "peer1", out["peer1"].Name)
+	require.NotNil(t, out["peer3"])
+	require.Equal(t, "peer3", out["peer3"].Name)
+	missing, ok := out["missing"]
+	require.True(t, ok)
+	require.Nil(t, missing)
+
+	_, _, err = peerings.ReadMany(ctx, []string{"peer1", ""}, nil)
+	require.EqualError(t, err, "peering name cannot be empty")
+}
+
+func TestAPI_Peering_DeleteAndWait(t *testing.T) {
+	t.Parallel()
+
+	c, s := makeClientWithCA(t)
+	defer s.Stop()
+	s.WaitForSerfCheck(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCtxDuration)
+	defer cancel()
+
+	peerings := c.Peerings()
+
+	_, _, err := peerings.GenerateToken(ctx, PeeringGenerateTokenRequest{PeerName: "peer1"}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, peerings.DeleteAndWait(ctx, "peer1", 10*time.Second))
+
+	resp, qm, err := peerings.Read(ctx, "peer1", nil)
+	require.NoError(t, err)
+	require.NotNil(t, qm)
+	require.Nil(t, resp)
+}
+
+func TestAPI_Peering_DeleteAndWait_Stuck(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	mapi.withReply("DELETE", "/v1/peering/peer1", nil, 200, nil).Once()
+	mapi.withReply("GET", "/v1/peering/peer1", nil, 200, &Peering{
+		Name:  "peer1",
+		State: PeeringStateDeleting,
+	})
+
+	err := client.Peerings().DeleteAndWait(context.Background(), "peer1", 600*time.Millisecond)
+	require.EqualError(t, err, `peering "peer1" is still in the DELETING state after 600ms`)
+}
+
+func TestAPI_Peering_DeleteAndWait_InvalidTimeout(t *testing.T) {
+	// No request is expected, the peering must not be deleted.
+	_, client := setupMockAPI(t)
+
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		err := client.Peerings().DeleteAndWait(context.Background(), "peer1", timeout)
+		require.EqualError(t, err, "timeout must be positive, got "+timeout.String())
+	}
+}
+
+func TestAPI_Peering_ContextCancel(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	// Both endpoints hang until the client gives up on the request, as an
+	// unreachable accepting cluster would.
+	hang := func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}
+	mapi.static("POST", "/v1/peering/token", mock.Anything).Return(hang).Once()
+	mapi.static("POST", "/v1/peering/establish", mock.Anything).Return(hang).Once()
+
+	t.Run("GenerateToken", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		resp, _, err := client.Peerings().GenerateToken(ctx, PeeringGenerateTokenRequest{PeerName: "peer1"}, nil)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Nil(t, resp)
+		require.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Establish", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+		}()
+
+		start := time.Now()
+		resp, _, err := client.Peerings().Establish(ctx, PeeringEstablishRequest{
+			PeerName:     "peer1",
+			PeeringToken: "token",
+		}, nil)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, resp)
+		require.Less(t, time.Since(start), 5*time.Second)
+	})
+}
+
+func TestAPI_Peering_ListExportedServices(t *testing.T) {
+	mapi, client := setupMockAPI(t)
+
+	body := `[
+		{"Name": "api"},
+		{"Name": "web", "Namespace": "frontend", "Partition": "default"}
+	]`
+	mapi.static("GET", "/v1/peering/peer1/exported-services", nil).Return(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Consul-Index", "42")
+		w.Write([]byte(body))
+	}).Once()
+
+	services, qm, err := client.Peerings().ListExportedServices(context.Background(), "peer1", nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), qm.LastIndex)
+	require.Equal(t, []CompoundServiceName{
+		{Name: "api"},
+		{Name: "web", Namespace: "frontend", Partition: "default"},
+	}, services)
+
+	_, _, err = client.Peerings().ListExportedServices(context.Background(), "", nil)
+	require.EqualError(t, err, "peering name cannot be empty")
+}
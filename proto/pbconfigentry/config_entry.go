@@ -294,6 +294,7 @@ func EnvoyExtensionsToStructs(args []*EnvoyExtension) []structs.EnvoyExtension {
 				Name:      args[i].Name,
 				Required:  args[i].Required,
 				Arguments: EnvoyExtensionArgumentsToStructs(args[i].Arguments),
+				Priority:  int(args[i].Priority),
 			}
 		}
 
@@ -310,6 +311,7 @@ func EnvoyExtensionsFromStructs(args []structs.EnvoyExtension) []*EnvoyExtension
 			Name:      e.Name,
 			Required:  e.Required,
 			Arguments: EnvoyExtensionArgumentsFromStructs(e.Arguments),
+			Priority:  int32(e.Priority),
 		}
 	}
 
@@ -40,6 +40,7 @@ func GenerateTokenRequestToAPI(s *GenerateTokenRequest, t *api.PeeringGenerateTo
 	t.Partition = s.Partition
 	t.Meta = s.Meta
 	t.ServerExternalAddresses = s.ServerExternalAddresses
+	t.ExpiryTime = TimePtrFromProto(s.ExpiryTime)
 }
 func GenerateTokenRequestFromAPI(t *api.PeeringGenerateTokenRequest, s *GenerateTokenRequest) {
 	if s == nil {
@@ -49,6 +50,7 @@ func GenerateTokenRequestFromAPI(t *api.PeeringGenerateTokenRequest, s *Generate
 	s.Partition = t.Partition
 	s.Meta = t.Meta
 	s.ServerExternalAddresses = t.ServerExternalAddresses
+	s.ExpiryTime = TimePtrToProto(t.ExpiryTime)
 }
 func GenerateTokenResponseToAPI(s *GenerateTokenResponse, t *api.PeeringGenerateTokenResponse) {
 	if s == nil {
@@ -82,6 +84,11 @@ func PeeringToAPI(s *Peering, t *api.Peering) {
 	if s.Remote != nil {
 		RemoteInfoToAPI(s.Remote, &t.Remote)
 	}
+	t.ManualServerAddresses = s.ManualServerAddresses
+	t.HasEstablishmentSecret = s.HasEstablishmentSecret
+	t.HasActiveStreamSecret = s.HasActiveStreamSecret
+	t.HasPendingStreamSecret = s.HasPendingStreamSecret
+	t.LastStreamErr = s.LastStreamErr
 }
 func PeeringFromAPI(t *api.Peering, s *Peering) {
 	if s == nil {
@@ -105,6 +112,11 @@ func PeeringFromAPI(t *api.Peering, s *Peering) {
 		RemoteInfoFromAPI(&t.Remote, &x)
 		s.Remote = &x
 	}
+	s.ManualServerAddresses = t.ManualServerAddresses
+	s.HasEstablishmentSecret = t.HasEstablishmentSecret
+	s.HasActiveStreamSecret = t.HasActiveStreamSecret
+	s.HasPendingStreamSecret = t.HasPendingStreamSecret
+	s.LastStreamErr = t.LastStreamErr
 }
 func RemoteInfoToAPI(s *RemoteInfo, t *api.PeeringRemoteInfo) {
 	if s == nil {
@@ -159,6 +159,7 @@ func StreamStatusToAPI(status *StreamStatus) api.PeeringStreamStatus {
 		LastHeartbeat:    TimePtrFromProto(status.LastHeartbeat),
 		LastReceive:      TimePtrFromProto(status.LastReceive),
 		LastSend:         TimePtrFromProto(status.LastSend),
+		MaxMessageSize:   status.MaxMessageSize,
 	}
 }
 
@@ -169,6 +170,7 @@ func StreamStatusFromAPI(status api.PeeringStreamStatus) *StreamStatus {
 		LastHeartbeat:    TimePtrToProto(status.LastHeartbeat),
 		LastReceive:      TimePtrToProto(status.LastReceive),
 		LastSend:         TimePtrToProto(status.LastSend),
+		MaxMessageSize:   status.MaxMessageSize,
 	}
 }
 
@@ -258,6 +260,19 @@ func (resp *PeeringListResponse) ToAPI() []*api.Peering {
 	return list
 }
 
+// TODO consider using mog for this
+func (b *PeeringTrustBundle) ToAPI() *api.PeeringTrustBundle {
+	return &api.PeeringTrustBundle{
+		TrustDomain:       b.TrustDomain,
+		PeerName:          b.PeerName,
+		Partition:         b.Partition,
+		RootPEMs:          b.RootPEMs,
+		ExportedPartition: b.ExportedPartition,
+		CreateIndex:       b.CreateIndex,
+		ModifyIndex:       b.ModifyIndex,
+	}
+}
+
 // TODO consider using mog for this
 func (resp *GenerateTokenResponse) ToAPI() *api.PeeringGenerateTokenResponse {
 	var t api.PeeringGenerateTokenResponse
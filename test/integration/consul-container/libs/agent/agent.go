@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"io"
 
 	"github.com/hashicorp/consul/api"
 )
@@ -9,8 +10,10 @@ import (
 // Agent represent a Consul agent abstraction
 type Agent interface {
 	GetAddr() (string, int)
+	GetDNSAddr() (string, int)
 	GetClient() *api.Client
 	GetName() string
+	GetNodeName() string
 	GetConfig() Config
 	GetDatacenter() string
 	IsServer() bool
@@ -18,7 +21,10 @@ type Agent interface {
 	Terminate() error
 	Upgrade(ctx context.Context, config Config) error
 	Exec(ctx context.Context, cmd []string) (int, error)
+	ExecConsul(args ...string) (stdout, stderr string, err error)
+	Logs(ctx context.Context) (io.ReadCloser, error)
 	DataDir() string
+	WaitReady(ctx context.Context, opts ReadyOptions) error
 }
 
 // Config is a set of configurations required to create a Agent
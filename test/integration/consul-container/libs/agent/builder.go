@@ -2,6 +2,7 @@ package agent
 
 import (
 	"encoding/json"
+	"os"
 	"path/filepath"
 
 	"github.com/pkg/errors"
@@ -81,6 +82,78 @@ type Builder struct {
 	conf    *agentconfig.Config
 	certs   map[string]string
 	context *BuildContext
+
+	// extraConfig is merged over the generated config by ToAgentConfig.
+	extraConfig []json.RawMessage
+
+	// sections are the top-level config sections rendered from the builder's
+	// own types, for the agent config fields without json tags. They are
+	// merged over the generated config before extraConfig.
+	sections map[string]interface{}
+
+	// err is the first error from a builder option, returned by ToAgentConfig.
+	err error
+
+	// image and version override the docker image and Consul version of the
+	// agent when set.
+	image   string
+	version string
+
+	// providedTLS is set when the TLS config was set from provided certs,
+	// which conflicts with the certs injected by the build context.
+	providedTLS bool
+
+	// providedEncryptKey is set when the gossip encryption key was set with
+	// EncryptKey, which conflicts with the key injected by the build context.
+	providedEncryptKey bool
+}
+
+// AuditSink is a destination for audit logs. Name identifies the sink; the
+// other fields map to the agent's audit sink configuration and are omitted
+// when empty.
+type AuditSink struct {
+	Name              string `json:"-"`
+	Type              string `json:"type,omitempty"`
+	Format            string `json:"format,omitempty"`
+	Path              string `json:"path,omitempty"`
+	DeliveryGuarantee string `json:"delivery_guarantee,omitempty"`
+	Mode              string `json:"mode,omitempty"`
+	RotateBytes       int    `json:"rotate_bytes,omitempty"`
+	RotateDuration    string `json:"rotate_duration,omitempty"`
+	RotateMaxFiles    int    `json:"rotate_max_files,omitempty"`
+}
+
+// auditConfig is the agent's audit section.
+type auditConfig struct {
+	Enabled bool                 `json:"enabled"`
+	Sinks   map[string]AuditSink `json:"sink"`
+}
+
+// DNSConfig configures the agent's DNS interface. The fields map to the
+// agent's dns_config block and are omitted when empty, leaving the agent
+// defaults in place. AllowStale is a pointer since the agent defaults it to
+// true.
+type DNSConfig struct {
+	AllowStale     *bool             `json:"allow_stale,omitempty"`
+	MaxStale       string            `json:"max_stale,omitempty"`
+	NodeTTL        string            `json:"node_ttl,omitempty"`
+	ServiceTTL     map[string]string `json:"service_ttl,omitempty"`
+	OnlyPassing    bool              `json:"only_passing,omitempty"`
+	EnableTruncate bool              `json:"enable_truncate,omitempty"`
+	UDPAnswerLimit int               `json:"udp_answer_limit,omitempty"`
+	ARecordLimit   int               `json:"a_record_limit,omitempty"`
+	UseCache       bool              `json:"use_cache,omitempty"`
+	CacheMaxAge    string            `json:"cache_max_age,omitempty"`
+	SOA            *DNSSOA           `json:"soa,omitempty"`
+}
+
+// DNSSOA holds the timers, in seconds, of the SOA record returned by the
+// agent. Unset timers keep their default.
+type DNSSOA struct {
+	Refresh uint32 `json:"refresh,omitempty"`
+	Retry   uint32 `json:"retry,omitempty"`
+	Expire  uint32 `json:"expire,omitempty"`
+	MinTTL  uint32 `json:"min_ttl,omitempty"`
 }
 
 // NewConfigBuilder instantiates a builder object with sensible defaults for a single consul instance
@@ -118,7 +191,7 @@ func NewConfigBuilder(ctx *BuildContext) *Builder {
 		Server:  utils.IntToPointer(8300),
 	}
 
-	if ctx != nil && (ctx.consulVersion == "local" || semver.Compare("v"+ctx.consulVersion, "v1.14.0") >= 0) {
+	if ctx != nil && supportsGRPCTLS(ctx.consulVersion) {
 		// Enable GRPCTLS for version after v1.14.0
 		b.conf.Ports.GRPCTLS = utils.IntToPointer(8503)
 	}
@@ -126,6 +199,35 @@ func NewConfigBuilder(ctx *BuildContext) *Builder {
 	return b
 }
 
+// supportsGRPCTLS returns whether the Consul version has a dedicated GRPC TLS
+// port, which was added in v1.14.0.
+func supportsGRPCTLS(version string) bool {
+	return version == "local" || semver.Compare("v"+version, "v1.14.0") >= 0
+}
+
+// AuditLog enables audit logging to the given sinks. At least one sink must be
+// provided. Audit logging requires Consul Enterprise.
+func (b *Builder) AuditLog(sinks []AuditSink) *Builder {
+	if len(sinks) == 0 {
+		b.setErr(errors.New("audit logging requires at least one sink"))
+		return b
+	}
+
+	audit := auditConfig{
+		Enabled: true,
+		Sinks:   make(map[string]AuditSink, len(sinks)),
+	}
+	for _, sink := range sinks {
+		if sink.Name == "" {
+			b.setErr(errors.New("audit sink name is required"))
+			return b
+		}
+		audit.Sinks[sink.Name] = sink
+	}
+	b.setSection("audit", audit)
+	return b
+}
+
 func (b *Builder) Bootstrap(servers int) *Builder {
 	if servers < 1 {
 		b.conf.Bootstrap = nil
@@ -148,11 +250,63 @@ func (b *Builder) Client() *Builder {
 	return b
 }
 
+// DNSConfig sets the configuration of the agent's DNS interface.
+func (b *Builder) DNSConfig(cfg DNSConfig) *Builder {
+	b.setSection("dns_config", cfg)
+	return b
+}
+
 func (b *Builder) Datacenter(name string) *Builder {
 	b.conf.Datacenter = utils.StringToPointer(name)
 	return b
 }
 
+// EncryptKey enables gossip encryption with the given base64 encoded key and
+// requires the agent to verify the encryption of incoming and outgoing
+// gossip. The key must be 16, 24 or 32 bytes long. It cannot be combined with
+// the key injected by the build context.
+func (b *Builder) EncryptKey(key string) *Builder {
+	if err := validateSerfEncryptionKey(key); err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.conf.EncryptKey = utils.StringToPointer(key)
+	b.conf.EncryptVerifyIncoming = utils.BoolToPointer(true)
+	b.conf.EncryptVerifyOutgoing = utils.BoolToPointer(true)
+	b.providedEncryptKey = true
+	return b
+}
+
+// ExtraConfig deep merges the given JSON object over the generated agent
+// config. It allows setting config fields that the builder doesn't model.
+// Conflicting keys are resolved in favor of the extra config, and nested
+// objects are merged recursively. Multiple calls are merged in order.
+func (b *Builder) ExtraConfig(extra json.RawMessage) *Builder {
+	b.extraConfig = append(b.extraConfig, extra)
+	return b
+}
+
+// HTTPSTLS overrides the TLS settings of the HTTPS API. See TLS for the
+// meaning of the arguments.
+func (b *Builder) HTTPSTLS(caFile, certFile, keyFile string, verifyIncoming bool) *Builder {
+	b.conf.TLS.HTTPS = b.tlsProtocolConfig(caFile, certFile, keyFile, verifyIncoming)
+	return b
+}
+
+// Image sets the docker image used for the agent, overriding the target
+// image.
+func (b *Builder) Image(image string) *Builder {
+	b.image = image
+	return b
+}
+
+// InternalRPCTLS overrides the TLS settings of the server RPC protocol. See
+// TLS for the meaning of the arguments.
+func (b *Builder) InternalRPCTLS(caFile, certFile, keyFile string, verifyIncoming bool) *Builder {
+	b.conf.TLS.InternalRPC = b.tlsProtocolConfig(caFile, certFile, keyFile, verifyIncoming)
+	return b
+}
+
 func (b *Builder) Peering(enable bool) *Builder {
 	b.conf.Peering = agentconfig.Peering{
 		Enabled: utils.BoolToPointer(enable),
@@ -165,6 +319,18 @@ func (b *Builder) RetryJoin(names ...string) *Builder {
 	return b
 }
 
+// TLS enables TLS for all the agent's protocols with the provided CA, cert and
+// key files. The files are read from the host and mounted into the agent's
+// container; an empty path leaves the matching setting unset. Outgoing
+// connections are always verified, and verifyIncoming additionally requires
+// clients to present a certificate signed by the CA. The protocol specific
+// settings of InternalRPCTLS and HTTPSTLS take precedence over these defaults.
+func (b *Builder) TLS(caFile, certFile, keyFile string, verifyIncoming bool) *Builder {
+	b.conf.TLS.Defaults = b.tlsProtocolConfig(caFile, certFile, keyFile, verifyIncoming)
+	b.conf.TLS.Defaults.VerifyOutgoing = utils.BoolToPointer(true)
+	return b
+}
+
 func (b *Builder) Telemetry(statSite string) *Builder {
 	b.conf.Telemetry = agentconfig.Telemetry{
 		StatsiteAddr: utils.StringToPointer(statSite),
@@ -172,18 +338,53 @@ func (b *Builder) Telemetry(statSite string) *Builder {
 	return b
 }
 
+// Version sets the Consul version of the agent, overriding the version of
+// the build context. This allows a cluster to run agents with different
+// versions, e.g. during an upgrade. Options the version doesn't support are
+// left out of the agent configuration.
+func (b *Builder) Version(version string) *Builder {
+	b.version = version
+
+	b.conf.Ports.GRPCTLS = nil
+	if supportsGRPCTLS(version) {
+		b.conf.Ports.GRPCTLS = utils.IntToPointer(8503)
+	}
+	return b
+}
+
 // ToAgentConfig renders the builders configuration into a string
 // representation of the json config file for agents.
 // DANGER! Some fields may not have json tags in the Agent Config.
 // You may need to add these yourself.
 func (b *Builder) ToAgentConfig() (*Config, error) {
-	b.injectContextOptions()
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	if err := b.injectContextOptions(); err != nil {
+		return nil, err
+	}
 
 	out, err := json.MarshalIndent(b.conf, "", "  ")
 	if err != nil {
 		return nil, errors.Wrap(err, "could not marshall builder")
 	}
 
+	extras := b.extraConfig
+	if len(b.sections) > 0 {
+		sections, err := json.Marshal(b.sections)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshall config sections")
+		}
+		extras = append([]json.RawMessage{sections}, extras...)
+	}
+	if len(extras) > 0 {
+		out, err = mergeExtraConfig(out, extras)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	conf := &Config{
 		Certs:   b.certs,
 		Cmd:     []string{"agent"},
@@ -196,13 +397,59 @@ func (b *Builder) ToAgentConfig() (*Config, error) {
 	if b.context != nil && b.context.consulVersion != "" {
 		conf.Version = b.context.consulVersion
 	}
+	if b.version != "" {
+		conf.Version = b.version
+	}
+	if b.image != "" {
+		conf.Image = b.image
+	}
 
 	return conf, nil
 }
 
-func (b *Builder) injectContextOptions() {
+// tlsProtocolConfig mounts the given files and returns the TLS settings
+// referencing them.
+func (b *Builder) tlsProtocolConfig(caFile, certFile, keyFile string, verifyIncoming bool) agentconfig.TLSProtocolConfig {
+	b.providedTLS = true
+	return agentconfig.TLSProtocolConfig{
+		CAFile:         b.mountCertFile(caFile),
+		CertFile:       b.mountCertFile(certFile),
+		KeyFile:        b.mountCertFile(keyFile),
+		VerifyIncoming: utils.BoolToPointer(verifyIncoming),
+	}
+}
+
+// mountCertFile reads the file at path from the host and adds it to the certs
+// mounted into the agent's container. It returns the path of the file in the
+// container, or nil if path is empty.
+func (b *Builder) mountCertFile(path string) *string {
+	if path == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		b.setErr(errors.Wrapf(err, "could not read cert file %s", path))
+		return nil
+	}
+
+	// All certs are mounted into the same directory.
+	filename := filepath.Join(remoteCertDirectory, filepath.Base(path))
+	if existing, ok := b.certs[filename]; ok && existing != string(content) {
+		b.setErr(errors.Errorf("cert files with different contents share the name %s", filepath.Base(path)))
+		return nil
+	}
+	b.certs[filename] = string(content)
+	return utils.StringToPointer(filename)
+}
+
+func (b *Builder) injectContextOptions() error {
 	if b.context == nil {
-		return
+		return nil
+	}
+
+	if b.providedTLS && b.context.caCert != "" {
+		return errors.New("provided TLS certs cannot be combined with the certs injected by the build context")
 	}
 
 	var dc string
@@ -217,6 +464,9 @@ func (b *Builder) injectContextOptions() {
 	server := b.conf.ServerMode != nil && *b.conf.ServerMode
 
 	if b.context.encryptKey != "" {
+		if b.providedEncryptKey {
+			return errors.New("provided encrypt key cannot be combined with the key injected by the build context")
+		}
 		b.conf.EncryptKey = utils.StringToPointer(b.context.encryptKey)
 	}
 
@@ -280,4 +530,58 @@ func (b *Builder) injectContextOptions() {
 		panic("client certificate distribution not implemented")
 	}
 	b.context.index++
+	return nil
+}
+
+// setSection sets the top-level config section name, replacing any previous
+// value.
+func (b *Builder) setSection(name string, value interface{}) {
+	if b.sections == nil {
+		b.sections = make(map[string]interface{})
+	}
+	b.sections[name] = value
+}
+
+// setErr records the first error from a builder option.
+func (b *Builder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// mergeExtraConfig deep merges each of the extra JSON objects over the
+// rendered config.
+func mergeExtraConfig(rendered []byte, extras []json.RawMessage) ([]byte, error) {
+	var conf map[string]interface{}
+	if err := json.Unmarshal(rendered, &conf); err != nil {
+		return nil, errors.Wrap(err, "could not decode rendered config")
+	}
+
+	for _, extra := range extras {
+		var extraConf map[string]interface{}
+		if err := json.Unmarshal(extra, &extraConf); err != nil {
+			return nil, errors.Wrap(err, "extra config must be a JSON object")
+		}
+		deepMerge(conf, extraConf)
+	}
+
+	out, err := json.MarshalIndent(conf, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshall merged config")
+	}
+	return out, nil
+}
+
+// deepMerge merges src into dst. Nested objects are merged recursively, and
+// any other value in src replaces the one in dst.
+func deepMerge(dst, src map[string]interface{}) {
+	for k, srcVal := range src {
+		srcMap, srcIsMap := srcVal.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			deepMerge(dstMap, srcMap)
+			continue
+		}
+		dst[k] = srcVal
+	}
 }
@@ -0,0 +1,286 @@
+package agent
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_AuditLog(t *testing.T) {
+	t.Run("file sink", func(t *testing.T) {
+		conf, err := NewConfigBuilder(nil).
+			AuditLog([]AuditSink{
+				{
+					Name:              "file",
+					Type:              "file",
+					Format:            "json",
+					Path:              "/consul/data/audit/audit.json",
+					DeliveryGuarantee: "best-effort",
+					RotateDuration:    "24h",
+					RotateMaxFiles:    15,
+				},
+			}).
+			ToAgentConfig()
+		require.NoError(t, err)
+
+		var rendered map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(conf.JSON), &rendered))
+
+		expected := map[string]interface{}{
+			"enabled": true,
+			"sink": map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":               "file",
+					"format":             "json",
+					"path":               "/consul/data/audit/audit.json",
+					"delivery_guarantee": "best-effort",
+					"rotate_duration":    "24h",
+					"rotate_max_files":   float64(15),
+				},
+			},
+		}
+		require.Equal(t, expected, rendered["audit"])
+	})
+
+	t.Run("no sinks", func(t *testing.T) {
+		_, err := NewConfigBuilder(nil).AuditLog(nil).ToAgentConfig()
+		require.EqualError(t, err, "audit logging requires at least one sink")
+	})
+
+	t.Run("unnamed sink", func(t *testing.T) {
+		_, err := NewConfigBuilder(nil).AuditLog([]AuditSink{{Type: "file"}}).ToAgentConfig()
+		require.EqualError(t, err, "audit sink name is required")
+	})
+}
+
+func TestBuilder_DNSConfig(t *testing.T) {
+	t.Run("settings", func(t *testing.T) {
+		allowStale := false
+		conf, err := NewConfigBuilder(nil).
+			DNSConfig(DNSConfig{
+				AllowStale:     &allowStale,
+				NodeTTL:        "10s",
+				ServiceTTL:     map[string]string{"*": "5s"},
+				OnlyPassing:    true,
+				UDPAnswerLimit: 5,
+				SOA:            &DNSSOA{Refresh: 3600, MinTTL: 30},
+			}).
+			ToAgentConfig()
+		require.NoError(t, err)
+
+		var rendered map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(conf.JSON), &rendered))
+
+		expected := map[string]interface{}{
+			"allow_stale":      false,
+			"node_ttl":         "10s",
+			"service_ttl":      map[string]interface{}{"*": "5s"},
+			"only_passing":     true,
+			"udp_answer_limit": float64(5),
+			"soa": map[string]interface{}{
+				"refresh": float64(3600),
+				"min_ttl": float64(30),
+			},
+		}
+		require.Equal(t, expected, rendered["dns_config"])
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		conf, err := NewConfigBuilder(nil).ToAgentConfig()
+		require.NoError(t, err)
+
+		var rendered map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(conf.JSON), &rendered))
+		require.Empty(t, rendered["dns_config"])
+	})
+}
+
+func TestBuilder_EncryptKey(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	t.Run("valid key", func(t *testing.T) {
+		conf, err := NewConfigBuilder(nil).EncryptKey(key).ToAgentConfig()
+		require.NoError(t, err)
+
+		var rendered map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(conf.JSON), &rendered))
+		require.Equal(t, key, rendered["encrypt"])
+		require.Equal(t, true, rendered["encrypt_verify_incoming"])
+		require.Equal(t, true, rendered["encrypt_verify_outgoing"])
+	})
+
+	t.Run("invalid key length", func(t *testing.T) {
+		_, err := NewConfigBuilder(nil).
+			EncryptKey(base64.StdEncoding.EncodeToString(make([]byte, 20))).
+			ToAgentConfig()
+		require.EqualError(t, err, "encrypt key must be 16, 24 or 32 bytes, got 20")
+	})
+
+	t.Run("invalid base64", func(t *testing.T) {
+		_, err := NewConfigBuilder(nil).EncryptKey("not base64!").ToAgentConfig()
+		require.ErrorContains(t, err, "encrypt key is not valid base64")
+	})
+
+	t.Run("build context key", func(t *testing.T) {
+		ctx, err := NewBuildContext(BuildOptions{InjectGossipEncryption: true})
+		require.NoError(t, err)
+
+		_, err = NewConfigBuilder(ctx).EncryptKey(key).ToAgentConfig()
+		require.EqualError(t, err, "provided encrypt key cannot be combined with the key injected by the build context")
+	})
+}
+
+func TestBuilder_ExtraConfig(t *testing.T) {
+	render := func(t *testing.T, b *Builder) map[string]interface{} {
+		conf, err := b.ToAgentConfig()
+		require.NoError(t, err)
+
+		var rendered map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(conf.JSON), &rendered))
+		return rendered
+	}
+
+	t.Run("nested merge", func(t *testing.T) {
+		rendered := render(t, NewConfigBuilder(nil).
+			ExtraConfig(json.RawMessage(`{"ports": {"grpc": 9502, "grpc_tls": 9503}}`)))
+
+		ports := rendered["ports"].(map[string]interface{})
+		require.Equal(t, float64(9502), ports["grpc"])
+		require.Equal(t, float64(9503), ports["grpc_tls"])
+		// Keys that aren't in the extra config are preserved.
+		require.Equal(t, float64(8501), ports["https"])
+		require.Equal(t, float64(8300), ports["server"])
+	})
+
+	t.Run("top-level override", func(t *testing.T) {
+		rendered := render(t, NewConfigBuilder(nil).
+			ExtraConfig(json.RawMessage(`{"log_level": "TRACE", "experiments": ["resource-apis"]}`)))
+
+		require.Equal(t, "TRACE", rendered["log_level"])
+		require.Equal(t, []interface{}{"resource-apis"}, rendered["experiments"])
+		require.Equal(t, "0.0.0.0", rendered["bind_addr"])
+	})
+
+	t.Run("later extra config wins", func(t *testing.T) {
+		rendered := render(t, NewConfigBuilder(nil).
+			ExtraConfig(json.RawMessage(`{"log_level": "TRACE"}`)).
+			ExtraConfig(json.RawMessage(`{"log_level": "INFO"}`)))
+
+		require.Equal(t, "INFO", rendered["log_level"])
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		_, err := NewConfigBuilder(nil).ExtraConfig(json.RawMessage(`["log_level"]`)).ToAgentConfig()
+		require.ErrorContains(t, err, "extra config must be a JSON object")
+	})
+}
+
+func TestBuilder_Version(t *testing.T) {
+	ctx, err := NewBuildContext(BuildOptions{ConsulVersion: "1.14.3"})
+	require.NoError(t, err)
+
+	t.Run("context version", func(t *testing.T) {
+		conf, err := NewConfigBuilder(ctx).ToAgentConfig()
+		require.NoError(t, err)
+		require.Equal(t, "1.14.3", conf.Version)
+		require.Contains(t, conf.JSON, `"grpc_tls"`)
+	})
+
+	t.Run("per agent version and image", func(t *testing.T) {
+		conf, err := NewConfigBuilder(ctx).
+			Image("hashicorp/consul").
+			Version("1.13.4").
+			ToAgentConfig()
+		require.NoError(t, err)
+		require.Equal(t, "hashicorp/consul", conf.Image)
+		require.Equal(t, "1.13.4", conf.Version)
+		// v1.13 doesn't support a dedicated GRPC TLS port.
+		require.NotContains(t, conf.JSON, `"grpc_tls"`)
+	})
+}
+
+func TestBuilder_TLS(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+	caFile := writeFile("ca.pem", "ca")
+	certFile := writeFile("server.pem", "cert")
+	keyFile := writeFile("server-key.pem", "key")
+	httpsCertFile := writeFile("https.pem", "https cert")
+	httpsKeyFile := writeFile("https-key.pem", "https key")
+
+	t.Run("defaults and protocol overrides", func(t *testing.T) {
+		conf, err := NewConfigBuilder(nil).
+			TLS(caFile, certFile, keyFile, true).
+			InternalRPCTLS("", "", "", true).
+			HTTPSTLS("", httpsCertFile, httpsKeyFile, false).
+			ToAgentConfig()
+		require.NoError(t, err)
+
+		require.Equal(t, map[string]string{
+			"/consul/config/certs/ca.pem":         "ca",
+			"/consul/config/certs/server.pem":     "cert",
+			"/consul/config/certs/server-key.pem": "key",
+			"/consul/config/certs/https.pem":      "https cert",
+			"/consul/config/certs/https-key.pem":  "https key",
+		}, conf.Certs)
+
+		var rendered map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(conf.JSON), &rendered))
+
+		expected := map[string]interface{}{
+			"defaults": map[string]interface{}{
+				"ca_file":         "/consul/config/certs/ca.pem",
+				"cert_file":       "/consul/config/certs/server.pem",
+				"key_file":        "/consul/config/certs/server-key.pem",
+				"verify_incoming": true,
+				"verify_outgoing": true,
+			},
+			"internal_rpc": map[string]interface{}{
+				"verify_incoming": true,
+			},
+			"https": map[string]interface{}{
+				"cert_file":       "/consul/config/certs/https.pem",
+				"key_file":        "/consul/config/certs/https-key.pem",
+				"verify_incoming": false,
+			},
+			"grpc": map[string]interface{}{},
+		}
+		require.Equal(t, expected, rendered["tls"])
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := NewConfigBuilder(nil).
+			TLS(filepath.Join(dir, "missing.pem"), certFile, keyFile, false).
+			ToAgentConfig()
+		require.ErrorContains(t, err, "could not read cert file")
+	})
+
+	t.Run("conflicting file names", func(t *testing.T) {
+		otherCA := filepath.Join(t.TempDir(), "ca.pem")
+		require.NoError(t, os.WriteFile(otherCA, []byte("other ca"), 0600))
+
+		_, err := NewConfigBuilder(nil).
+			TLS(caFile, certFile, keyFile, false).
+			HTTPSTLS(otherCA, "", "", false).
+			ToAgentConfig()
+		require.EqualError(t, err, "cert files with different contents share the name ca.pem")
+	})
+
+	t.Run("build context certs", func(t *testing.T) {
+		ctx, err := NewBuildContext(BuildOptions{InjectAutoEncryption: true})
+		require.NoError(t, err)
+
+		_, err = NewConfigBuilder(ctx).
+			TLS(caFile, certFile, keyFile, false).
+			ToAgentConfig()
+		require.EqualError(t, err, "provided TLS certs cannot be combined with the certs injected by the build context")
+	})
+}
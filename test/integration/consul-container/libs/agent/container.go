@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -31,6 +32,8 @@ type consulContainerNode struct {
 	serverMode     bool
 	ip             string
 	port           int
+	dnsHost        string
+	dnsPort        int
 	datacenter     string
 	config         Config
 	podReq         testcontainers.ContainerRequest
@@ -120,6 +123,15 @@ func NewConsulContainer(ctx context.Context, config Config, network string, inde
 		return nil, err
 	}
 
+	dnsHost, err := podContainer.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mappedDNSPort, err := podContainer.MappedPort(ctx, dnsPort+"/udp")
+	if err != nil {
+		return nil, err
+	}
+
 	consulContainer, err := startContainer(ctx, consulReq)
 	if err != nil {
 		return nil, err
@@ -152,6 +164,8 @@ func NewConsulContainer(ctx context.Context, config Config, network string, inde
 		serverMode: pc.Server,
 		ip:         ip,
 		port:       mappedPort.Int(),
+		dnsHost:    dnsHost,
+		dnsPort:    mappedDNSPort.Int(),
 		datacenter: pc.Datacenter,
 		client:     apiClient,
 		ctx:        ctx,
@@ -173,6 +187,12 @@ func (c *consulContainerNode) GetName() string {
 	return name
 }
 
+// GetNodeName returns the Consul node name of the agent. Unlike GetName, it
+// is available after the agent has been terminated.
+func (c *consulContainerNode) GetNodeName() string {
+	return c.name
+}
+
 func (c *consulContainerNode) GetConfig() Config {
 	return c.config
 }
@@ -195,6 +215,12 @@ func (c *consulContainerNode) GetAddr() (string, int) {
 	return c.ip, c.port
 }
 
+// GetDNSAddr returns the host address and port mapped to the agent's DNS
+// interface over UDP.
+func (c *consulContainerNode) GetDNSAddr() (string, int) {
+	return c.dnsHost, c.dnsPort
+}
+
 func (c *consulContainerNode) RegisterTermination(f func() error) {
 	c.terminateFuncs = append(c.terminateFuncs, f)
 }
@@ -203,6 +229,14 @@ func (c *consulContainerNode) Exec(ctx context.Context, cmd []string) (int, erro
 	return c.container.Exec(ctx, cmd)
 }
 
+// Logs returns the stdout and stderr of the agent's container.
+func (c *consulContainerNode) Logs(ctx context.Context) (io.ReadCloser, error) {
+	if c.container == nil {
+		return nil, fmt.Errorf("agent %s has been terminated", c.name)
+	}
+	return c.container.Logs(ctx)
+}
+
 // Upgrade terminates a running container and create a new one using the provided config.
 // The upgraded node will
 //   - use the same node name and the data dir as the old version node
@@ -320,6 +354,9 @@ func startContainer(ctx context.Context, req testcontainers.ContainerRequest) (t
 
 const pauseImage = "k8s.gcr.io/pause:3.3"
 
+// dnsPort is the port of the agent's DNS interface, see NewConfigBuilder.
+const dnsPort = "8600"
+
 type containerOpts struct {
 	certDir           string
 	configFile        string
@@ -341,7 +378,7 @@ func newContainerRequest(config Config, opts containerOpts) (podRequest, consulR
 		AutoRemove:   false,
 		Name:         opts.name + "-pod",
 		SkipReaper:   skipReaper,
-		ExposedPorts: []string{httpPort + "/tcp"},
+		ExposedPorts: []string{httpPort + "/tcp", dnsPort + "/udp"},
 		Hostname:     opts.hostname,
 		Networks:     opts.addtionalNetworks,
 	}
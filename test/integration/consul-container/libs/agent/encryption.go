@@ -25,6 +25,21 @@ func newSerfEncryptionKey() (string, error) {
 	return base64.StdEncoding.EncodeToString(key), nil
 }
 
+// validateSerfEncryptionKey returns an error unless key is the base64 encoding
+// of a 16, 24 or 32 byte key, the sizes supported by memberlist.
+func validateSerfEncryptionKey(key string) error {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return errors.Wrap(err, "encrypt key is not valid base64")
+	}
+	switch len(raw) {
+	case 16, 24, 32:
+		return nil
+	default:
+		return fmt.Errorf("encrypt key must be 16, 24 or 32 bytes, got %d", len(raw))
+	}
+}
+
 func newServerTLSKeyPair(dc string, ctx *BuildContext) (string, string, string, string) {
 	// Generate agent-specific key pair. Borrowed from 'consul tls cert create -server -dc <dc_name>'
 	name := fmt.Sprintf("server.%s.%s", dc, "consul")
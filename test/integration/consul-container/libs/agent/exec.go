@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// ExecConsul runs the consul CLI with the given arguments in the agent's
+// container and returns what it wrote to stdout and stderr. An error is
+// returned, along with the output, if the command exits with a non-zero code.
+func (c *consulContainerNode) ExecConsul(args ...string) (string, string, error) {
+	if c.container == nil {
+		return "", "", fmt.Errorf("agent %s has been terminated", c.name)
+	}
+	return execOutput(c.ctx, c.container.GetContainerID(), append([]string{"consul"}, args...))
+}
+
+// execOutput runs cmd in the container with the given ID and returns its
+// output. Unlike testcontainers' Exec, it attaches to the command to collect
+// the output.
+func execOutput(ctx context.Context, containerID string, cmd []string) (string, string, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return "", "", fmt.Errorf("could not create docker client: %w", err)
+	}
+	defer cli.Close()
+
+	exec, err := cli.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("could not create exec for %q: %w", strings.Join(cmd, " "), err)
+	}
+
+	resp, err := cli.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
+	if err != nil {
+		return "", "", fmt.Errorf("could not attach to %q: %w", strings.Join(cmd, " "), err)
+	}
+	defer resp.Close()
+
+	stdout, stderr, err := demuxExecOutput(resp.Reader)
+	if err != nil {
+		return stdout, stderr, fmt.Errorf("could not read the output of %q: %w", strings.Join(cmd, " "), err)
+	}
+
+	inspect, err := cli.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return stdout, stderr, fmt.Errorf("could not inspect %q: %w", strings.Join(cmd, " "), err)
+	}
+	if inspect.ExitCode != 0 {
+		return stdout, stderr, fmt.Errorf("%q exited with code %d: %s", strings.Join(cmd, " "), inspect.ExitCode, strings.TrimSpace(stderr))
+	}
+	return stdout, stderr, nil
+}
+
+// demuxExecOutput splits the multiplexed stream of an exec attached to both
+// stdout and stderr.
+func demuxExecOutput(r io.Reader) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, r); err != nil {
+		return stdout.String(), stderr.String(), err
+	}
+	return stdout.String(), stderr.String(), nil
+}
@@ -0,0 +1,27 @@
+package agent
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDemuxExecOutput(t *testing.T) {
+	var stream bytes.Buffer
+	stdoutWriter := stdcopy.NewStdWriter(&stream, stdcopy.Stdout)
+	stderrWriter := stdcopy.NewStdWriter(&stream, stdcopy.Stderr)
+
+	_, err := stdoutWriter.Write([]byte("Node  Address\n"))
+	require.NoError(t, err)
+	_, err = stderrWriter.Write([]byte("warning\n"))
+	require.NoError(t, err)
+	_, err = stdoutWriter.Write([]byte("agent-0  10.0.0.2:8301\n"))
+	require.NoError(t, err)
+
+	stdout, stderr, err := demuxExecOutput(&stream)
+	require.NoError(t, err)
+	require.Equal(t, "Node  Address\nagent-0  10.0.0.2:8301\n", stdout)
+	require.Equal(t, "warning\n", stderr)
+}
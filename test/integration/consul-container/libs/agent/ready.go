@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// ReadyOptions configures the checks performed by WaitReady.
+type ReadyOptions struct {
+	// RequireLeader additionally requires the agent to know the cluster
+	// leader.
+	RequireLeader bool
+
+	// RequireVoter additionally requires the agent to be a server that is a
+	// voter in the Raft configuration. It implies RequireLeader.
+	RequireVoter bool
+}
+
+// WaitReady blocks until the agent's HTTP API responds, its serf health check
+// is passing, and the additional requirements of opts are met. It returns the
+// last failed check once ctx is done.
+func (c *consulContainerNode) WaitReady(ctx context.Context, opts ReadyOptions) error {
+	return waitReady(ctx, c.client, opts)
+}
+
+func waitReady(ctx context.Context, client *api.Client, opts ReadyOptions) error {
+	waiter := &utils.Waiter{
+		MinFailures: 10,
+		MinWait:     100 * time.Millisecond,
+		MaxWait:     2 * time.Second,
+	}
+
+	for {
+		err := checkReady(client, opts)
+		if err == nil {
+			return nil
+		}
+		if waitErr := waiter.Wait(ctx); waitErr != nil {
+			return fmt.Errorf("agent is not ready: %w", err)
+		}
+	}
+}
+
+func checkReady(client *api.Client, opts ReadyOptions) error {
+	self, err := client.Agent().Self()
+	if err != nil {
+		return fmt.Errorf("agent API is not available: %w", err)
+	}
+	nodeName, _ := self["Config"]["NodeName"].(string)
+
+	checks, _, err := client.Health().Node(nodeName, nil)
+	if err != nil {
+		return fmt.Errorf("could not read the health checks of node %s: %w", nodeName, err)
+	}
+	serfPassing := false
+	for _, check := range checks {
+		if check.CheckID == "serfHealth" && check.Status == api.HealthPassing {
+			serfPassing = true
+		}
+	}
+	if !serfPassing {
+		return fmt.Errorf("serf health check of node %s is not passing", nodeName)
+	}
+
+	if !opts.RequireLeader && !opts.RequireVoter {
+		return nil
+	}
+
+	leader, err := client.Status().Leader()
+	if err != nil {
+		return fmt.Errorf("could not query leader: %w", err)
+	}
+	if leader == "" {
+		return fmt.Errorf("no leader available")
+	}
+
+	if !opts.RequireVoter {
+		return nil
+	}
+
+	raftConfig, err := client.Operator().RaftGetConfiguration(nil)
+	if err != nil {
+		return fmt.Errorf("could not read the raft configuration: %w", err)
+	}
+	for _, server := range raftConfig.Servers {
+		if server.Node == nodeName {
+			if !server.Voter {
+				return fmt.Errorf("server %s is not a voter", nodeName)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("agent %s is not a server in the raft configuration", nodeName)
+}
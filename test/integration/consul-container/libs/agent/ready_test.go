@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// newTestAgentAPI returns a client for a fake agent API whose /v1/agent/self
+// endpoint fails the first unavailable requests.
+func newTestAgentAPI(t *testing.T, unavailable int32, voter bool) (*api.Client, *int32) {
+	var selfRequests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/agent/self":
+			if atomic.AddInt32(&selfRequests, 1) <= unavailable {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, `{"Config": {"NodeName": "node1"}}`)
+		case "/v1/health/node/node1":
+			fmt.Fprint(w, `[{"Node": "node1", "CheckID": "serfHealth", "Status": "passing"}]`)
+		case "/v1/status/leader":
+			fmt.Fprint(w, `"10.0.0.1:8300"`)
+		case "/v1/operator/raft/configuration":
+			fmt.Fprintf(w, `{"Servers": [{"Node": "node1", "Voter": %t}]}`, voter)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := api.DefaultConfig()
+	cfg.Address = srv.URL
+	client, err := api.NewClient(cfg)
+	require.NoError(t, err)
+	return client, &selfRequests
+}
+
+func TestWaitReady(t *testing.T) {
+	client, selfRequests := newTestAgentAPI(t, 3, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	require.NoError(t, waitReady(ctx, client, ReadyOptions{RequireVoter: true}))
+	require.Equal(t, int32(4), atomic.LoadInt32(selfRequests))
+}
+
+func TestWaitReady_NotVoter(t *testing.T) {
+	client, _ := newTestAgentAPI(t, 0, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, waitReady(ctx, client, ReadyOptions{RequireLeader: true}))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	err := waitReady(ctx, client, ReadyOptions{RequireVoter: true})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "server node1 is not a voter")
+}
@@ -2,6 +2,7 @@ package assert
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -26,8 +27,81 @@ func PeeringStatus(t *testing.T, client *api.Client, peerName string, status api
 	})
 }
 
+// WaitForPeeringState blocks until the peering connection reaches the wanted
+// state or the timeout elapses. Every state observed along the way is logged so
+// that the transitions a peering goes through are visible in the test output.
+func WaitForPeeringState(t *testing.T, client *api.Client, peerName string, wantState api.PeeringState, timeout time.Duration) {
+	t.Helper()
+
+	failer := func() *retry.Timer {
+		return &retry.Timer{Timeout: timeout, Wait: defaultWait}
+	}
+
+	lastState := api.PeeringStateUndefined
+	retry.RunWith(failer(), t, func(r *retry.R) {
+		peering, _, err := client.Peerings().Read(context.Background(), peerName, &api.QueryOptions{})
+		if err != nil {
+			r.Fatal("error reading peering data: ", err)
+		}
+		if peering == nil {
+			r.Fatal("peering ", peerName, " not found")
+		}
+		if peering.State != lastState {
+			t.Logf("peering %q transitioned from %s to %s", peerName, lastState, peering.State)
+			lastState = peering.State
+		}
+		if wantState != peering.State {
+			r.Fatal("peering state did not match: got ", peering.State, " want ", wantState)
+		}
+	})
+}
+
 // PeeringExports verifies the correct number of exported services with a default retry.
 func PeeringExports(t *testing.T, client *api.Client, peerName string, exports int) {
+	PeeringStreamStatus(t, client, peerName, func(status api.PeeringStreamStatus) error {
+		if exports != len(status.ExportedServices) {
+			return fmt.Errorf("peering exported services did not match: got %d want %d", len(status.ExportedServices), exports)
+		}
+		return nil
+	})
+}
+
+// PeeringImports verifies the correct number of imported services with a default retry.
+func PeeringImports(t *testing.T, client *api.Client, peerName string, imports int) {
+	PeeringStreamStatus(t, client, peerName, func(status api.PeeringStreamStatus) error {
+		if imports != len(status.ImportedServices) {
+			return fmt.Errorf("peering imported services did not match: got %d want %d", len(status.ImportedServices), imports)
+		}
+		return nil
+	})
+}
+
+// PeeredServiceImported verifies that n healthy instances of the service are
+// imported from the given peer.
+func PeeredServiceImported(t *testing.T, client *api.Client, svc string, peerName string, n int) {
+	failer := func() *retry.Timer {
+		return &retry.Timer{Timeout: defaultTimeout, Wait: defaultWait}
+	}
+
+	retry.RunWith(failer(), t, func(r *retry.R) {
+		entries, _, err := client.Health().PeeredService(svc, peerName, true, nil)
+		if err != nil {
+			r.Fatal("error reading imported service: ", err)
+		}
+		if len(entries) != n {
+			r.Fatalf("expected %d instances of %s imported from %s, got %d", n, svc, peerName, len(entries))
+		}
+		for _, entry := range entries {
+			if entry.Service.PeerName != peerName {
+				r.Fatalf("instance %s was not imported from %s", entry.Service.ID, peerName)
+			}
+		}
+	})
+}
+
+// PeeringStreamStatus retries until check accepts the stream status of the
+// peering, with a default retry.
+func PeeringStreamStatus(t *testing.T, client *api.Client, peerName string, check func(api.PeeringStreamStatus) error) {
 	failer := func() *retry.Timer {
 		return &retry.Timer{Timeout: defaultTimeout, Wait: defaultWait}
 	}
@@ -37,8 +111,11 @@ func PeeringExports(t *testing.T, client *api.Client, peerName string, exports i
 		if err != nil {
 			r.Fatal("error reading peering data")
 		}
-		if exports != len(peering.StreamStatus.ExportedServices) {
-			r.Fatal("peering exported services did not match: got ", len(peering.StreamStatus.ExportedServices), " want ", exports)
+		if peering == nil {
+			r.Fatal("peering ", peerName, " not found")
+		}
+		if err := check(peering.StreamStatus); err != nil {
+			r.Fatal(err)
 		}
 	})
 }
@@ -1,15 +1,25 @@
 package assert
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
 )
 
 const (
@@ -20,34 +30,329 @@ const (
 // HTTPServiceEchoes verifies that a post to the given ip/port combination returns the data
 // in the response body
 func HTTPServiceEchoes(t *testing.T, ip string, port int) {
+	t.Helper()
+	HTTPServiceEchoesCtx(context.Background(), t, ip, port)
+}
+
+// HTTPServiceEchoesCtx is like HTTPServiceEchoes, but stops retrying as soon
+// as ctx is done, failing the test with the last HTTP error.
+func HTTPServiceEchoesCtx(ctx context.Context, t *testing.T, ip string, port int) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultHTTPTimeout)
+	defer cancel()
+
+	url := fmt.Sprintf("http://%s:%d", ip, port)
+	if err := httpServiceEchoes(ctx, t, url); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// httpServiceEchoes posts to url until the response body echoes the posted
+// data or ctx is done, in which case it returns the last error.
+func httpServiceEchoes(ctx context.Context, t *testing.T, url string) error {
 	phrase := "hello"
+
+	for {
+		t.Logf("making call to %s", url)
+		err := httpPostEchoes(ctx, url, phrase)
+		if err == nil {
+			return nil
+		}
+
+		timer := time.NewTimer(defaultHTTPWait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("service %s did not echo before %v: %w", url, ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+func httpPostEchoes(ctx context.Context, url, phrase string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(phrase))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not make call to service: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("could not read response body: %w", err)
+	}
+
+	if !strings.Contains(string(body), phrase) {
+		return fmt.Errorf("received an incorrect response %q", body)
+	}
+	return nil
+}
+
+// HTTPServiceResponseContains verifies that a GET to the given ip/port
+// combination returns a response body containing substr. It retries until the
+// body matches, so it can tell which backend ends up serving the requests, for
+// example after a traffic shift between service versions.
+func HTTPServiceResponseContains(t *testing.T, ip string, port int, substr string) {
+	t.Helper()
+	HTTPServiceResponseContainsPath(t, ip, port, "/", substr)
+}
+
+// HTTPServiceResponseContainsPath is like HTTPServiceResponseContains, but
+// requests the given path, which may include a query string.
+func HTTPServiceResponseContainsPath(t *testing.T, ip string, port int, path, substr string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultHTTPTimeout)
+	defer cancel()
+
+	url := fmt.Sprintf("http://%s:%d%s", ip, port, path)
+	for {
+		t.Logf("making call to %s", url)
+		err := httpGetContains(ctx, url, substr)
+		if err == nil {
+			return
+		}
+
+		timer := time.NewTimer(defaultHTTPWait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			t.Fatalf("response of %s did not contain %q before %v: %v", url, substr, ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+func httpGetContains(ctx context.Context, url, substr string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not make call to service: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("could not read response body: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("received status code %d", res.StatusCode)
+	}
+	if !strings.Contains(string(body), substr) {
+		return fmt.Errorf("received an incorrect response %q", body)
+	}
+	return nil
+}
+
+// TCPServiceEchoes verifies that the bytes written to a TCP connection to the
+// given ip/port combination are echoed back. The connection is established
+// again on failures such as resets while the mesh converges.
+func TCPServiceEchoes(t *testing.T, ip string, port int) {
 	failer := func() *retry.Timer {
 		return &retry.Timer{Timeout: defaultHTTPTimeout, Wait: defaultHTTPWait}
 	}
 
-	client := http.DefaultClient
-	url := fmt.Sprintf("http://%s:%d", ip, port)
+	addr := net.JoinHostPort(ip, strconv.Itoa(port))
 
 	retry.RunWith(failer(), t, func(r *retry.R) {
-		t.Logf("making call to %s", url)
-		reader := strings.NewReader(phrase)
-		res, err := client.Post(url, "text/plain", reader)
+		t.Logf("making TCP connection to %s", addr)
+
+		conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+		if err != nil {
+			r.Fatal("could not connect to service ", addr, ": ", err)
+		}
+		defer conn.Close()
+
+		if err := conn.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			r.Fatal("could not set connection deadline: ", err)
+		}
+
+		nonce := []byte(fmt.Sprintf("hello-%d", time.Now().UnixNano()))
+		if _, err := conn.Write(nonce); err != nil {
+			r.Fatal("could not write to service ", addr, ": ", err)
+		}
+
+		// The echo may arrive in several segments, so read until the
+		// whole nonce has been received.
+		echo := make([]byte, len(nonce))
+		if _, err := io.ReadFull(conn, echo); err != nil {
+			r.Fatal("could not read echo from service ", addr, ": ", err)
+		}
+
+		if !bytes.Equal(nonce, echo) {
+			r.Fatalf("received an incorrect echo %q, want %q", echo, nonce)
+		}
+	})
+}
+
+// GRPCServiceEchoes verifies that a gRPC health check made to the given ip/port
+// combination reports the service as serving.
+func GRPCServiceEchoes(t *testing.T, ip string, port int) {
+	failer := func() *retry.Timer {
+		return &retry.Timer{Timeout: defaultHTTPTimeout, Wait: defaultHTTPWait}
+	}
+
+	addr := fmt.Sprintf("%s:%d", ip, port)
+
+	retry.RunWith(failer(), t, func(r *retry.R) {
+		t.Logf("making gRPC health check to %s", addr)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			r.Fatal("could not dial service ", addr, ": ", err)
+		}
+		defer conn.Close()
+
+		res, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
 		if err != nil {
-			r.Fatal("could not make call to service ", url)
+			r.Fatal("could not make health check to service ", addr, ": ", err)
 		}
-		defer res.Body.Close()
 
-		body, err := io.ReadAll(res.Body)
+		if res.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			r.Fatal("received an incorrect health status ", res.Status)
+		}
+	})
+}
+
+// EnvoyStatGreaterThan verifies that the sum of the envoy stats matching the
+// metric regular expression is greater than n, using the stats endpoint on the
+// given envoy admin port.
+func EnvoyStatGreaterThan(t *testing.T, port int, metric string, n int) {
+	failer := func() *retry.Timer {
+		return &retry.Timer{Timeout: defaultHTTPTimeout, Wait: defaultHTTPWait}
+	}
+
+	retry.RunWith(failer(), t, func(r *retry.R) {
+		stats, err := libservice.GetEnvoyStats(port, metric)
 		if err != nil {
-			r.Fatal("could not read response body ", url)
+			r.Fatal("could not get envoy stats: ", err)
+		}
+		if len(stats) == 0 {
+			r.Fatal("no envoy stats matched ", metric)
 		}
 
-		if !strings.Contains(string(body), phrase) {
-			r.Fatal("received an incorrect response ", body)
+		var total int
+		for _, v := range stats {
+			total += v
+		}
+		if total <= n {
+			r.Fatal("envoy stat ", metric, " is ", total, ", want greater than ", n)
 		}
 	})
 }
 
+// EnvoyEndpointHealthy verifies that envoy considers at least one endpoint of
+// the cluster healthy, using the /clusters endpoint on the given envoy admin
+// port. The cluster name must either match exactly or be the leading
+// dot-separated part of the envoy cluster name, so "static-server" matches
+// "static-server.default.dc1.internal.<trust domain>.consul".
+func EnvoyEndpointHealthy(t *testing.T, adminPort int, clusterName string) {
+	failer := func() *retry.Timer {
+		return &retry.Timer{Timeout: defaultHTTPTimeout, Wait: defaultHTTPWait}
+	}
+
+	retry.RunWith(failer(), t, func(r *retry.R) {
+		clusters, err := utils.GetEnvoyClusters(adminPort)
+		if err != nil {
+			r.Fatal("could not get envoy clusters: ", err)
+		}
+
+		healthy, found := healthyEnvoyEndpoints(clusters, clusterName)
+		if !found {
+			r.Fatal("envoy cluster ", clusterName, " not found")
+		}
+		if healthy == 0 {
+			r.Fatal("envoy cluster ", clusterName, " has no healthy endpoints")
+		}
+	})
+}
+
+// healthyEnvoyEndpoints parses the text output of the envoy /clusters admin
+// endpoint, where each line has the form
+// "<cluster>::<host>::<key>::<value>", and counts the endpoints of the cluster
+// whose health_flags are healthy. It also reports whether the cluster was
+// found at all.
+func healthyEnvoyEndpoints(clusters, clusterName string) (int, bool) {
+	var (
+		healthy int
+		found   bool
+	)
+	for _, line := range strings.Split(clusters, "\n") {
+		parts := strings.Split(line, "::")
+		if len(parts) < 2 {
+			continue
+		}
+
+		name := parts[0]
+		if name != clusterName && !strings.HasPrefix(name, clusterName+".") {
+			continue
+		}
+		found = true
+
+		if len(parts) == 4 && parts[2] == "health_flags" && parts[3] == "healthy" {
+			healthy++
+		}
+	}
+	return healthy, found
+}
+
+// EnvoyUpstreamEndpointCount verifies that envoy has exactly n endpoints for
+// the clusters whose name contains clusterNameSubstr, using the config dump
+// on the given envoy admin port. It retries until the endpoints converge.
+func EnvoyUpstreamEndpointCount(t *testing.T, adminPort int, clusterNameSubstr string, n int) {
+	t.Helper()
+
+	utils.RetryEnvoyConfigDump(t, adminPort, envoyUpstreamEndpointsFilter(clusterNameSubstr), func(results []string) bool {
+		return len(results) == n
+	}, defaultHTTPTimeout)
+}
+
+// envoyUpstreamEndpointsFilter returns a jq filter selecting the address of
+// every endpoint of the clusters whose name contains clusterNameSubstr.
+func envoyUpstreamEndpointsFilter(clusterNameSubstr string) string {
+	return fmt.Sprintf(`.configs[] | select(.["@type"] == "type.googleapis.com/envoy.admin.v3.EndpointsConfigDump") | .dynamic_endpoint_configs[]? | select(.endpoint_config.cluster_name | contains(%q)) | .endpoint_config.endpoints[]?.lb_endpoints[]? | .endpoint.address.socket_address | "\(.address):\(.port_value)"`, clusterNameSubstr)
+}
+
+// EnvoyClusterSNI verifies that the clusters whose name contains
+// clusterNameSubstr dial their upstream over TLS with the SNI wantSNI, using
+// the config dump on the given envoy admin port. It retries until the clusters
+// converge.
+func EnvoyClusterSNI(t *testing.T, adminPort int, clusterNameSubstr, wantSNI string) {
+	t.Helper()
+
+	utils.RetryEnvoyConfigDump(t, adminPort, envoyClusterSNIFilter(clusterNameSubstr), func(results []string) bool {
+		if len(results) == 0 {
+			return false
+		}
+		for _, sni := range results {
+			if sni != wantSNI {
+				return false
+			}
+		}
+		return true
+	}, defaultHTTPTimeout)
+}
+
+// envoyClusterSNIFilter returns a jq filter selecting the SNI of the TLS
+// transport socket of every cluster whose name contains clusterNameSubstr.
+func envoyClusterSNIFilter(clusterNameSubstr string) string {
+	return fmt.Sprintf(`.configs[] | select(.["@type"] == "type.googleapis.com/envoy.admin.v3.ClustersConfigDump") | .dynamic_active_clusters[]? | select(.cluster.name | contains(%q)) | .cluster.transport_socket.typed_config.sni`, clusterNameSubstr)
+}
+
 // CatalogServiceExists verifies the service name exists in the Consul catalog
 func CatalogServiceExists(t *testing.T, c *api.Client, svc string) {
 	retry.Run(t, func(r *retry.R) {
@@ -0,0 +1,243 @@
+package assert
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+const testEnvoyClusters = `local_app::observability_name::local_app
+local_app::default_priority::max_connections::1024
+local_app::127.0.0.1:8080::health_flags::healthy
+static-server.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul::observability_name::static-server.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul
+static-server.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul::10.0.0.2:20000::cx_active::1
+static-server.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul::10.0.0.2:20000::health_flags::healthy
+static-server.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul::10.0.0.3:20000::health_flags::/failed_eds_health
+static-server-v2.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul::10.0.0.4:20000::health_flags::/failed_eds_health
+`
+
+func TestEnvoyEndpointHealthy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/clusters", r.URL.Path)
+		fmt.Fprint(w, testEnvoyClusters)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	EnvoyEndpointHealthy(t, port, "static-server")
+}
+
+const testEnvoyEndpointsConfigDump = `{
+  "configs": [
+    {
+      "@type": "type.googleapis.com/envoy.admin.v3.EndpointsConfigDump",
+      "dynamic_endpoint_configs": [
+        {
+          "endpoint_config": {
+            "cluster_name": "static-server.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul",
+            "endpoints": [
+              {
+                "lb_endpoints": [
+                  {"endpoint": {"address": {"socket_address": {"address": "10.0.0.2", "port_value": 20000}}}}
+                ]
+              }
+            ]
+          }
+        },
+        {
+          "endpoint_config": {
+            "cluster_name": "static-server-v2.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul",
+            "endpoints": [
+              {
+                "lb_endpoints": [
+                  {"endpoint": {"address": {"socket_address": {"address": "10.0.0.3", "port_value": 20000}}}},
+                  {"endpoint": {"address": {"socket_address": {"address": "10.0.0.4", "port_value": 20000}}}}
+                ]
+              }
+            ]
+          }
+        }
+      ]
+    }
+  ]
+}`
+
+func TestEnvoyUpstreamEndpointCount(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/config_dump", r.URL.Path)
+		fmt.Fprint(w, testEnvoyEndpointsConfigDump)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	EnvoyUpstreamEndpointCount(t, port, "static-server.default", 1)
+	EnvoyUpstreamEndpointCount(t, port, "static-server-v2.default", 2)
+}
+
+const testEnvoyClustersConfigDump = `{
+  "configs": [
+    {
+      "@type": "type.googleapis.com/envoy.admin.v3.ClustersConfigDump",
+      "dynamic_active_clusters": [
+        {
+          "cluster": {
+            "name": "static-server.default.dialing-to-acceptor.external.11111111-2222-3333-4444-555555555555.consul",
+            "transport_socket": {
+              "name": "tls",
+              "typed_config": {
+                "@type": "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext",
+                "sni": "static-server.default.default.dialing-to-acceptor.external.11111111-2222-3333-4444-555555555555.consul"
+              }
+            }
+          }
+        },
+        {
+          "cluster": {
+            "name": "static-server-v2.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul",
+            "transport_socket": {
+              "name": "tls",
+              "typed_config": {
+                "@type": "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext",
+                "sni": "static-server-v2.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"
+              }
+            }
+          }
+        }
+      ]
+    }
+  ]
+}`
+
+func TestEnvoyClusterSNI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/config_dump", r.URL.Path)
+		fmt.Fprint(w, testEnvoyClustersConfigDump)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	EnvoyClusterSNI(t, port, "static-server.default.dialing-to-acceptor",
+		"static-server.default.default.dialing-to-acceptor.external.11111111-2222-3333-4444-555555555555.consul")
+	EnvoyClusterSNI(t, port, "static-server-v2.default",
+		"static-server-v2.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul")
+
+	results, err := utils.JQFilter(testEnvoyClustersConfigDump, envoyClusterSNIFilter("static-server"))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+}
+
+func TestHealthyEnvoyEndpoints(t *testing.T) {
+	cases := map[string]struct {
+		cluster string
+		healthy int
+		found   bool
+	}{
+		"prefix": {
+			cluster: "static-server",
+			healthy: 1,
+			found:   true,
+		},
+		"exact name": {
+			cluster: "static-server.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul",
+			healthy: 1,
+			found:   true,
+		},
+		"no healthy endpoints": {
+			cluster: "static-server-v2",
+			healthy: 0,
+			found:   true,
+		},
+		"nonexistent cluster": {
+			cluster: "does-not-exist",
+			healthy: 0,
+			found:   false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			healthy, found := healthyEnvoyEndpoints(testEnvoyClusters, tc.cluster)
+			require.Equal(t, tc.healthy, healthy)
+			require.Equal(t, tc.found, found)
+		})
+	}
+}
+
+func TestHTTPServiceEchoes_ContextCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "not an echo")
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := httpServiceEchoes(ctx, t, srv.URL)
+	require.Less(t, time.Since(start), defaultHTTPWait+time.Second)
+
+	require.ErrorContains(t, err, `received an incorrect response "not an echo"`)
+	require.ErrorContains(t, err, context.Canceled.Error())
+}
+
+func TestHTTPServiceEchoes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	HTTPServiceEchoesCtx(context.Background(), t, u.Hostname(), port)
+}
+
+func TestHTTPServiceResponseContains(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, "/debug", r.URL.Path)
+		require.Equal(t, "dump", r.URL.Query().Get("env"))
+
+		// The first request is served by the old version.
+		calls++
+		if calls == 1 {
+			fmt.Fprint(w, "FORTIO_NAME=static-server-v1\n")
+			return
+		}
+		fmt.Fprint(w, "FORTIO_NAME=static-server-v2\n")
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	HTTPServiceResponseContainsPath(t, u.Hostname(), port, "/debug?env=dump", "FORTIO_NAME=static-server-v2")
+	require.Equal(t, 2, calls)
+}
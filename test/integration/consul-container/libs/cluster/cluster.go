@@ -1,20 +1,27 @@
 package cluster
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/serf/serf"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"github.com/teris-io/shortid"
 	"github.com/testcontainers/testcontainers-go"
 
+	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
@@ -32,8 +39,21 @@ type Cluster struct {
 	Index       int
 	Network     testcontainers.Network
 	NetworkName string
+
+	// LeaderLossTolerance is the longest the cluster may go without a leader
+	// during a RollingRestart. DefaultLeaderLossTolerance is used when unset.
+	LeaderLossTolerance time.Duration
+
+	// partitionRules are the rules installed by PartitionAgents.
+	partitionRules []partitionRule
 }
 
+// ErrNoLeader is returned when the cluster has not elected a leader.
+var ErrNoLeader = errors.New("no leader available")
+
+// DefaultLeaderLossTolerance is the default for Cluster.LeaderLossTolerance.
+const DefaultLeaderLossTolerance = 30 * time.Second
+
 // New creates a Consul cluster. An agent will be started for each of the given
 // configs and joined to the cluster.
 //
@@ -62,7 +82,68 @@ func New(configs []libagent.Config) (*Cluster, error) {
 	return &cluster, nil
 }
 
-// Add starts an agent with the given configuration and joins it with the existing cluster
+// NewOnNetwork creates a Consul cluster like New, but attaches the agents to
+// the existing docker network with the given name instead of creating a new
+// one. Clusters sharing a network can reach each other's agents by container
+// name. The network is owned by the caller and must outlive the cluster.
+func NewOnNetwork(configs []libagent.Config, networkName string) (*Cluster, error) {
+	if networkName == "" {
+		return nil, errors.New("network name is required")
+	}
+
+	id, err := shortid.Generate()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not cluster id")
+	}
+
+	cluster := Cluster{
+		ID:          id,
+		NetworkName: networkName,
+	}
+
+	if err := cluster.Add(configs); err != nil {
+		return nil, errors.Wrap(err, "could not start or join all agents")
+	}
+	return &cluster, nil
+}
+
+// ClusterConfig holds options that are applied to a cluster once all of its
+// agents have joined.
+type ClusterConfig struct {
+	// Partitions are the admin partitions to create once the cluster has
+	// elected a leader. Admin partitions require Consul Enterprise.
+	Partitions []string
+}
+
+// NewWithConfig creates a Consul cluster like New and then applies the given
+// cluster configuration.
+func NewWithConfig(configs []libagent.Config, clusterConfig ClusterConfig) (*Cluster, error) {
+	cluster, err := New(configs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(clusterConfig.Partitions) > 0 {
+		err := waitFor("a leader", func() error {
+			_, err := cluster.LeaderAddress()
+			return err
+		})
+		if err != nil {
+			return cluster, err
+		}
+	}
+
+	for _, name := range clusterConfig.Partitions {
+		if err := cluster.CreatePartition(name); err != nil {
+			return cluster, err
+		}
+	}
+	return cluster, nil
+}
+
+// Add starts an agent with the given configuration and joins it with the existing cluster.
+// Each agent runs the image and version of its own configuration, so a cluster
+// may mix Consul versions.
 func (c *Cluster) Add(configs []libagent.Config) error {
 
 	agents := make([]libagent.Agent, len(configs))
@@ -100,13 +181,43 @@ func (c *Cluster) Join(agents []libagent.Agent) error {
 }
 
 // Remove instructs the agent to leave the cluster then removes it
-// from the cluster Agent list.
+// from the cluster Agent list. It is equivalent to Leave.
 func (c *Cluster) Remove(n libagent.Agent) error {
+	return c.Leave(n)
+}
+
+// Leave instructs the agent to gracefully leave the cluster then removes it
+// from the cluster Agent list. The agent must still be running.
+func (c *Cluster) Leave(n libagent.Agent) error {
 	err := n.GetClient().Agent().Leave()
 	if err != nil {
 		return errors.Wrapf(err, "could not remove agent %s", n.GetName())
 	}
 
+	return c.removeAgent(n)
+}
+
+// ForceRemove removes the agent from the cluster Agent list, then has one of
+// the remaining agents force the node out of the cluster. It is meant for
+// agents that can no longer leave gracefully, e.g. because they have been
+// terminated.
+func (c *Cluster) ForceRemove(n libagent.Agent) error {
+	if err := c.removeAgent(n); err != nil {
+		return err
+	}
+
+	if len(c.Agents) == 0 {
+		return errors.New("no agents left to force the node out of the cluster")
+	}
+
+	err := c.Agents[0].GetClient().Agent().ForceLeave(n.GetNodeName())
+	if err != nil {
+		return errors.Wrapf(err, "could not force remove agent %s", n.GetNodeName())
+	}
+	return nil
+}
+
+func (c *Cluster) removeAgent(n libagent.Agent) error {
 	foundIdx := -1
 	for idx, this := range c.Agents {
 		if this == n {
@@ -198,16 +309,212 @@ func (c *Cluster) StandardUpgrade(t *testing.T, ctx context.Context, targetVersi
 	return nil
 }
 
-// Terminate will attempt to terminate all agents in the cluster and its network. If any agent
-// termination fails, Terminate will abort and return an error.
-func (c *Cluster) Terminate() error {
-	for _, n := range c.Agents {
-		err := n.Terminate()
+// RollingRestart replaces every server in the cluster with a new one built
+// from ctx, one at a time, without losing quorum. For each server a
+// replacement is added and joined, Raft is given time to stabilize with the
+// replacement as a voter, and then the old server leaves and is terminated.
+//
+// The client is used to observe the cluster and must not point at a server
+// being replaced. An error is returned if the cluster is without a leader for
+// longer than LeaderLossTolerance at any point.
+func (c *Cluster) RollingRestart(client *api.Client, ctx *libagent.BuildContext) error {
+	servers, err := c.Servers()
+	if err != nil {
+		return err
+	}
+
+	tolerance := c.LeaderLossTolerance
+	if tolerance == 0 {
+		tolerance = DefaultLeaderLossTolerance
+	}
+	monitor := newLeaderMonitor(client, tolerance)
+	defer monitor.stop()
+
+	for _, old := range servers {
+		var joinAddrs []string
+		for _, n := range c.Agents {
+			if n != old {
+				addr, _ := n.GetAddr()
+				joinAddrs = append(joinAddrs, addr)
+			}
+		}
+
+		conf, err := libagent.NewConfigBuilder(ctx).
+			Bootstrap(0).
+			RetryJoin(joinAddrs...).
+			ToAgentConfig()
 		if err != nil {
+			return errors.Wrap(err, "could not build replacement server config")
+		}
+
+		if err := c.Add([]libagent.Config{*conf}); err != nil {
+			return errors.Wrapf(err, "could not add replacement for server %s", old.GetName())
+		}
+		if err := waitForMembers(client, len(c.Agents)); err != nil {
+			return err
+		}
+		if err := waitForRaftVoters(client, len(servers)+1); err != nil {
+			return err
+		}
+		if err := monitor.err(); err != nil {
+			return err
+		}
+
+		if err := c.Remove(old); err != nil {
+			return err
+		}
+		if err := old.Terminate(); err != nil {
+			return errors.Wrapf(err, "could not terminate server %s", old.GetName())
+		}
+		if err := waitForMembers(client, len(c.Agents)); err != nil {
+			return err
+		}
+		if err := waitForRaftVoters(client, len(servers)); err != nil {
+			return err
+		}
+		if err := monitor.err(); err != nil {
 			return err
 		}
 	}
 
+	return nil
+}
+
+// Upgrade replaces every agent of the cluster in place with one running
+// newVersion, keeping its node name and data directory. Servers are upgraded
+// one at a time, the followers first and the leader last, so the cluster
+// keeps its quorum, and then the clients are upgraded. After each agent, the
+// cluster must have all of its members alive, all of its servers as Raft
+// voters and a leader before the next agent is upgraded.
+//
+// An error is returned if the cluster is without a leader for longer than
+// LeaderLossTolerance during the upgrade of an agent.
+func (c *Cluster) Upgrade(ctx context.Context, newVersion string) error {
+	leader, err := c.Leader()
+	if err != nil {
+		return err
+	}
+	followers, err := c.Followers()
+	if err != nil {
+		return err
+	}
+	clients, err := c.Clients()
+	if err != nil {
+		return err
+	}
+
+	order := append(append(followers, leader), clients...)
+	numServers := len(followers) + 1
+
+	for _, n := range order {
+		if err := c.upgradeAgent(ctx, n, newVersion, numServers); err != nil {
+			return errors.Wrapf(err, "could not upgrade agent %s to version %s", n.GetName(), newVersion)
+		}
+	}
+	return nil
+}
+
+// upgradeAgent upgrades n to newVersion and waits for the cluster to be
+// healthy again.
+func (c *Cluster) upgradeAgent(ctx context.Context, n libagent.Agent, newVersion string, numServers int) error {
+	tolerance := c.LeaderLossTolerance
+	if tolerance == 0 {
+		tolerance = DefaultLeaderLossTolerance
+	}
+
+	// The leader is observed through another agent while n is down.
+	var monitor *leaderMonitor
+	for _, observer := range c.Agents {
+		if observer != n {
+			monitor = newLeaderMonitor(observer.GetClient(), tolerance)
+			defer monitor.stop()
+			break
+		}
+	}
+
+	config := n.GetConfig()
+	config.Version = newVersion
+	if err := n.Upgrade(ctx, config); err != nil {
+		return err
+	}
+
+	client := n.GetClient()
+	if err := waitForMembers(client, len(c.Agents)); err != nil {
+		return err
+	}
+	if err := waitForRaftVoters(client, numServers); err != nil {
+		return err
+	}
+	err := waitFor("a leader", func() error {
+		_, err := getLeader(client)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if monitor != nil {
+		return monitor.err()
+	}
+	return nil
+}
+
+// CreatePartition creates an admin partition with the given name. Admin
+// partitions require Consul Enterprise.
+func (c *Cluster) CreatePartition(name string) error {
+	if len(c.Agents) < 1 {
+		return fmt.Errorf("no agent available")
+	}
+
+	partition := &api.Partition{Name: name}
+	_, _, err := c.Agents[0].GetClient().Partitions().Create(context.Background(), partition, nil)
+	if err != nil {
+		return errors.Wrapf(err, "could not create partition %s", name)
+	}
+	return nil
+}
+
+// RegisterCatalogService registers a node, and optionally a service and
+// checks, directly in the catalog through the leader. No instance is started
+// for the service, see libservice for services backed by containers.
+func (c *Cluster) RegisterCatalogService(reg *api.CatalogRegistration) error {
+	leader, err := c.Leader()
+	if err != nil {
+		return fmt.Errorf("could not determine leader: %w", err)
+	}
+
+	if _, err := leader.GetClient().Catalog().Register(reg, nil); err != nil {
+		return errors.Wrapf(err, "could not register node %s in the catalog", reg.Node)
+	}
+	return nil
+}
+
+// DeregisterCatalogService removes a node, service, or check registered with
+// RegisterCatalogService from the catalog through the leader.
+func (c *Cluster) DeregisterCatalogService(dereg *api.CatalogDeregistration) error {
+	leader, err := c.Leader()
+	if err != nil {
+		return fmt.Errorf("could not determine leader: %w", err)
+	}
+
+	if _, err := leader.GetClient().Catalog().Deregister(dereg, nil); err != nil {
+		return errors.Wrapf(err, "could not deregister node %s from the catalog", dereg.Node)
+	}
+	return nil
+}
+
+// maxConcurrentTerminations is the number of agents Terminate tears down at
+// the same time.
+const maxConcurrentTerminations = 8
+
+// Terminate will attempt to terminate all agents in the cluster and its network. Agents are
+// terminated concurrently and every agent is attempted even if some fail. If a single agent
+// termination fails its error is returned, if several fail their errors are combined.
+func (c *Cluster) Terminate() error {
+	if err := terminateAgents(c.Agents, maxConcurrentTerminations); err != nil {
+		return err
+	}
+
 	// Testcontainers seems to clean this the network.
 	// Trigger it now will throw an error while the containers are still shutting down
 	//if err := c.Network.Remove(context.Background()); err != nil {
@@ -216,6 +523,41 @@ func (c *Cluster) Terminate() error {
 	return nil
 }
 
+// terminateAgents terminates the agents with at most workers terminations
+// running at the same time.
+func terminateAgents(agents []libagent.Agent, workers int) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, workers)
+	)
+
+	for _, n := range agents {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(n libagent.Agent) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := n.Terminate(); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return multierror.Append(nil, errs...)
+}
+
 // Leader returns the cluster leader agent, or an error if no leader is
 // available.
 func (c *Cluster) Leader() (libagent.Agent, error) {
@@ -238,17 +580,84 @@ func (c *Cluster) Leader() (libagent.Agent, error) {
 	return nil, fmt.Errorf("leader not found")
 }
 
+// LeaderAddress returns the advertised server RPC address of the current
+// leader, as reported by the first agent that can be reached. ErrNoLeader is
+// returned if the cluster has not elected a leader.
+func (c *Cluster) LeaderAddress() (string, error) {
+	if len(c.Agents) < 1 {
+		return "", fmt.Errorf("no agent available")
+	}
+
+	var lastErr error
+	for _, n := range c.Agents {
+		leaderAdd, err := getLeader(n.GetClient())
+		if err == nil {
+			return leaderAdd, nil
+		}
+		// Prefer reporting that there is no leader over agents that could
+		// not be reached at all.
+		if lastErr == nil || errors.Is(err, ErrNoLeader) {
+			lastErr = err
+		}
+	}
+	return "", lastErr
+}
+
 func getLeader(client *api.Client) (string, error) {
 	leaderAdd, err := client.Status().Leader()
 	if err != nil {
 		return "", errors.Wrap(err, "could not query leader")
 	}
 	if leaderAdd == "" {
-		return "", errors.New("no leader available")
+		return "", ErrNoLeader
 	}
 	return leaderAdd, nil
 }
 
+// Snapshot saves a snapshot of the cluster state from the current leader and
+// returns its contents.
+func (c *Cluster) Snapshot() ([]byte, error) {
+	leader, err := c.Leader()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine leader: %w", err)
+	}
+
+	snap, _, err := leader.GetClient().Snapshot().Save(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error saving snapshot: %w", err)
+	}
+	defer snap.Close()
+
+	data, err := io.ReadAll(snap)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// Restore restores a snapshot taken with Snapshot through the current leader.
+// It returns once the leader has applied the snapshot and is serving
+// consistent reads again.
+func (c *Cluster) Restore(data []byte) error {
+	leader, err := c.Leader()
+	if err != nil {
+		return fmt.Errorf("could not determine leader: %w", err)
+	}
+
+	client := leader.GetClient()
+	if err := client.Snapshot().Restore(nil, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("error restoring snapshot: %w", err)
+	}
+
+	return waitFor("the leader to apply the snapshot", func() error {
+		if _, err := getLeader(client); err != nil {
+			return err
+		}
+		_, _, err := client.Catalog().Services(&api.QueryOptions{RequireConsistent: true})
+		return err
+	})
+}
+
 // Followers returns the cluster following servers.
 func (c *Cluster) Followers() ([]libagent.Agent, error) {
 	var followers []libagent.Agent
@@ -290,11 +699,126 @@ func (c *Cluster) Clients() ([]libagent.Agent, error) {
 	return clients, nil
 }
 
+// RaftConfiguration returns the Raft configuration of the cluster, as read
+// through the first agent that can be reached. Unlike Servers and Followers,
+// which only reflect the agents of the cluster, it tells which servers are
+// Raft voters.
+func (c *Cluster) RaftConfiguration() (*api.RaftConfiguration, error) {
+	if len(c.Agents) < 1 {
+		return nil, fmt.Errorf("no agent available")
+	}
+
+	var lastErr error
+	for _, n := range c.Agents {
+		raftConfig, err := n.GetClient().Operator().RaftGetConfiguration(nil)
+		if err == nil {
+			return raftConfig, nil
+		}
+		lastErr = err
+	}
+	return nil, errors.Wrap(lastErr, "could not read the raft configuration")
+}
+
+// WaitForKV waits until every server of the cluster has the expected value
+// for the key, or until the timeout elapses. A nil expected value waits for
+// the key to be deleted. Each server is read through its own client with stale
+// consistency, so it answers from its local state rather than forwarding to
+// the leader, which makes replication lag visible.
+func (c *Cluster) WaitForKV(key string, expected []byte, timeout time.Duration) error {
+	servers, err := c.Servers()
+	if err != nil {
+		return err
+	}
+	if len(servers) < 1 {
+		return fmt.Errorf("no server available")
+	}
+
+	return waitForWithTimeout(fmt.Sprintf("the servers to converge on key %q", key), timeout, func() error {
+		for _, server := range servers {
+			if err := checkKV(server.GetClient(), key, expected); err != nil {
+				return errors.Wrapf(err, "server %s", server.GetNodeName())
+			}
+		}
+		return nil
+	})
+}
+
+func checkKV(client *api.Client, key string, expected []byte) error {
+	pair, _, err := client.KV().Get(key, &api.QueryOptions{AllowStale: true})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case pair == nil && expected == nil:
+		return nil
+	case pair == nil:
+		return fmt.Errorf("key %q does not exist", key)
+	case expected == nil:
+		return fmt.Errorf("key %q still exists", key)
+	case !bytes.Equal(pair.Value, expected):
+		return fmt.Errorf("key %q has value %q", key, pair.Value)
+	}
+	return nil
+}
+
+// GetClientForAgent returns an API client targeting the HTTP API of the given
+// agent of the cluster. Requests made with it are handled by that agent, e.g.
+// a follower that forwards them to the leader, rather than by the agent of
+// the client returned by the cluster setup helpers.
+func (c *Cluster) GetClientForAgent(n libagent.Agent) (*api.Client, error) {
+	for _, a := range c.Agents {
+		if a == n {
+			return n.GetClient(), nil
+		}
+	}
+	return nil, fmt.Errorf("agent %s is not part of the cluster", n.GetNodeName())
+}
+
 // PeerWithCluster establishes peering with the acceptor cluster
 func (c *Cluster) PeerWithCluster(acceptingClient *api.Client, acceptingPeerName string, dialingPeerName string) error {
+	return c.peerWithCluster(acceptingClient, acceptingPeerName, dialingPeerName, PeeringModeDirect, nil)
+}
+
+// PeerWithClusterMode is like PeerWithCluster, but the servers of the clusters
+// reach each other as selected by mode. With PeeringModeMeshGateways, both
+// clusters are configured to peer through their mesh gateways before the
+// peering token is generated.
+func (c *Cluster) PeerWithClusterMode(acceptingClient *api.Client, acceptingPeerName string, dialingPeerName string, mode PeeringMode) error {
+	return c.peerWithCluster(acceptingClient, acceptingPeerName, dialingPeerName, mode, nil)
+}
+
+// PeerWithClusterUsingAddresses establishes peering with the acceptor cluster,
+// but forces the dialer to use addrs as the initial peer server addresses
+// instead of the ones advertised in the peering token. This is useful to
+// verify that the addresses learned over the peering stream supersede the
+// initial ones.
+func (c *Cluster) PeerWithClusterUsingAddresses(acceptingClient *api.Client, acceptingPeerName string, dialingPeerName string, addrs []string) error {
+	if len(addrs) == 0 {
+		return fmt.Errorf("at least one peer server address is required")
+	}
+	return c.peerWithCluster(acceptingClient, acceptingPeerName, dialingPeerName, PeeringModeDirect, addrs)
+}
+
+func (c *Cluster) peerWithCluster(acceptingClient *api.Client, acceptingPeerName string, dialingPeerName string, mode PeeringMode, addrs []string) error {
 	node := c.Agents[0]
 	dialingClient := node.GetClient()
 
+	switch mode {
+	case PeeringModeDirect:
+	case PeeringModeMeshGateways:
+		// The accepting cluster must be configured first so the peering token
+		// carries the addresses of its mesh gateways.
+		for _, client := range []*api.Client{acceptingClient, dialingClient} {
+			peering := &api.PeeringMeshConfig{PeerThroughMeshGateways: true}
+			if _, err := client.ConfigEntries().SetMeshPeering(peering, nil); err != nil {
+				return fmt.Errorf("error configuring peering through mesh gateways: %v", err)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown peering mode %q", mode)
+	}
+
 	generateReq := api.PeeringGenerateTokenRequest{
 		PeerName: acceptingPeerName,
 	}
@@ -303,9 +827,17 @@ func (c *Cluster) PeerWithCluster(acceptingClient *api.Client, acceptingPeerName
 		return fmt.Errorf("error generate token: %v", err)
 	}
 
+	peeringToken := generateRes.PeeringToken
+	if addrs != nil {
+		peeringToken, err = overridePeeringTokenAddresses(peeringToken, addrs)
+		if err != nil {
+			return fmt.Errorf("error overriding peer server addresses: %v", err)
+		}
+	}
+
 	establishReq := api.PeeringEstablishRequest{
 		PeerName:     dialingPeerName,
-		PeeringToken: generateRes.PeeringToken,
+		PeeringToken: peeringToken,
 	}
 	_, _, err = dialingClient.Peerings().Establish(context.Background(), establishReq, &api.WriteOptions{})
 	if err != nil {
@@ -315,6 +847,28 @@ func (c *Cluster) PeerWithCluster(acceptingClient *api.Client, acceptingPeerName
 	return nil
 }
 
+// overridePeeringTokenAddresses replaces the server addresses encoded in a
+// peering token. The dialing cluster stores them as the PeerServerAddresses
+// of the peering when it is established.
+func overridePeeringTokenAddresses(token string, addrs []string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+
+	var tok structs.PeeringToken
+	if err := json.Unmarshal(raw, &tok); err != nil {
+		return "", fmt.Errorf("failed to unmarshal token: %w", err)
+	}
+	tok.ServerAddresses = addrs
+
+	raw, err = json.Marshal(tok)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal token: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
 const retryTimeout = 90 * time.Second
 const retryFrequency = 500 * time.Millisecond
 
@@ -355,3 +909,156 @@ func WaitForMembers(t *testing.T, client *api.Client, expectN int) {
 		require.Equal(r, expectN, activeMembers)
 	})
 }
+
+// WaitForServers waits until the client sees expectN alive server members.
+func WaitForServers(t *testing.T, client *api.Client, expectN int) {
+	waitForMembersWithRole(t, client, "consul", expectN)
+}
+
+// WaitForClients waits until the client sees expectN alive client members.
+func WaitForClients(t *testing.T, client *api.Client, expectN int) {
+	waitForMembersWithRole(t, client, "node", expectN)
+}
+
+func waitForMembersWithRole(t *testing.T, client *api.Client, role string, expectN int) {
+	retry.RunWith(LongFailer(), t, func(r *retry.R) {
+		members, err := client.Agent().Members(false)
+		require.NoError(r, err)
+		require.Equal(r, expectN, countAliveMembers(members, role))
+	})
+}
+
+// countAliveMembers returns the number of alive members whose "role" tag
+// matches role, i.e. "consul" for servers and "node" for clients.
+func countAliveMembers(members []*api.AgentMember, role string) int {
+	var n int
+	for _, member := range members {
+		if serf.MemberStatus(member.Status) == serf.StatusAlive && member.Tags["role"] == role {
+			n++
+		}
+	}
+	return n
+}
+
+// waitForMembers is like WaitForMembers, but returns an error instead of
+// failing a test.
+func waitForMembers(client *api.Client, expectN int) error {
+	return waitFor(fmt.Sprintf("%d alive members", expectN), func() error {
+		members, err := client.Agent().Members(false)
+		if err != nil {
+			return err
+		}
+		var activeMembers int
+		for _, member := range members {
+			if serf.MemberStatus(member.Status) == serf.StatusAlive {
+				activeMembers++
+			}
+		}
+		if activeMembers != expectN {
+			return fmt.Errorf("got %d alive members", activeMembers)
+		}
+		return nil
+	})
+}
+
+// waitForRaftVoters waits until the Raft configuration contains exactly
+// expectN servers which are all voters.
+func waitForRaftVoters(client *api.Client, expectN int) error {
+	return waitFor(fmt.Sprintf("%d raft voters", expectN), func() error {
+		raftConfig, err := client.Operator().RaftGetConfiguration(nil)
+		if err != nil {
+			return err
+		}
+		var voters int
+		for _, server := range raftConfig.Servers {
+			if server.Voter {
+				voters++
+			}
+		}
+		if len(raftConfig.Servers) != expectN || voters != expectN {
+			return fmt.Errorf("got %d raft servers with %d voters", len(raftConfig.Servers), voters)
+		}
+		return nil
+	})
+}
+
+// waitFor polls check at retryFrequency until it succeeds or retryTimeout
+// elapses.
+func waitFor(desc string, check func() error) error {
+	return waitForWithTimeout(desc, retryTimeout, check)
+}
+
+// waitForWithTimeout is like waitFor, but gives up after the given timeout.
+func waitForWithTimeout(desc string, timeout time.Duration, check func() error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := check()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Wrapf(err, "timed out waiting for %s", desc)
+		}
+		time.Sleep(retryFrequency)
+	}
+}
+
+// leaderMonitor polls for a leader in the background and records an error
+// if no leader is seen for longer than the tolerance.
+type leaderMonitor struct {
+	stopCh chan struct{}
+	doneCh chan struct{}
+
+	mu     sync.Mutex
+	lostAt time.Time
+	failed error
+}
+
+func newLeaderMonitor(client *api.Client, tolerance time.Duration) *leaderMonitor {
+	m := &leaderMonitor{
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(m.doneCh)
+
+		ticker := time.NewTicker(retryFrequency)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.stopCh:
+				return
+			case <-ticker.C:
+			}
+
+			_, err := getLeader(client)
+
+			m.mu.Lock()
+			switch {
+			case err == nil:
+				m.lostAt = time.Time{}
+			case m.lostAt.IsZero():
+				m.lostAt = time.Now()
+			case m.failed == nil && time.Since(m.lostAt) > tolerance:
+				m.failed = fmt.Errorf("cluster has been without a leader for more than %s: %w", tolerance, err)
+			}
+			m.mu.Unlock()
+		}
+	}()
+
+	return m
+}
+
+// err returns the first leader loss that exceeded the tolerance, if any.
+func (m *leaderMonitor) err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.failed
+}
+
+func (m *leaderMonitor) stop() {
+	close(m.stopCh)
+	<-m.doneCh
+}
@@ -0,0 +1,417 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/serf/serf"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+)
+
+func TestOverridePeeringTokenAddresses(t *testing.T) {
+	orig := structs.PeeringToken{
+		CA:              []string{"ca-pem"},
+		ServerAddresses: []string{"10.0.0.1:8503"},
+		ServerName:      "server.dc1.peering.consul",
+		PeerID:          "9e650110-ac74-4c5a-a6a8-9348b2bed4e9",
+	}
+	raw, err := json.Marshal(orig)
+	require.NoError(t, err)
+
+	token, err := overridePeeringTokenAddresses(base64.StdEncoding.EncodeToString(raw), []string{"192.0.2.1:8503", "10.0.0.2:8503"})
+	require.NoError(t, err)
+
+	raw, err = base64.StdEncoding.DecodeString(token)
+	require.NoError(t, err)
+
+	var got structs.PeeringToken
+	require.NoError(t, json.Unmarshal(raw, &got))
+
+	expect := orig
+	expect.ServerAddresses = []string{"192.0.2.1:8503", "10.0.0.2:8503"}
+	require.Equal(t, expect, got)
+
+	_, err = overridePeeringTokenAddresses("not-a-token", []string{"192.0.2.1:8503"})
+	require.Error(t, err)
+}
+
+func TestCountAliveMembers(t *testing.T) {
+	members := []*api.AgentMember{
+		{Name: "server-1", Status: int(serf.StatusAlive), Tags: map[string]string{"role": "consul"}},
+		{Name: "server-2", Status: int(serf.StatusAlive), Tags: map[string]string{"role": "consul"}},
+		{Name: "server-3", Status: int(serf.StatusLeft), Tags: map[string]string{"role": "consul"}},
+		{Name: "client-1", Status: int(serf.StatusAlive), Tags: map[string]string{"role": "node"}},
+		{Name: "client-2", Status: int(serf.StatusFailed), Tags: map[string]string{"role": "node"}},
+		{Name: "unknown", Status: int(serf.StatusAlive)},
+	}
+
+	require.Equal(t, 2, countAliveMembers(members, "consul"))
+	require.Equal(t, 1, countAliveMembers(members, "node"))
+	require.Equal(t, 0, countAliveMembers(nil, "consul"))
+}
+
+func TestIptablesDropCommands(t *testing.T) {
+	require.Equal(t, [][]string{
+		{"iptables", "-I", "INPUT", "-s", "10.0.0.2", "-j", "DROP"},
+		{"iptables", "-I", "OUTPUT", "-d", "10.0.0.2", "-j", "DROP"},
+	}, iptablesDropCommands("-I", "10.0.0.2"))
+
+	require.Equal(t, [][]string{
+		{"iptables", "-D", "INPUT", "-s", "10.0.0.2", "-j", "DROP"},
+		{"iptables", "-D", "OUTPUT", "-d", "10.0.0.2", "-j", "DROP"},
+	}, iptablesDropCommands("-D", "10.0.0.2"))
+}
+
+func TestNetemScripts(t *testing.T) {
+	require.Equal(t,
+		`set -e; for dev in $(ls /sys/class/net); do if [ "$dev" != lo ]; then tc qdisc replace dev "$dev" root netem delay 200000us loss 5%; fi; done`,
+		netemImpairScript(200*time.Millisecond, 5))
+
+	require.Contains(t, netemImpairScript(1500*time.Microsecond, 0), "netem delay 1500us loss 0%")
+
+	require.Equal(t,
+		`set -e; for dev in $(ls /sys/class/net); do if tc qdisc show dev "$dev" | grep -q netem; then tc qdisc del dev "$dev" root; fi; done`,
+		netemUnimpairScript())
+}
+
+func TestCluster_Impair_Validation(t *testing.T) {
+	var c Cluster
+	require.EqualError(t, c.Impair(nil, -time.Second, 0), "latency must not be negative")
+	require.EqualError(t, c.Impair(nil, time.Second, 101), "lossPercent must be between 0 and 100")
+	require.EqualError(t, c.Impair(nil, time.Second, -1), "lossPercent must be between 0 and 100")
+}
+
+// terminateAgent is an agent that records concurrent calls to Terminate.
+type terminateAgent struct {
+	libagent.Agent
+
+	active     *int32
+	maxActive  *int32
+	terminated int32
+	err        error
+}
+
+func (a *terminateAgent) Terminate() error {
+	n := atomic.AddInt32(a.active, 1)
+	defer atomic.AddInt32(a.active, -1)
+	for {
+		max := atomic.LoadInt32(a.maxActive)
+		if n <= max || atomic.CompareAndSwapInt32(a.maxActive, max, n) {
+			break
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	atomic.StoreInt32(&a.terminated, 1)
+	return a.err
+}
+
+func TestCluster_Terminate(t *testing.T) {
+	const numAgents = 20
+
+	var active, maxActive int32
+	cluster := &Cluster{}
+	var agents []*terminateAgent
+	for i := 0; i < numAgents; i++ {
+		a := &terminateAgent{active: &active, maxActive: &maxActive}
+		if i%7 == 3 {
+			a.err = fmt.Errorf("agent %d failed", i)
+		}
+		agents = append(agents, a)
+		cluster.Agents = append(cluster.Agents, a)
+	}
+
+	start := time.Now()
+	err := cluster.Terminate()
+	elapsed := time.Since(start)
+
+	// Every agent is terminated even though some failed.
+	for i, a := range agents {
+		require.Equal(t, int32(1), atomic.LoadInt32(&a.terminated), "agent %d", i)
+	}
+
+	require.Greater(t, maxActive, int32(1))
+	require.LessOrEqual(t, maxActive, int32(maxConcurrentTerminations))
+	require.Less(t, elapsed, numAgents*50*time.Millisecond)
+
+	require.Error(t, err)
+	for _, i := range []int{3, 10, 17} {
+		require.Contains(t, err.Error(), fmt.Sprintf("agent %d failed", i))
+	}
+
+	t.Run("single error", func(t *testing.T) {
+		expected := errors.New("boom")
+		cluster := &Cluster{Agents: []libagent.Agent{
+			&terminateAgent{active: &active, maxActive: &maxActive},
+			&terminateAgent{active: &active, maxActive: &maxActive, err: expected},
+		}}
+		require.Equal(t, expected, cluster.Terminate())
+	})
+}
+
+func TestStandUpConfigs(t *testing.T) {
+	var configured int
+	configs, err := standUpConfigs(nil, 3, 2, []func(*libagent.Builder){
+		func(b *libagent.Builder) {
+			configured++
+			b.Datacenter("dc3")
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, configs, 5)
+	require.Equal(t, 5, configured)
+
+	type renderedConfig struct {
+		Server          bool     `json:"server"`
+		BootstrapExpect int      `json:"bootstrap_expect"`
+		RetryJoin       []string `json:"retry_join"`
+		Datacenter      string   `json:"datacenter"`
+	}
+	var rendered []renderedConfig
+	for _, conf := range configs {
+		var rc renderedConfig
+		require.NoError(t, json.Unmarshal([]byte(conf.JSON), &rc))
+		require.Equal(t, "dc3", rc.Datacenter)
+		rendered = append(rendered, rc)
+	}
+
+	for i, rc := range rendered[:3] {
+		require.True(t, rc.Server, "agent %d", i)
+		require.Equal(t, 3, rc.BootstrapExpect)
+		require.Equal(t, []string{fmt.Sprintf("agent-%d", (i+1)%3)}, rc.RetryJoin)
+	}
+	for i, rc := range rendered[3:] {
+		require.False(t, rc.Server, "agent %d", i+3)
+		require.Zero(t, rc.BootstrapExpect)
+		require.Equal(t, []string{"agent-0", "agent-1", "agent-2"}, rc.RetryJoin)
+	}
+}
+
+// logAgent is an agent with fixed container logs and an API client.
+type logAgent struct {
+	libagent.Agent
+
+	name   string
+	logs   string
+	client *api.Client
+	server bool
+}
+
+func (a *logAgent) GetNodeName() string    { return a.name }
+func (a *logAgent) GetClient() *api.Client { return a.client }
+func (a *logAgent) IsServer() bool         { return a.server }
+
+func (a *logAgent) Logs(ctx context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(a.logs)), nil
+}
+
+func TestCluster_CollectLogs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/agent/monitor", r.URL.Path)
+		require.Equal(t, "DEBUG", r.URL.Query().Get("loglevel"))
+		fmt.Fprintln(w, "[DEBUG] agent: monitor line")
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(&api.Config{Address: srv.URL})
+	require.NoError(t, err)
+
+	cluster := &Cluster{Agents: []libagent.Agent{
+		&logAgent{name: "server-1", logs: "server-1 output\n", client: client},
+		&logAgent{name: "client-1", logs: "client-1 output\n", client: client},
+	}}
+
+	dir := filepath.Join(t.TempDir(), "logs")
+	require.NoError(t, cluster.CollectLogs(dir))
+
+	for _, name := range []string{"server-1", "client-1"} {
+		content, err := os.ReadFile(filepath.Join(dir, name+".log"))
+		require.NoError(t, err)
+		require.Equal(t, name+" output\n", string(content))
+
+		content, err = os.ReadFile(filepath.Join(dir, name+"-monitor.log"))
+		require.NoError(t, err)
+		require.Equal(t, "[DEBUG] agent: monitor line\n", string(content))
+	}
+}
+
+func TestLogDirName(t *testing.T) {
+	require.Equal(t, "TestRollingUpgrade_upgrade_1_14-logs-", logDirName("TestRollingUpgrade/upgrade 1.14"))
+}
+
+func TestCluster_GetClientForAgent(t *testing.T) {
+	client1, err := api.NewClient(&api.Config{Address: "10.0.0.1:8500"})
+	require.NoError(t, err)
+	client2, err := api.NewClient(&api.Config{Address: "10.0.0.2:8500"})
+	require.NoError(t, err)
+
+	server1 := &logAgent{name: "server-1", client: client1}
+	server2 := &logAgent{name: "server-2", client: client2}
+	cluster := &Cluster{Agents: []libagent.Agent{server1, server2}}
+
+	client, err := cluster.GetClientForAgent(server2)
+	require.NoError(t, err)
+	require.Same(t, client2, client)
+
+	_, err = cluster.GetClientForAgent(&logAgent{name: "other"})
+	require.EqualError(t, err, "agent other is not part of the cluster")
+}
+
+func TestCluster_RaftConfiguration(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/operator/raft/configuration", r.URL.Path)
+		fmt.Fprint(w, `{
+			"Servers": [
+				{"ID": "1", "Node": "agent-0", "Address": "10.0.0.1:8300", "Leader": true, "Voter": true},
+				{"ID": "2", "Node": "agent-1", "Address": "10.0.0.2:8300", "Voter": false}
+			],
+			"Index": 42
+		}`)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(&api.Config{Address: srv.URL})
+	require.NoError(t, err)
+	// The first agent cannot be reached, so the second one is used.
+	unreachable, err := api.NewClient(&api.Config{Address: "127.0.0.1:1"})
+	require.NoError(t, err)
+
+	cluster := &Cluster{Agents: []libagent.Agent{
+		&logAgent{name: "agent-0", client: unreachable},
+		&logAgent{name: "agent-1", client: client},
+	}}
+
+	raftConfig, err := cluster.RaftConfiguration()
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), raftConfig.Index)
+	require.Len(t, raftConfig.Servers, 2)
+	require.True(t, raftConfig.Servers[0].Voter)
+	require.Equal(t, "agent-1", raftConfig.Servers[1].Node)
+	require.False(t, raftConfig.Servers[1].Voter)
+
+	_, err = (&Cluster{}).RaftConfiguration()
+	require.EqualError(t, err, "no agent available")
+}
+
+func TestMeshGatewayReady(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/health/service/mesh", r.URL.Path)
+		require.Contains(t, r.URL.Query(), "passing")
+		fmt.Fprint(w, `[
+			{"Service": {"Kind": "mesh-gateway", "Service": "mesh", "Address": "10.0.0.1", "Port": 8443}}
+		]`)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(&api.Config{Address: srv.URL})
+	require.NoError(t, err)
+
+	require.NoError(t, meshGatewayReady(client, "10.0.0.1"))
+	require.EqualError(t, meshGatewayReady(client, "10.0.0.2"), "no healthy mesh gateway with address 10.0.0.2")
+}
+
+func TestCluster_PeerWithClusterMode(t *testing.T) {
+	newServer := func(name string, requests *[]string) *api.Client {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*requests = append(*requests, r.Method+" "+r.URL.Path)
+			switch r.URL.Path {
+			case "/v1/config/mesh/mesh":
+				w.WriteHeader(http.StatusNotFound)
+			case "/v1/config":
+				var entry api.MeshConfigEntry
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&entry))
+				require.True(t, entry.Peering.PeerThroughMeshGateways, "mesh config entry of %s", name)
+				fmt.Fprint(w, "true")
+			case "/v1/peering/token":
+				fmt.Fprint(w, `{"PeeringToken": "token"}`)
+			case "/v1/peering/establish":
+				fmt.Fprint(w, `{}`)
+			default:
+				t.Errorf("unexpected request %s %s to %s", r.Method, r.URL.Path, name)
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		client, err := api.NewClient(&api.Config{Address: srv.URL})
+		require.NoError(t, err)
+		return client
+	}
+
+	var acceptingRequests, dialingRequests []string
+	acceptingClient := newServer("accepting", &acceptingRequests)
+	dialing := &Cluster{Agents: []libagent.Agent{
+		&logAgent{name: "agent-0", client: newServer("dialing", &dialingRequests)},
+	}}
+
+	require.NoError(t, dialing.PeerWithClusterMode(acceptingClient, "accepting", "dialing", PeeringModeMeshGateways))
+	require.Equal(t, []string{"GET /v1/config/mesh/mesh", "PUT /v1/config", "POST /v1/peering/token"}, acceptingRequests)
+	require.Equal(t, []string{"GET /v1/config/mesh/mesh", "PUT /v1/config", "POST /v1/peering/establish"}, dialingRequests)
+
+	err := dialing.PeerWithClusterMode(acceptingClient, "accepting", "dialing", "bogus")
+	require.EqualError(t, err, `unknown peering mode "bogus"`)
+}
+
+func TestCluster_WaitForKV(t *testing.T) {
+	newClient := func(handler http.HandlerFunc) *api.Client {
+		srv := httptest.NewServer(handler)
+		t.Cleanup(srv.Close)
+
+		client, err := api.NewClient(&api.Config{Address: srv.URL})
+		require.NoError(t, err)
+		return client
+	}
+
+	kv := func(value string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/v1/kv/key", r.URL.Path)
+			require.Contains(t, r.URL.Query(), "stale")
+			fmt.Fprintf(w, `[{"Key": "key", "Value": %q}]`, base64.StdEncoding.EncodeToString([]byte(value)))
+		}
+	}
+
+	// The second server only has the key from the second read on.
+	var reads int
+	lagging := func(w http.ResponseWriter, r *http.Request) {
+		reads++
+		if reads == 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		kv("value")(w, r)
+	}
+
+	cluster := &Cluster{Agents: []libagent.Agent{
+		&logAgent{name: "agent-0", server: true, client: newClient(kv("value"))},
+		&logAgent{name: "agent-1", server: true, client: newClient(lagging)},
+		// Clients are not read.
+		&logAgent{name: "agent-2", client: newClient(kv("other"))},
+	}}
+	require.NoError(t, cluster.WaitForKV("key", []byte("value"), 10*time.Second))
+	require.Equal(t, 2, reads)
+
+	err := cluster.WaitForKV("key", []byte("other"), time.Second)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `server agent-0: key "key" has value "value"`)
+
+	err = cluster.WaitForKV("key", nil, time.Second)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `key "key" still exists`)
+
+	require.EqualError(t, (&Cluster{}).WaitForKV("key", nil, time.Second), "no server available")
+}
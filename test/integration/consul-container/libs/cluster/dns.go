@@ -0,0 +1,24 @@
+package cluster
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+)
+
+// DNSResolver returns a resolver sending its queries to the DNS interface of
+// the given agent, through the port mapped on the host. The resolvers of the
+// host are not used.
+func DNSResolver(n libagent.Agent) *net.Resolver {
+	host, port := n.GetDNSAddr()
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", addr)
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package cluster
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+)
+
+// dnsAgent is an agent with a fixed DNS address.
+type dnsAgent struct {
+	libagent.Agent
+
+	host string
+	port int
+}
+
+func (a *dnsAgent) GetDNSAddr() (string, int) { return a.host, a.port }
+
+func TestDNSResolver(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	resolver := DNSResolver(&dnsAgent{host: "127.0.0.1", port: addr.Port})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go resolver.LookupSRV(ctx, "", "", "web.service.consul.")
+
+	// The query is sent to the agent's address, nothing replies to it.
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 512)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Contains(t, string(buf[:n]), "\x03web\x07service\x06consul\x00")
+}
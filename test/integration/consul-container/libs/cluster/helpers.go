@@ -1,10 +1,10 @@
 package cluster
 
 import (
-	"context"
 	"fmt"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
 	"github.com/hashicorp/consul/api"
@@ -15,10 +15,107 @@ import (
 	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
 )
 
+// StandUpOption customizes the cluster created by StandUp.
+type StandUpOption func(*standUpConfig)
+
+type standUpConfig struct {
+	buildOpts libagent.BuildOptions
+	configure []func(*libagent.Builder)
+}
+
+// WithBuildOptions sets the build options shared by the agents of the
+// cluster. The ConsulVersion is always set to the version given to StandUp.
+func WithBuildOptions(opts libagent.BuildOptions) StandUpOption {
+	return func(c *standUpConfig) {
+		c.buildOpts = opts
+	}
+}
+
+// WithAgentConfig applies fn to the config builder of every agent of the
+// cluster, after StandUp has set up the topology.
+func WithAgentConfig(fn func(*libagent.Builder)) StandUpOption {
+	return func(c *standUpConfig) {
+		c.configure = append(c.configure, fn)
+	}
+}
+
+// StandUp creates a cluster of the given version with the given number of
+// server and client agents. The servers bootstrap together and the clients
+// join them. It returns once the cluster has a leader and all agents are
+// members.
+func StandUp(t *testing.T, version string, servers, clients int, opts ...StandUpOption) *Cluster {
+	require.Greater(t, servers, 0, "a cluster needs at least one server")
+
+	var conf standUpConfig
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	conf.buildOpts.ConsulVersion = version
+
+	ctx, err := libagent.NewBuildContext(conf.buildOpts)
+	require.NoError(t, err)
+
+	configs, err := standUpConfigs(ctx, servers, clients, conf.configure)
+	require.NoError(t, err)
+
+	cluster, err := New(configs)
+	require.NoError(t, err)
+
+	client := cluster.Agents[0].GetClient()
+	WaitForLeader(t, cluster, client)
+	WaitForMembers(t, client, servers+clients)
+
+	return cluster
+}
+
+// standUpConfigs returns the agent configs of a cluster with the given number
+// of servers followed by the given number of clients.
+func standUpConfigs(ctx *libagent.BuildContext, servers, clients int, configure []func(*libagent.Builder)) ([]libagent.Config, error) {
+	var (
+		configs     []libagent.Config
+		serverNames []string
+	)
+	for i := 0; i < servers; i++ {
+		serverNames = append(serverNames, fmt.Sprintf("agent-%d", i))
+	}
+
+	for i := 0; i < servers+clients; i++ {
+		b := libagent.NewConfigBuilder(ctx)
+		if i < servers {
+			b.Bootstrap(servers).
+				RetryJoin(serverNames[(i+1)%servers]) // Round-robin join the servers
+		} else {
+			b.Client().
+				RetryJoin(serverNames...)
+		}
+		for _, fn := range configure {
+			fn(b)
+		}
+
+		conf, err := b.ToAgentConfig()
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not build config of agent %d", i)
+		}
+		configs = append(configs, *conf)
+	}
+	return configs, nil
+}
+
 // creatingAcceptingClusterAndSetup creates a cluster with 3 servers and 1 client.
 // It also creates and registers a service+sidecar.
 // The API client returned is pointed at the client agent.
 func CreatingAcceptingClusterAndSetup(t *testing.T, numServer int, version string, acceptingPeerName string) (*Cluster, *api.Client, *libagent.BuildContext) {
+	return creatingAcceptingClusterAndSetup(t, numServer, version, acceptingPeerName, "")
+}
+
+// CreatingAcceptingClusterAndSetupOnNetwork is like
+// CreatingAcceptingClusterAndSetup, but the cluster joins the existing docker
+// network with the given name.
+func CreatingAcceptingClusterAndSetupOnNetwork(t *testing.T, numServer int, version string, acceptingPeerName string, networkName string) (*Cluster, *api.Client, *libagent.BuildContext) {
+	return creatingAcceptingClusterAndSetup(t, numServer, version, acceptingPeerName, networkName)
+}
+
+func creatingAcceptingClusterAndSetup(t *testing.T, numServer int, version string, acceptingPeerName string, networkName string) (*Cluster, *api.Client, *libagent.BuildContext) {
 	var configs []libagent.Config
 
 	opts := libagent.BuildOptions{
@@ -53,7 +150,7 @@ func CreatingAcceptingClusterAndSetup(t *testing.T, numServer int, version strin
 
 	configs = append(configs, *clientConf)
 
-	cluster, err := New(configs)
+	cluster, err := newCluster(configs, networkName)
 	require.NoError(t, err)
 
 	// Use the client agent as the HTTP endpoint since we will not rotate it
@@ -68,7 +165,7 @@ func CreatingAcceptingClusterAndSetup(t *testing.T, numServer int, version strin
 	require.True(t, ok)
 
 	// Create the mesh gateway for dataplane traffic
-	_, err = libservice.NewGatewayService(context.Background(), "mesh", "mesh", clientNode)
+	_, err = cluster.AddMeshGateway()
 	require.NoError(t, err)
 
 	// Create a service and proxy instance
@@ -101,6 +198,16 @@ func CreatingAcceptingClusterAndSetup(t *testing.T, numServer int, version strin
 
 // createDialingClusterAndSetup creates a cluster for peering with a single dev agent
 func CreateDialingClusterAndSetup(t *testing.T, version string, dialingPeerName string) (*Cluster, *api.Client, libservice.Service) {
+	return createDialingClusterAndSetup(t, version, dialingPeerName, "")
+}
+
+// CreateDialingClusterAndSetupOnNetwork is like CreateDialingClusterAndSetup,
+// but the cluster joins the existing docker network with the given name.
+func CreateDialingClusterAndSetupOnNetwork(t *testing.T, version string, dialingPeerName string, networkName string) (*Cluster, *api.Client, libservice.Service) {
+	return createDialingClusterAndSetup(t, version, dialingPeerName, networkName)
+}
+
+func createDialingClusterAndSetup(t *testing.T, version string, dialingPeerName string, networkName string) (*Cluster, *api.Client, libservice.Service) {
 	opts := libagent.BuildOptions{
 		Datacenter:             "dc2",
 		InjectAutoEncryption:   true,
@@ -118,7 +225,7 @@ func CreateDialingClusterAndSetup(t *testing.T, version string, dialingPeerName
 
 	configs := []libagent.Config{*conf}
 
-	cluster, err := New(configs)
+	cluster, err := newCluster(configs, networkName)
 	require.NoError(t, err)
 
 	node := cluster.Agents[0]
@@ -132,7 +239,7 @@ func CreateDialingClusterAndSetup(t *testing.T, version string, dialingPeerName
 	require.True(t, ok)
 
 	// Create the mesh gateway for dataplane traffic
-	_, err = libservice.NewGatewayService(context.Background(), "mesh", "mesh", node)
+	_, err = cluster.AddMeshGateway()
 	require.NoError(t, err)
 
 	// Create a service and proxy instance
@@ -143,3 +250,12 @@ func CreateDialingClusterAndSetup(t *testing.T, version string, dialingPeerName
 
 	return cluster, client, clientProxyService
 }
+
+// newCluster creates a cluster on the named network, or on a network of its
+// own if networkName is empty.
+func newCluster(configs []libagent.Config, networkName string) (*Cluster, error) {
+	if networkName == "" {
+		return New(configs)
+	}
+	return NewOnNetwork(configs, networkName)
+}
@@ -0,0 +1,108 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+)
+
+const (
+	// tcImage is the image the tc commands impairing an agent are run with,
+	// since the consul image doesn't ship tc.
+	tcImage = "docker.mirror.hashicorp.services/gaiadocker/iproute2"
+
+	// iptablesImage is the image the iptables commands partitioning agents are
+	// run with, since the consul image doesn't ship iptables.
+	iptablesImage = "docker.mirror.hashicorp.services/nicolaka/netshoot"
+)
+
+// Impair degrades the network of agent: every packet it sends is delayed by
+// latency, and lossPercent percent of them are dropped. The impairment is a
+// netem queueing discipline on each interface of the network namespace of the
+// agent, so it applies to all its peers, and replaces any previous impairment
+// until Unimpair is called.
+func (c *Cluster) Impair(agent libagent.Agent, latency time.Duration, lossPercent int) error {
+	if latency < 0 {
+		return fmt.Errorf("latency must not be negative")
+	}
+	if lossPercent < 0 || lossPercent > 100 {
+		return fmt.Errorf("lossPercent must be between 0 and 100")
+	}
+	if err := runInNetworkNamespace(agent, tcImage, netemImpairScript(latency, lossPercent)); err != nil {
+		return errors.Wrapf(err, "could not impair agent %s", agent.GetNodeName())
+	}
+	return nil
+}
+
+// Unimpair removes the impairment added to the network of agent by Impair, if
+// any.
+func (c *Cluster) Unimpair(agent libagent.Agent) error {
+	if err := runInNetworkNamespace(agent, tcImage, netemUnimpairScript()); err != nil {
+		return errors.Wrapf(err, "could not unimpair agent %s", agent.GetNodeName())
+	}
+	return nil
+}
+
+// netemImpairScript returns the shell script replacing the root queueing
+// discipline of every interface but the loopback with netem.
+func netemImpairScript(latency time.Duration, lossPercent int) string {
+	return fmt.Sprintf(`set -e; for dev in $(ls /sys/class/net); do `+
+		`if [ "$dev" != lo ]; then tc qdisc replace dev "$dev" root netem delay %dus loss %d%%; fi; done`,
+		latency.Microseconds(), lossPercent)
+}
+
+// netemUnimpairScript returns the shell script deleting the netem root
+// queueing disciplines, which restores the default ones.
+func netemUnimpairScript() string {
+	return `set -e; for dev in $(ls /sys/class/net); do ` +
+		`if tc qdisc show dev "$dev" | grep -q netem; then tc qdisc del dev "$dev" root; fi; done`
+}
+
+// runInNetworkNamespace runs script with image in a privileged container
+// sharing the network namespace of agent and waits for it to exit. This keeps
+// the agent containers themselves unprivileged.
+func runInNetworkNamespace(agent libagent.Agent, image, script string) error {
+	ctx := context.Background()
+	name := strings.TrimPrefix(agent.GetName(), "/")
+
+	req := testcontainers.ContainerRequest{
+		Image:       image,
+		Entrypoint:  []string{"/bin/sh", "-c"},
+		Cmd:         []string{script},
+		NetworkMode: dockercontainer.NetworkMode("container:" + name),
+		Privileged:  true,
+		WaitingFor:  wait.ForExit().WithExitTimeout(30 * time.Second),
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return err
+	}
+	defer container.Terminate(ctx)
+
+	state, err := container.State(ctx)
+	if err != nil {
+		return err
+	}
+	if state.ExitCode != 0 {
+		var output bytes.Buffer
+		if logs, err := container.Logs(ctx); err == nil {
+			stdcopy.StdCopy(&output, &output, logs)
+			logs.Close()
+		}
+		return fmt.Errorf("%q exited with code %d: %s", script, state.ExitCode, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/hashicorp/consul/api"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+)
+
+// monitorLogsDuration is how long CollectLogs streams the output of the agent
+// monitor endpoint of each agent.
+const monitorLogsDuration = 2 * time.Second
+
+// CollectLogs writes the logs of every agent of the cluster to dir, which is
+// created if needed. For each agent, the container output is written to
+// <node name>.log and the debug output of the agent monitor endpoint,
+// streamed for a short while, to <node name>-monitor.log. Every agent is
+// attempted even if some fail, and the errors are combined.
+func (c *Cluster) CollectLogs(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var errs error
+	for _, n := range c.Agents {
+		if err := collectAgentLogs(n, dir, monitorLogsDuration); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("could not collect the logs of agent %s: %w", n.GetNodeName(), err))
+		}
+	}
+	return errs
+}
+
+// DumpLogsOnFailure collects the logs of the cluster into a temporary
+// directory if the test failed. It is meant to be deferred after the cluster
+// termination, so that it runs while the agents are still available:
+//
+//	defer terminate(t, cluster)
+//	defer cluster.DumpLogsOnFailure(t)
+func (c *Cluster) DumpLogsOnFailure(t *testing.T) {
+	if !t.Failed() {
+		return
+	}
+
+	dir, err := os.MkdirTemp("", logDirName(t.Name()))
+	if err != nil {
+		t.Logf("could not create the log directory: %v", err)
+		return
+	}
+	if err := c.CollectLogs(dir); err != nil {
+		t.Logf("could not collect all cluster logs: %v", err)
+	}
+	t.Logf("cluster logs written to %s", dir)
+}
+
+var logDirNameRegexp = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// logDirName returns a directory name prefix for the logs of the test.
+func logDirName(testName string) string {
+	return logDirNameRegexp.ReplaceAllString(testName, "_") + "-logs-"
+}
+
+func collectAgentLogs(n libagent.Agent, dir string, monitorDuration time.Duration) error {
+	var errs error
+
+	name := n.GetNodeName()
+	if err := writeLogFile(filepath.Join(dir, name+".log"), func(w io.Writer) error {
+		return writeContainerLogs(n, w)
+	}); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+
+	if err := writeLogFile(filepath.Join(dir, name+"-monitor.log"), func(w io.Writer) error {
+		return writeMonitorLogs(n.GetClient(), w, monitorDuration)
+	}); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	return errs
+}
+
+func writeLogFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeContainerLogs(n libagent.Agent, w io.Writer) error {
+	logs, err := n.Logs(context.Background())
+	if err != nil {
+		return fmt.Errorf("could not read container logs: %w", err)
+	}
+	defer logs.Close()
+
+	_, err = io.Copy(w, logs)
+	return err
+}
+
+// writeMonitorLogs writes the log lines of the agent monitor endpoint to w
+// until the stream ends or d elapses.
+func writeMonitorLogs(client *api.Client, w io.Writer, d time.Duration) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	q := (&api.QueryOptions{}).WithContext(ctx)
+	logCh, err := client.Agent().Monitor("DEBUG", stopCh, q)
+	if err != nil {
+		return fmt.Errorf("could not monitor agent: %w", err)
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		select {
+		case line := <-logCh:
+			// An empty line signals the end of the stream.
+			if line == "" {
+				return nil
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		case <-timer.C:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/hashicorp/consul/api"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+)
+
+// meshGatewayName is the service name the mesh gateways are registered with.
+const meshGatewayName = "mesh"
+
+// PeeringMode selects how the servers of the dialing cluster reach the servers
+// of the accepting cluster.
+type PeeringMode string
+
+const (
+	// PeeringModeDirect has the dialing servers connect to the addresses of
+	// the accepting servers.
+	PeeringModeDirect PeeringMode = "direct"
+
+	// PeeringModeMeshGateways sends the peering control plane traffic through
+	// the mesh gateways of both clusters. Each cluster needs a mesh gateway,
+	// see AddMeshGateway, before peering.
+	PeeringModeMeshGateways PeeringMode = "mesh-gateways"
+)
+
+// AddMeshGateway starts a mesh gateway registered with the first client agent
+// of the cluster, or with the first agent if the cluster has no clients. It
+// returns once the gateway is registered and healthy. The gateway is
+// terminated along with the agent it is registered with.
+func (c *Cluster) AddMeshGateway() (libservice.Service, error) {
+	if len(c.Agents) < 1 {
+		return nil, fmt.Errorf("no agent available")
+	}
+
+	node := c.Agents[0]
+	clients, err := c.Clients()
+	if err != nil {
+		return nil, err
+	}
+	if len(clients) > 0 {
+		node = clients[0]
+	}
+
+	gateway, err := libservice.NewGatewayService(context.Background(), meshGatewayName, "mesh", node)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start the mesh gateway")
+	}
+
+	if err := waitForMeshGateway(node, gateway.GetContainerIP()); err != nil {
+		return nil, err
+	}
+	return gateway, nil
+}
+
+// waitForMeshGateway waits until the mesh gateway with the given address is
+// registered with the agent and all its checks are passing.
+func waitForMeshGateway(node libagent.Agent, address string) error {
+	client := node.GetClient()
+	return waitFor("the mesh gateway to be healthy", func() error {
+		return meshGatewayReady(client, address)
+	})
+}
+
+func meshGatewayReady(client *api.Client, address string) error {
+	entries, _, err := client.Health().Service(meshGatewayName, "", true, nil)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Service.Kind == api.ServiceKindMeshGateway && entry.Service.Address == address {
+			return nil
+		}
+	}
+	return fmt.Errorf("no healthy mesh gateway with address %s", address)
+}
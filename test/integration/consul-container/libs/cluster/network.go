@@ -7,6 +7,13 @@ import (
 	"github.com/testcontainers/testcontainers-go"
 )
 
+// NewNetwork creates a docker network with the given name that several
+// clusters can share through NewOnNetwork. The caller must remove the network
+// once the clusters have been terminated.
+func NewNetwork(name string) (testcontainers.Network, error) {
+	return createNetwork(name)
+}
+
 func createNetwork(name string) (testcontainers.Network, error) {
 	req := testcontainers.GenericNetworkRequest{
 		NetworkRequest: testcontainers.NetworkRequest{
@@ -0,0 +1,69 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+)
+
+// partitionRule is a set of iptables rules installed in the network namespace
+// of agent to drop all traffic to and from ip.
+type partitionRule struct {
+	agent libagent.Agent
+	ip    string
+}
+
+// PartitionAgents blocks all traffic between the agents of groupA and the
+// agents of groupB, while traffic within each group is unaffected. The
+// partition is created with iptables rules in the network namespace of the
+// agents of groupA and lasts until HealPartition is called.
+func (c *Cluster) PartitionAgents(groupA, groupB []libagent.Agent) error {
+	for _, a := range groupA {
+		for _, b := range groupB {
+			if a == b {
+				return fmt.Errorf("agent %s cannot be on both sides of a partition", a.GetNodeName())
+			}
+
+			ip, _ := b.GetAddr()
+			rule := partitionRule{agent: a, ip: ip}
+			if err := rule.apply("-I"); err != nil {
+				return errors.Wrapf(err, "could not partition agent %s from %s", a.GetNodeName(), b.GetNodeName())
+			}
+			c.partitionRules = append(c.partitionRules, rule)
+		}
+	}
+	return nil
+}
+
+// HealPartition removes all partitions created with PartitionAgents.
+func (c *Cluster) HealPartition() error {
+	for len(c.partitionRules) > 0 {
+		rule := c.partitionRules[0]
+		if err := rule.apply("-D"); err != nil {
+			return errors.Wrapf(err, "could not heal partition between agent %s and %s", rule.agent.GetNodeName(), rule.ip)
+		}
+		c.partitionRules = c.partitionRules[1:]
+	}
+	return nil
+}
+
+// apply inserts ("-I") or deletes ("-D") the rules.
+func (r partitionRule) apply(action string) error {
+	script := []string{"set -e"}
+	for _, cmd := range iptablesDropCommands(action, r.ip) {
+		script = append(script, strings.Join(cmd, " "))
+	}
+	return runInNetworkNamespace(r.agent, iptablesImage, strings.Join(script, "; "))
+}
+
+// iptablesDropCommands returns the iptables commands that insert or delete
+// the rules dropping the traffic to and from ip.
+func iptablesDropCommands(action, ip string) [][]string {
+	return [][]string{
+		{"iptables", action, "INPUT", "-s", ip, "-j", "DROP"},
+		{"iptables", action, "OUTPUT", "-d", ip, "-j", "DROP"},
+	}
+}
@@ -21,6 +21,19 @@ type ConnectContainer struct {
 	appPort   int
 	adminPort int
 	req       testcontainers.ContainerRequest
+
+	// serviceBindPort is the envoy listener port inside the container.
+	serviceBindPort int
+
+	// upstreamBindPorts and upstreamPorts map the name of an upstream to its
+	// envoy listener port inside the container and its mapped port.
+	upstreamBindPorts map[string]int
+	upstreamPorts     map[string]int
+
+	// node and serviceID identify the registration of the sidecar, which has
+	// the default sidecar ID of the service it proxies.
+	node      libnode.Agent
+	serviceID string
 }
 
 func (g ConnectContainer) GetName() string {
@@ -35,6 +48,31 @@ func (g ConnectContainer) GetAddr() (string, int) {
 	return g.ip, g.appPort
 }
 
+// GetContainerIP returns the IP of the sidecar container. Unlike the address
+// returned by GetAddr, it is used with the listener ports inside the
+// container, e.g. 19000 for the envoy admin API.
+func (g ConnectContainer) GetContainerIP() string {
+	return g.ip
+}
+
+// GetUpstreamAddr returns the address of the sidecar listener for the named
+// upstream.
+func (g ConnectContainer) GetUpstreamAddr(serviceName string) (string, int, error) {
+	port, ok := g.upstreamPorts[serviceName]
+	if !ok {
+		return "", 0, fmt.Errorf("no upstream %q", serviceName)
+	}
+	return "localhost", port, nil
+}
+
+func (g ConnectContainer) UpdateTags(tags []string) error {
+	return updateTags(g.node.GetClient(), g.serviceID, tags)
+}
+
+func (g ConnectContainer) UpdateMeta(meta map[string]string) error {
+	return updateMeta(g.node.GetClient(), g.serviceID, meta)
+}
+
 func (g ConnectContainer) Start() error {
 	if g.container == nil {
 		return fmt.Errorf("container has not been initialized")
@@ -46,6 +84,74 @@ func (g ConnectContainer) GetAdminAddr() (string, int) {
 	return "localhost", g.adminPort
 }
 
+// GetStatsAddr returns the address of the envoy stats endpoint, which is
+// served by the admin listener.
+func (g ConnectContainer) GetStatsAddr() (string, int) {
+	return "localhost", g.adminPort
+}
+
+// GetBootstrapConfig returns the bootstrap configuration the envoy sidecar
+// was started with, as JSON. Use GetEnvoyConfigDump for the dynamic
+// configuration.
+func (g ConnectContainer) GetBootstrapConfig() (string, error) {
+	return utils.GetEnvoyBootstrap(g.adminPort)
+}
+
+// RestartEnvoy stops and starts the envoy sidecar container, leaving the
+// application container running, and waits for envoy to report it is LIVE.
+// The mapped ports may change across the restart, so callers must fetch the
+// addresses again afterwards.
+func (g *ConnectContainer) RestartEnvoy() error {
+	if g.container == nil {
+		return fmt.Errorf("container has not been initialized")
+	}
+
+	ctx := context.Background()
+	timeout := 10 * time.Second
+	if err := g.container.Stop(ctx, &timeout); err != nil {
+		return fmt.Errorf("error stopping envoy: %w", err)
+	}
+	if err := g.container.Start(ctx); err != nil {
+		return fmt.Errorf("error starting envoy: %w", err)
+	}
+
+	mappedAppPort, err := g.container.MappedPort(ctx, nat.Port(fmt.Sprintf("%d", g.serviceBindPort)))
+	if err != nil {
+		return err
+	}
+	mappedAdminPort, err := g.container.MappedPort(ctx, nat.Port(fmt.Sprintf("%d", 19000)))
+	if err != nil {
+		return err
+	}
+	g.appPort = mappedAppPort.Int()
+	g.adminPort = mappedAdminPort.Int()
+
+	upstreamPorts, err := mapUpstreamPorts(ctx, g.container, g.upstreamBindPorts)
+	if err != nil {
+		return err
+	}
+	g.upstreamPorts = upstreamPorts
+
+	return waitForEnvoyLive(g.adminPort, 30*time.Second)
+}
+
+func waitForEnvoyLive(adminPort int, timeout time.Duration) error {
+	var (
+		state string
+		err   error
+	)
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(500 * time.Millisecond) {
+		state, err = utils.GetEnvoyReady(adminPort)
+		if err == nil && state == "LIVE" {
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("envoy did not become ready: %w", err)
+	}
+	return fmt.Errorf("envoy did not become ready: state %q", state)
+}
+
 // Terminate attempts to terminate the container. On failure, an error will be
 // returned and the reaper process (RYUK) will handle cleanup.
 func (c ConnectContainer) Terminate() error {
@@ -69,6 +175,12 @@ func (c ConnectContainer) Terminate() error {
 }
 
 func NewConnectService(ctx context.Context, name string, serviceName string, serviceBindPort int, node libnode.Agent) (*ConnectContainer, error) {
+	return newConnectService(ctx, name, serviceName, serviceBindPort, nil, node)
+}
+
+// newConnectService is like NewConnectService, but also exposes the listener
+// ports of the given upstreams, keyed by upstream name.
+func newConnectService(ctx context.Context, name string, serviceName string, serviceBindPort int, upstreamBindPorts map[string]int, node libnode.Agent) (*ConnectContainer, error) {
 	namePrefix := fmt.Sprintf("%s-service-connect-%s", node.GetDatacenter(), name)
 	containerName := utils.RandName(namePrefix)
 
@@ -104,6 +216,11 @@ func NewConnectService(ctx context.Context, name string, serviceName string, ser
 			"19000/tcp",                            // Envoy Admin Port
 		},
 	}
+	for _, port := range upstreamBindPorts {
+		if port != serviceBindPort {
+			req.ExposedPorts = append(req.ExposedPorts, fmt.Sprintf("%d/tcp", port))
+		}
+	}
 	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
 		ContainerRequest: req,
 		Started:          true,
@@ -125,6 +242,10 @@ func NewConnectService(ctx context.Context, name string, serviceName string, ser
 	if err != nil {
 		return nil, err
 	}
+	upstreamPorts, err := mapUpstreamPorts(ctx, container, upstreamBindPorts)
+	if err != nil {
+		return nil, err
+	}
 
 	if *utils.FollowLog {
 		if err := container.StartLogProducer(ctx); err != nil {
@@ -146,5 +267,24 @@ func NewConnectService(ctx context.Context, name string, serviceName string, ser
 		ip:        ip,
 		appPort:   mappedAppPort.Int(),
 		adminPort: mappedAdminPort.Int(),
+
+		serviceBindPort:   serviceBindPort,
+		upstreamBindPorts: upstreamBindPorts,
+		upstreamPorts:     upstreamPorts,
+
+		node:      node,
+		serviceID: serviceName + "-sidecar-proxy",
 	}, nil
 }
+
+func mapUpstreamPorts(ctx context.Context, container testcontainers.Container, bindPorts map[string]int) (map[string]int, error) {
+	ports := make(map[string]int, len(bindPorts))
+	for name, port := range bindPorts {
+		mapped, err := container.MappedPort(ctx, nat.Port(fmt.Sprintf("%d", port)))
+		if err != nil {
+			return nil, err
+		}
+		ports[name] = mapped.Int()
+	}
+	return ports, nil
+}
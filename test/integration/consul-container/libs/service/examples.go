@@ -21,6 +21,12 @@ type exampleContainer struct {
 	httpPort  int
 	grpcPort  int
 	req       testcontainers.ContainerRequest
+
+	// node and serviceID identify the registration of the service, which the
+	// helpers register with the agent using its name as ID. Services
+	// registered through the catalog can't be updated.
+	node      libnode.Agent
+	serviceID string
 }
 
 func (g exampleContainer) GetName() string {
@@ -35,6 +41,18 @@ func (g exampleContainer) GetAddr() (string, int) {
 	return g.ip, g.httpPort
 }
 
+func (g exampleContainer) GetContainerIP() string {
+	return g.ip
+}
+
+func (g exampleContainer) UpdateTags(tags []string) error {
+	return updateTags(g.node.GetClient(), g.serviceID, tags)
+}
+
+func (g exampleContainer) UpdateMeta(meta map[string]string) error {
+	return updateMeta(g.node.GetClient(), g.serviceID, meta)
+}
+
 func (g exampleContainer) Start() error {
 	if g.container == nil {
 		return fmt.Errorf("container has not been initialized")
@@ -73,7 +91,7 @@ func NewExampleService(ctx context.Context, name string, httpPort int, grpcPort
 		WaitingFor: wait.ForLog("").WithStartupTimeout(10 * time.Second),
 		AutoRemove: false,
 		Name:       containerName,
-		Cmd:        []string{"server", "-http-port", fmt.Sprintf("%d", httpPort), "-grpc-port", fmt.Sprintf("%d", grpcPort), "-redirect-port", "-disabled"},
+		Cmd:        []string{"server", "-http-port", fmt.Sprintf("%d", httpPort), "-grpc-port", fmt.Sprintf("%d", grpcPort), "-tcp-port", "8078", "-redirect-port", "-disabled"},
 		Env:        map[string]string{"FORTIO_NAME": name},
 		ExposedPorts: []string{
 			fmt.Sprintf("%d/tcp", httpPort), // HTTP Listener
@@ -115,5 +133,12 @@ func NewExampleService(ctx context.Context, name string, httpPort int, grpcPort
 	}
 	node.RegisterTermination(terminate)
 
-	return &exampleContainer{container: container, ip: ip, httpPort: mappedHTPPPort.Int(), grpcPort: mappedGRPCPort.Int()}, nil
+	return &exampleContainer{
+		container: container,
+		ip:        ip,
+		httpPort:  mappedHTPPPort.Int(),
+		grpcPort:  mappedGRPCPort.Int(),
+		node:      node,
+		serviceID: name,
+	}, nil
 }
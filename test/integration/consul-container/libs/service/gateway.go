@@ -19,6 +19,11 @@ type gatewayContainer struct {
 	ip        string
 	port      int
 	req       testcontainers.ContainerRequest
+
+	// node and serviceID identify the registration of the gateway, which is
+	// registered by consul connect envoy with its service name as ID.
+	node      libnode.Agent
+	serviceID string
 }
 
 func (g gatewayContainer) GetName() string {
@@ -33,6 +38,18 @@ func (g gatewayContainer) GetAddr() (string, int) {
 	return g.ip, g.port
 }
 
+func (g gatewayContainer) GetContainerIP() string {
+	return g.ip
+}
+
+func (g gatewayContainer) UpdateTags(tags []string) error {
+	return updateTags(g.node.GetClient(), g.serviceID, tags)
+}
+
+func (g gatewayContainer) UpdateMeta(meta map[string]string) error {
+	return updateMeta(g.node.GetClient(), g.serviceID, meta)
+}
+
 func (g gatewayContainer) Start() error {
 	if g.container == nil {
 		return fmt.Errorf("container has not been initialized")
@@ -130,5 +147,5 @@ func NewGatewayService(ctx context.Context, name string, kind string, node libno
 	}
 	node.RegisterTermination(terminate)
 
-	return &gatewayContainer{container: container, ip: ip, port: mappedPort.Int()}, nil
+	return &gatewayContainer{container: container, ip: ip, port: mappedPort.Int(), node: node, serviceID: name}, nil
 }
@@ -2,37 +2,111 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	libnode "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
 )
 
 func CreateAndRegisterStaticServerAndSidecar(node libnode.Agent) (Service, Service, error) {
+	return createAndRegisterStaticServerAndSidecar(node, "static-server", 8080, ServerOptions{})
+}
+
+// ServerOptions configures the static-server sidecar created by
+// CreateAndRegisterStaticServerAndSidecarWithOptions.
+type ServerOptions struct {
+	// FaultDelay delays every HTTP request to static-server by the given
+	// duration using the envoy fault filter. Zero disables the delay.
+	FaultDelay time.Duration
+
+	// FaultAbortPercent is the percentage of HTTP requests to static-server
+	// that the envoy fault filter aborts with a 503. Zero disables aborts.
+	FaultAbortPercent int
+}
+
+// CreateAndRegisterStaticServerAndSidecarWithOptions is like
+// CreateAndRegisterStaticServerAndSidecar, but the sidecar injects the faults
+// of opts into the HTTP requests to static-server.
+func CreateAndRegisterStaticServerAndSidecarWithOptions(node libnode.Agent, opts ServerOptions) (Service, Service, error) {
+	return createAndRegisterStaticServerAndSidecar(node, "static-server", 8080, opts)
+}
+
+// RegisterStaticServerSidecar registers the static-server service and its
+// sidecar created by CreateAndRegisterStaticServerAndSidecarWithOptions again,
+// which replaces the previous registration and allows changing the faults of
+// the sidecar.
+func RegisterStaticServerSidecar(node libnode.Agent, server, sidecar Service, opts ServerOptions) error {
+	return registerStaticServerAndSidecar(node, "static-server", 8080, server, sidecar, opts)
+}
+
+// CreateAndRegisterGRPCStaticServerAndSidecar is like
+// CreateAndRegisterStaticServerAndSidecar, but registers the gRPC port of the
+// static-server. The static-server exposes a gRPC health server with
+// reflection enabled. A service-defaults config entry setting the protocol of
+// static-server to grpc is required for traffic to be routed as gRPC.
+func CreateAndRegisterGRPCStaticServerAndSidecar(node libnode.Agent) (Service, Service, error) {
+	return createAndRegisterStaticServerAndSidecar(node, "static-server", 8079, ServerOptions{})
+}
+
+// CreateAndRegisterTCPStaticServerAndSidecar is like
+// CreateAndRegisterStaticServerAndSidecar, but registers the TCP echo port of
+// the static-server, which writes back every byte it receives.
+func CreateAndRegisterTCPStaticServerAndSidecar(node libnode.Agent) (Service, Service, error) {
+	return createAndRegisterStaticServerAndSidecar(node, "static-server", 8078, ServerOptions{})
+}
+
+// CreateAndRegisterNamedStaticServerAndSidecar is like
+// CreateAndRegisterStaticServerAndSidecar, but registers the service under the
+// given name so several static servers can run side by side.
+func CreateAndRegisterNamedStaticServerAndSidecar(node libnode.Agent, name string) (Service, Service, error) {
+	return createAndRegisterStaticServerAndSidecar(node, name, 8080, ServerOptions{})
+}
+
+func createAndRegisterStaticServerAndSidecar(node libnode.Agent, name string, servicePort int, opts ServerOptions) (Service, Service, error) {
 	// Create a service and proxy instance
-	serverService, err := NewExampleService(context.Background(), "static-server", 8080, 8079, node)
+	serverService, err := NewExampleService(context.Background(), name, 8080, 8079, node)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	serverConnectProxy, err := NewConnectService(context.Background(), "static-server-sidecar", "static-server", 8080, node) // bindPort not used
+	serverConnectProxy, err := NewConnectService(context.Background(), name+"-sidecar", name, servicePort, node) // bindPort not used
 	if err != nil {
 		return nil, nil, err
 	}
 
-	serverServiceIP, _ := serverService.GetAddr()
-	serverConnectProxyIP, _ := serverConnectProxy.GetAddr()
+	err = registerStaticServerAndSidecar(node, name, servicePort, serverService, serverConnectProxy, opts)
+	if err != nil {
+		return serverService, serverConnectProxy, err
+	}
+
+	return serverService, serverConnectProxy, nil
+}
 
-	// Register the static-server service and sidecar
+func registerStaticServerAndSidecar(node libnode.Agent, name string, servicePort int, serverService, serverConnectProxy Service, opts ServerOptions) error {
+	proxyConfig, err := faultProxyConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	serverServiceIP := serverService.GetContainerIP()
+	serverConnectProxyIP := serverConnectProxy.GetContainerIP()
+
+	// Register the service and sidecar
 	req := &api.AgentServiceRegistration{
-		Name:    "static-server",
-		Port:    8080,
+		Name:    name,
+		Port:    servicePort,
 		Address: serverServiceIP,
 		Connect: &api.AgentServiceConnect{
 			SidecarService: &api.AgentServiceRegistration{
-				Name:    "static-server-sidecar-proxy",
+				Name:    name + "-sidecar-proxy",
 				Port:    20000,
 				Address: serverConnectProxyIP,
 				Kind:    api.ServiceKindConnectProxy,
@@ -45,46 +119,136 @@ func CreateAndRegisterStaticServerAndSidecar(node libnode.Agent) (Service, Servi
 					},
 					&api.AgentServiceCheck{
 						Name:         "Connect Sidecar Aliasing Static Server",
-						AliasService: "static-server",
+						AliasService: name,
 						Status:       api.HealthPassing,
 					},
 				},
 				Proxy: &api.AgentServiceConnectProxyConfig{
-					DestinationServiceName: "static-server",
+					DestinationServiceName: name,
 					LocalServiceAddress:    serverServiceIP,
-					LocalServicePort:       8080,
+					LocalServicePort:       servicePort,
+					Config:                 proxyConfig,
 				},
 			},
 		},
 		Check: &api.AgentServiceCheck{
 			Name:     "Static Server Listening",
-			TCP:      fmt.Sprintf("%s:%d", serverServiceIP, 8080),
+			TCP:      fmt.Sprintf("%s:%d", serverServiceIP, servicePort),
 			Interval: "10s",
 			Status:   api.HealthPassing,
 		},
 	}
 
-	err = node.GetClient().Agent().ServiceRegister(req)
+	return node.GetClient().Agent().ServiceRegister(req)
+}
+
+// CreateAndRegisterStaticServer creates a static-server service without a
+// sidecar and registers it in the catalog in the given admin partition. An
+// empty partition registers the service in the default partition.
+func CreateAndRegisterStaticServer(node libnode.Agent, partition string) (Service, error) {
+	serverService, err := NewExampleService(context.Background(), "static-server", 8080, 8079, node)
 	if err != nil {
-		return serverService, serverConnectProxy, err
+		return nil, err
 	}
 
-	return serverService, serverConnectProxy, nil
+	serverServiceIP, _ := serverService.GetAddr()
+
+	// The service is registered through the catalog rather than the agent
+	// since agents can only register services in their own partition.
+	req := &api.CatalogRegistration{
+		Node:      "static-server-node",
+		Address:   serverServiceIP,
+		Partition: partition,
+		Service: &api.AgentService{
+			Service:   "static-server",
+			Port:      8080,
+			Address:   serverServiceIP,
+			Partition: partition,
+		},
+	}
+
+	_, err = node.GetClient().Catalog().Register(req, nil)
+	if err != nil {
+		return serverService, err
+	}
+
+	return serverService, nil
+}
+
+// SidecarOptions configures the static-client sidecar created by
+// CreateAndRegisterStaticClientSidecarWithOptions.
+type SidecarOptions struct {
+	// PeerName is the peer the static-server upstream is imported from. An
+	// empty PeerName targets the local static-server.
+	PeerName string
+
+	// LocalMeshGateway sets the mesh gateway mode of the upstream to local
+	// instead of remote.
+	LocalMeshGateway bool
+
+	// ConnectTimeout sets connect_timeout_ms on the upstream. Zero leaves the
+	// envoy default in place.
+	ConnectTimeout time.Duration
+
+	// Upstreams replaces the static-server upstream with the given upstreams.
+	// PeerName is ignored for these, each upstream sets its own peer.
+	Upstreams []Upstream
+}
+
+// Upstream is an upstream of the static-client sidecar.
+type Upstream struct {
+	// ServiceName is the name of the upstream service.
+	ServiceName string
+
+	// LocalBindPort is the port the sidecar listens on for the upstream.
+	LocalBindPort int
+
+	// Peer is the peer the upstream is imported from. An empty Peer targets
+	// a local service.
+	Peer string
+
+	// Protocol declares the protocol of the upstream, such as "http",
+	// "http2" or "grpc", in the proxy upstream config so that envoy uses the
+	// matching codec. An empty Protocol leaves the protocol to the
+	// service-defaults of the upstream, or tcp.
+	Protocol string
 }
 
 func CreateAndRegisterStaticClientSidecar(node libnode.Agent, peerName string, localMeshGateway bool) (*ConnectContainer, error) {
+	return CreateAndRegisterStaticClientSidecarWithOptions(node, SidecarOptions{
+		PeerName:         peerName,
+		LocalMeshGateway: localMeshGateway,
+	})
+}
+
+// CreateAndRegisterStaticClientSidecarWithOptions creates a static-client
+// sidecar and registers it with Consul. Unless opts.Upstreams is set, the
+// sidecar has static-server as an upstream bound to port 5000. GetAddr returns
+// the address of the first upstream, use GetUpstreamAddr for the others.
+func CreateAndRegisterStaticClientSidecarWithOptions(node libnode.Agent, opts SidecarOptions) (*ConnectContainer, error) {
+	upstreams := opts.Upstreams
+	if len(upstreams) == 0 {
+		upstreams = []Upstream{{
+			ServiceName:   "static-server",
+			LocalBindPort: 5000,
+			Peer:          opts.PeerName,
+		}}
+	}
+
+	bindPorts := make(map[string]int, len(upstreams))
+	proxyUpstreams := make([]api.Upstream, 0, len(upstreams))
+	for _, u := range upstreams {
+		bindPorts[u.ServiceName] = u.LocalBindPort
+		proxyUpstreams = append(proxyUpstreams, makeUpstream(u, opts))
+	}
+
 	// Create a service and proxy instance
-	clientConnectProxy, err := NewConnectService(context.Background(), "static-client-sidecar", "static-client", 5000, node)
+	clientConnectProxy, err := newConnectService(context.Background(), "static-client-sidecar", "static-client", upstreams[0].LocalBindPort, bindPorts, node)
 	if err != nil {
 		return nil, err
 	}
 
-	clientConnectProxyIP, _ := clientConnectProxy.GetAddr()
-
-	mgwMode := api.MeshGatewayModeRemote
-	if localMeshGateway {
-		mgwMode = api.MeshGatewayModeLocal
-	}
+	clientConnectProxyIP := clientConnectProxy.GetContainerIP()
 
 	// Register the static-client service and sidecar
 	req := &api.AgentServiceRegistration{
@@ -104,17 +268,7 @@ func CreateAndRegisterStaticClientSidecar(node libnode.Agent, peerName string, l
 					},
 				},
 				Proxy: &api.AgentServiceConnectProxyConfig{
-					Upstreams: []api.Upstream{
-						{
-							DestinationName:  "static-server",
-							DestinationPeer:  peerName,
-							LocalBindAddress: "0.0.0.0",
-							LocalBindPort:    5000,
-							MeshGateway: api.MeshGatewayConfig{
-								Mode: mgwMode,
-							},
-						},
-					},
+					Upstreams: proxyUpstreams,
 				},
 			},
 		},
@@ -129,20 +283,169 @@ func CreateAndRegisterStaticClientSidecar(node libnode.Agent, peerName string, l
 	return clientConnectProxy, nil
 }
 
+// faultProxyConfig returns the proxy config of a static-server sidecar
+// injecting the faults of opts, or nil if no faults are configured. The fault
+// filter is configured through the envoy_public_listener_json escape hatch,
+// Consul still adds the intentions and TLS to the listener.
+func faultProxyConfig(opts ServerOptions) (map[string]interface{}, error) {
+	if opts.FaultDelay <= 0 && opts.FaultAbortPercent <= 0 {
+		return nil, nil
+	}
+
+	fault := map[string]interface{}{
+		"@type": "type.googleapis.com/envoy.extensions.filters.http.fault.v3.HTTPFault",
+	}
+	if opts.FaultDelay > 0 {
+		fault["delay"] = map[string]interface{}{
+			"fixed_delay": fmt.Sprintf("%.3fs", opts.FaultDelay.Seconds()),
+			"percentage":  map[string]interface{}{"numerator": 100},
+		}
+	}
+	if opts.FaultAbortPercent > 0 {
+		fault["abort"] = map[string]interface{}{
+			"http_status": http.StatusServiceUnavailable,
+			"percentage":  map[string]interface{}{"numerator": opts.FaultAbortPercent},
+		}
+	}
+
+	listener := map[string]interface{}{
+		"@type": "type.googleapis.com/envoy.config.listener.v3.Listener",
+		"name":  "public_listener:0.0.0.0:20000",
+		"address": map[string]interface{}{
+			"socket_address": map[string]interface{}{
+				"address":    "0.0.0.0",
+				"port_value": 20000,
+			},
+		},
+		"filter_chains": []interface{}{
+			map[string]interface{}{
+				"filters": []interface{}{
+					map[string]interface{}{
+						"name": "envoy.filters.network.http_connection_manager",
+						"typed_config": map[string]interface{}{
+							"@type":       "type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager",
+							"stat_prefix": "public_listener",
+							"http_filters": []interface{}{
+								map[string]interface{}{
+									"name":         "envoy.filters.http.fault",
+									"typed_config": fault,
+								},
+								map[string]interface{}{
+									"name": "envoy.filters.http.router",
+									"typed_config": map[string]interface{}{
+										"@type": "type.googleapis.com/envoy.extensions.filters.http.router.v3.Router",
+									},
+								},
+							},
+							"route_config": map[string]interface{}{
+								"name": "public_listener",
+								"virtual_hosts": []interface{}{
+									map[string]interface{}{
+										"name":    "public_listener",
+										"domains": []string{"*"},
+										"routes": []interface{}{
+											map[string]interface{}{
+												"match": map[string]interface{}{"prefix": "/"},
+												"route": map[string]interface{}{"cluster": "local_app"},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	listenerJSON, err := json.Marshal(listener)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"protocol":                   "http",
+		"envoy_public_listener_json": string(listenerJSON),
+	}, nil
+}
+
+func staticServerUpstream(opts SidecarOptions) api.Upstream {
+	return makeUpstream(Upstream{
+		ServiceName:   "static-server",
+		LocalBindPort: 5000,
+		Peer:          opts.PeerName,
+	}, opts)
+}
+
+func makeUpstream(u Upstream, opts SidecarOptions) api.Upstream {
+	mgwMode := api.MeshGatewayModeRemote
+	if opts.LocalMeshGateway {
+		mgwMode = api.MeshGatewayModeLocal
+	}
+
+	upstream := api.Upstream{
+		DestinationName:  u.ServiceName,
+		DestinationPeer:  u.Peer,
+		LocalBindAddress: "0.0.0.0",
+		LocalBindPort:    u.LocalBindPort,
+		MeshGateway: api.MeshGatewayConfig{
+			Mode: mgwMode,
+		},
+	}
+
+	config := map[string]interface{}{}
+	if opts.ConnectTimeout > 0 {
+		config["connect_timeout_ms"] = opts.ConnectTimeout.Milliseconds()
+	}
+	if u.Protocol != "" {
+		config["protocol"] = u.Protocol
+	}
+	if len(config) > 0 {
+		upstream.Config = config
+	}
+
+	return upstream
+}
+
+// GetEnvoyConfigDump returns the envoy config dump, including endpoints, from
+// the admin endpoint on the given port.
 func GetEnvoyConfigDump(port int) (string, error) {
+	return utils.GetEnvoyConfigDump(port)
+}
+
+// GetEnvoyStats returns the envoy counters and gauges whose names match the
+// given regular expression, keyed by stat name. Histograms are skipped.
+func GetEnvoyStats(port int, filter string) (map[string]int, error) {
 	client := http.DefaultClient
-	url := fmt.Sprintf("http://localhost:%d/config_dump?include_eds", port)
+	url := fmt.Sprintf("http://localhost:%d/stats?filter=%s", port, neturl.QueryEscape(filter))
 
 	res, err := client.Get(url)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer res.Body.Close()
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return string(body), nil
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d getting envoy stats: %s", res.StatusCode, body)
+	}
+
+	stats := make(map[string]int)
+	for _, line := range strings.Split(string(body), "\n") {
+		name, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			// Histograms are reported as a list of quantiles.
+			continue
+		}
+		stats[name] = n
+	}
+	return stats, nil
 }
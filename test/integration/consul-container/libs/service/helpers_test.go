@@ -0,0 +1,165 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+)
+
+func TestGetEnvoyStats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/stats", r.URL.Path)
+		require.Equal(t, "upstream_rq", r.URL.Query().Get("filter"))
+		fmt.Fprint(w, `cluster.static-server.default.dc1.internal.foo.consul.upstream_rq_completed: 3
+cluster.static-server.default.dc1.internal.foo.consul.upstream_rq_active: 0
+cluster.static-server.default.dc1.internal.foo.consul.upstream_rq_time: P0(nan,1.0) P25(nan,1.025)
+`)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	stats, err := GetEnvoyStats(port, "upstream_rq")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{
+		"cluster.static-server.default.dc1.internal.foo.consul.upstream_rq_completed": 3,
+		"cluster.static-server.default.dc1.internal.foo.consul.upstream_rq_active":    0,
+	}, stats)
+}
+
+func TestStaticServerUpstream(t *testing.T) {
+	upstream := staticServerUpstream(SidecarOptions{})
+	require.Equal(t, "static-server", upstream.DestinationName)
+	require.Equal(t, api.MeshGatewayModeRemote, upstream.MeshGateway.Mode)
+	require.Nil(t, upstream.Config)
+
+	upstream = staticServerUpstream(SidecarOptions{
+		PeerName:         "peer1",
+		LocalMeshGateway: true,
+		ConnectTimeout:   250 * time.Millisecond,
+	})
+	require.Equal(t, "peer1", upstream.DestinationPeer)
+	require.Equal(t, api.MeshGatewayModeLocal, upstream.MeshGateway.Mode)
+	require.Equal(t, map[string]interface{}{"connect_timeout_ms": int64(250)}, upstream.Config)
+}
+
+func TestMakeUpstream(t *testing.T) {
+	upstream := makeUpstream(Upstream{
+		ServiceName:   "static-server-2",
+		LocalBindPort: 5001,
+		Peer:          "peer1",
+	}, SidecarOptions{LocalMeshGateway: true})
+
+	require.Equal(t, "static-server-2", upstream.DestinationName)
+	require.Equal(t, "peer1", upstream.DestinationPeer)
+	require.Equal(t, "0.0.0.0", upstream.LocalBindAddress)
+	require.Equal(t, 5001, upstream.LocalBindPort)
+	require.Equal(t, api.MeshGatewayModeLocal, upstream.MeshGateway.Mode)
+	require.Nil(t, upstream.Config)
+
+	upstream = makeUpstream(Upstream{
+		ServiceName:   "static-server",
+		LocalBindPort: 5000,
+		Protocol:      "grpc",
+	}, SidecarOptions{ConnectTimeout: time.Second})
+	require.Equal(t, map[string]interface{}{
+		"connect_timeout_ms": int64(1000),
+		"protocol":           "grpc",
+	}, upstream.Config)
+}
+
+func TestFaultProxyConfig(t *testing.T) {
+	config, err := faultProxyConfig(ServerOptions{})
+	require.NoError(t, err)
+	require.Nil(t, config)
+
+	config, err = faultProxyConfig(ServerOptions{
+		FaultDelay:        1500 * time.Millisecond,
+		FaultAbortPercent: 100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "http", config["protocol"])
+
+	var listener struct {
+		FilterChains []struct {
+			Filters []struct {
+				TypedConfig struct {
+					HTTPFilters []struct {
+						Name        string                 `json:"name"`
+						TypedConfig map[string]interface{} `json:"typed_config"`
+					} `json:"http_filters"`
+				} `json:"typed_config"`
+			} `json:"filters"`
+		} `json:"filter_chains"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(config["envoy_public_listener_json"].(string)), &listener))
+
+	httpFilters := listener.FilterChains[0].Filters[0].TypedConfig.HTTPFilters
+	require.Len(t, httpFilters, 2)
+	require.Equal(t, "envoy.filters.http.fault", httpFilters[0].Name)
+	require.Equal(t, "envoy.filters.http.router", httpFilters[1].Name)
+	require.Equal(t, map[string]interface{}{
+		"@type": "type.googleapis.com/envoy.extensions.filters.http.fault.v3.HTTPFault",
+		"delay": map[string]interface{}{
+			"fixed_delay": "1.500s",
+			"percentage":  map[string]interface{}{"numerator": float64(100)},
+		},
+		"abort": map[string]interface{}{
+			"http_status": float64(503),
+			"percentage":  map[string]interface{}{"numerator": float64(100)},
+		},
+	}, httpFilters[0].TypedConfig)
+}
+
+func TestUpdateRegistration(t *testing.T) {
+	var registered api.AgentServiceRegistration
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/agent/service/static-server":
+			fmt.Fprint(w, `{
+				"ID": "static-server",
+				"Service": "static-server",
+				"Tags": ["v1"],
+				"Meta": {"version": "1"},
+				"Port": 8080,
+				"Address": "10.0.0.1",
+				"Weights": {"Passing": 1, "Warning": 1}
+			}`)
+		case "/v1/agent/service/register":
+			require.Equal(t, http.MethodPut, r.Method)
+			// Re-registering must not drop the checks of the service.
+			require.Empty(t, r.URL.Query().Get("replace-existing-checks"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&registered))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(&api.Config{Address: srv.URL})
+	require.NoError(t, err)
+
+	require.NoError(t, updateTags(client, "static-server", []string{"v2"}))
+	require.Equal(t, "static-server", registered.ID)
+	require.Equal(t, "static-server", registered.Name)
+	require.Equal(t, []string{"v2"}, registered.Tags)
+	require.Equal(t, map[string]string{"version": "1"}, registered.Meta)
+	require.Equal(t, 8080, registered.Port)
+	require.Equal(t, "10.0.0.1", registered.Address)
+
+	require.NoError(t, updateMeta(client, "static-server", map[string]string{"version": "2"}))
+	require.Equal(t, []string{"v1"}, registered.Tags)
+	require.Equal(t, map[string]string{"version": "2"}, registered.Meta)
+}
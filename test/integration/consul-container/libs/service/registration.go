@@ -0,0 +1,53 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// updateRegistration re-registers the service with the given ID through the
+// agent API, after applying update to its current registration. The checks of
+// the service, and its sidecar if any, are left as they are.
+func updateRegistration(client *api.Client, serviceID string, update func(*api.AgentServiceRegistration)) error {
+	svc, _, err := client.Agent().Service(serviceID, nil)
+	if err != nil {
+		return fmt.Errorf("could not read service %q: %w", serviceID, err)
+	}
+
+	reg := &api.AgentServiceRegistration{
+		Kind:              svc.Kind,
+		ID:                svc.ID,
+		Name:              svc.Service,
+		Tags:              svc.Tags,
+		Port:              svc.Port,
+		Address:           svc.Address,
+		SocketPath:        svc.SocketPath,
+		TaggedAddresses:   svc.TaggedAddresses,
+		EnableTagOverride: svc.EnableTagOverride,
+		Meta:              svc.Meta,
+		Weights:           &svc.Weights,
+		Proxy:             svc.Proxy,
+		Connect:           svc.Connect,
+		Namespace:         svc.Namespace,
+		Partition:         svc.Partition,
+	}
+	update(reg)
+
+	if err := client.Agent().ServiceRegister(reg); err != nil {
+		return fmt.Errorf("could not register service %q: %w", serviceID, err)
+	}
+	return nil
+}
+
+func updateTags(client *api.Client, serviceID string, tags []string) error {
+	return updateRegistration(client, serviceID, func(reg *api.AgentServiceRegistration) {
+		reg.Tags = tags
+	})
+}
+
+func updateMeta(client *api.Client, serviceID string, meta map[string]string) error {
+	return updateRegistration(client, serviceID, func(reg *api.AgentServiceRegistration) {
+		reg.Meta = meta
+	})
+}
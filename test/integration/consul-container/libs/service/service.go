@@ -6,5 +6,15 @@ type Service interface {
 	Terminate() error
 	GetName() string
 	GetAddr() (string, int)
+	// GetContainerIP returns the IP of the container on its primary docker
+	// network. Other containers on that network reach the service on it
+	// without going through the ports mapped on the host.
+	GetContainerIP() string
 	Start() (err error)
+	// UpdateTags re-registers the service with the given tags, replacing the
+	// ones it was registered with.
+	UpdateTags(tags []string) error
+	// UpdateMeta re-registers the service with the given metadata, replacing
+	// the metadata it was registered with.
+	UpdateMeta(meta map[string]string) error
 }
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	libnode "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TCPForwarder is a container passing TCP connections through to a target
+// address, without terminating or inspecting them. It stands in for a legacy
+// proxy in front of a service. The forwarder isn't registered with Consul.
+type TCPForwarder struct {
+	ctx       context.Context
+	container testcontainers.Container
+	name      string
+	target    string
+	port      int
+
+	ip       string
+	hostPort int
+}
+
+// NewTCPForwarder starts a container listening on the given port and
+// forwarding every connection to target, a host:port address reachable from
+// the container, such as the container IP and port of another service. The
+// forwarder is terminated along with the given agent.
+func NewTCPForwarder(ctx context.Context, name string, port int, target string, node libnode.Agent) (*TCPForwarder, error) {
+	namePrefix := fmt.Sprintf("%s-tcp-forwarder-%s", node.GetDatacenter(), name)
+	containerName := utils.RandName(namePrefix)
+
+	listenPort := nat.Port(fmt.Sprintf("%d/tcp", port))
+	req := testcontainers.ContainerRequest{
+		Image:      hashicorpDockerProxy + "/alpine/socat",
+		WaitingFor: wait.ForListeningPort(listenPort).WithStartupTimeout(10 * time.Second),
+		AutoRemove: false,
+		Name:       containerName,
+		Cmd: []string{
+			fmt.Sprintf("TCP-LISTEN:%d,fork,reuseaddr", port),
+			"TCP-CONNECT:" + target,
+		},
+		ExposedPorts: []string{string(listenPort)},
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if *utils.FollowLog {
+		if err := container.StartLogProducer(ctx); err != nil {
+			return nil, err
+		}
+		container.FollowOutput(&LogConsumer{
+			Prefix: containerName,
+		})
+	}
+
+	terminate := func() error {
+		return container.Terminate(context.Background())
+	}
+	node.RegisterTermination(terminate)
+
+	f := &TCPForwarder{
+		ctx:       ctx,
+		container: container,
+		name:      containerName,
+		target:    target,
+		port:      port,
+	}
+	if err := f.refreshAddr(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// refreshAddr reads the container IP and the host port mapped to the listener,
+// which docker may both change when the container is restarted.
+func (f *TCPForwarder) refreshAddr() error {
+	ip, err := f.container.ContainerIP(f.ctx)
+	if err != nil {
+		return err
+	}
+	mappedPort, err := f.container.MappedPort(f.ctx, nat.Port(fmt.Sprintf("%d", f.port)))
+	if err != nil {
+		return err
+	}
+	f.ip = ip
+	f.hostPort = mappedPort.Int()
+	return nil
+}
+
+func (f *TCPForwarder) GetName() string {
+	return f.name
+}
+
+// GetAddr returns the container IP of the forwarder and the port its listener
+// is mapped to on the host.
+func (f *TCPForwarder) GetAddr() (string, int) {
+	return f.ip, f.hostPort
+}
+
+// GetContainerAddr returns the address other containers on the docker network
+// reach the listener of the forwarder on.
+func (f *TCPForwarder) GetContainerAddr() string {
+	return fmt.Sprintf("%s:%d", f.ip, f.port)
+}
+
+// GetTarget returns the address connections are forwarded to.
+func (f *TCPForwarder) GetTarget() string {
+	return f.target
+}
+
+// Stop stops the container, so connections to the forwarder are refused until
+// it is started again.
+func (f *TCPForwarder) Stop() error {
+	if f.container == nil {
+		return fmt.Errorf("container has not been initialized")
+	}
+	return f.container.Stop(f.ctx, nil)
+}
+
+// Start starts the container again after Stop and waits for the listener. The
+// addresses of the forwarder may change across a restart.
+func (f *TCPForwarder) Start() error {
+	if f.container == nil {
+		return fmt.Errorf("container has not been initialized")
+	}
+	if err := f.container.Start(f.ctx); err != nil {
+		return err
+	}
+	return f.refreshAddr()
+}
+
+// Terminate attempts to terminate the container. On failure, an error will be
+// returned and the reaper process (RYUK) will handle cleanup.
+func (f *TCPForwarder) Terminate() error {
+	if f.container == nil {
+		return nil
+	}
+
+	var err error
+	if *utils.FollowLog {
+		err = f.container.StopLogProducer()
+		if err1 := f.container.Terminate(f.ctx); err == nil {
+			err = err1
+		}
+	} else {
+		err = f.container.Terminate(f.ctx)
+	}
+
+	f.container = nil
+
+	return err
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+)
+
+// GetEnvoyConfigDump returns the envoy config dump, including endpoints, from
+// the admin endpoint on the given port.
+func GetEnvoyConfigDump(port int) (string, error) {
+	client := http.DefaultClient
+	url := fmt.Sprintf("http://localhost:%d/config_dump?include_eds", port)
+
+	res, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// GetEnvoyBootstrap returns the bootstrap configuration envoy was started
+// with, as JSON, from the admin endpoint on the given port.
+func GetEnvoyBootstrap(port int) (string, error) {
+	dump, err := GetEnvoyConfigDump(port)
+	if err != nil {
+		return "", err
+	}
+	return extractEnvoyBootstrap(dump)
+}
+
+// extractEnvoyBootstrap returns the bootstrap configuration from an envoy
+// config dump.
+func extractEnvoyBootstrap(dump string) (string, error) {
+	results, err := JQFilterTyped(dump, `.configs[] | select(."@type" == "type.googleapis.com/envoy.admin.v3.BootstrapConfigDump") | .bootstrap`)
+	if err != nil {
+		return "", fmt.Errorf("could not parse envoy config dump: %w", err)
+	}
+	if len(results) != 1 {
+		return "", fmt.Errorf("expected 1 bootstrap config in the envoy config dump, got %d", len(results))
+	}
+
+	bootstrap, err := json.Marshal(results[0])
+	if err != nil {
+		return "", err
+	}
+	return string(bootstrap), nil
+}
+
+// GetEnvoyClusters returns the text output of the envoy /clusters admin
+// endpoint on the given port.
+func GetEnvoyClusters(port int) (string, error) {
+	client := http.DefaultClient
+	url := fmt.Sprintf("http://localhost:%d/clusters", port)
+
+	res, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// GetEnvoyReady returns the server state reported by the envoy /ready admin
+// endpoint on the given port, e.g. "LIVE".
+func GetEnvoyReady(port int) (string, error) {
+	client := http.DefaultClient
+	url := fmt.Sprintf("http://localhost:%d/ready", port)
+
+	res, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
+// RetryEnvoyConfigDump polls the envoy config dump on the given admin port and
+// runs the jq filter over it until predicate returns true for the results or
+// the timeout elapses. The results that satisfied the predicate are returned.
+// On timeout the test fails, reporting the last results seen.
+func RetryEnvoyConfigDump(t *testing.T, adminPort int, filter string, predicate func([]string) bool, timeout time.Duration) []string {
+	t.Helper()
+
+	failer := func() *retry.Timer {
+		return &retry.Timer{Timeout: timeout, Wait: 1 * time.Second}
+	}
+
+	var results []string
+	retry.RunWith(failer(), t, func(r *retry.R) {
+		dump, err := GetEnvoyConfigDump(adminPort)
+		if err != nil {
+			r.Fatal("could not curl envoy configuration: ", err)
+		}
+
+		results, err = JQFilter(dump, filter)
+		if err != nil {
+			r.Fatal("could not parse envoy configuration: ", err)
+		}
+
+		if !predicate(results) {
+			r.Fatalf("envoy configuration did not match the predicate, last results: %q", results)
+		}
+	})
+	return results
+}
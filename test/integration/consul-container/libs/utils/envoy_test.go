@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryEnvoyConfigDump(t *testing.T) {
+	// The number of clusters in the dump grows with every request.
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/config_dump", r.URL.Path)
+		n := atomic.AddInt32(&calls, 1)
+		fmt.Fprintf(w, `{"clusters": %d}`, n)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	results := RetryEnvoyConfigDump(t, port, ".clusters", func(results []string) bool {
+		return len(results) == 1 && results[0] == "2"
+	}, 10*time.Second)
+	require.Equal(t, []string{"2"}, results)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestExtractEnvoyBootstrap(t *testing.T) {
+	dump := `{
+  "configs": [
+    {
+      "@type": "type.googleapis.com/envoy.admin.v3.BootstrapConfigDump",
+      "bootstrap": {
+        "node": {"id": "static-client-sidecar-proxy", "cluster": "static-client"},
+        "admin": {"address": {"socket_address": {"address": "0.0.0.0", "port_value": 19000}}}
+      }
+    },
+    {
+      "@type": "type.googleapis.com/envoy.admin.v3.ClustersConfigDump"
+    }
+  ]
+}`
+
+	bootstrap, err := extractEnvoyBootstrap(dump)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "node": {"id": "static-client-sidecar-proxy", "cluster": "static-client"},
+  "admin": {"address": {"socket_address": {"address": "0.0.0.0", "port_value": 19000}}}
+}`, bootstrap)
+
+	_, err = extractEnvoyBootstrap(`{"configs": []}`)
+	require.Error(t, err)
+}
@@ -20,7 +20,24 @@ func RandName(name string) string {
 // JQFilter uses the provided "jq" filter to parse json.
 // Matching results are returned as a slice of strings.
 func JQFilter(config, filter string) ([]string, error) {
+	values, err := JQFilterTyped(config, filter)
+	if err != nil {
+		return nil, err
+	}
+
 	result := []string{}
+	for _, v := range values {
+		s := fmt.Sprintf("%v", v)
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// JQFilterTyped uses the provided "jq" filter to parse json.
+// Matching results are returned as decoded JSON values: numbers are float64,
+// objects are map[string]interface{}, and arrays are []interface{}.
+func JQFilterTyped(config, filter string) ([]interface{}, error) {
+	result := []interface{}{}
 	query, err := gojq.Parse(filter)
 	if err != nil {
 		return nil, err
@@ -41,8 +58,7 @@ func JQFilter(config, filter string) ([]string, error) {
 		if err, ok := v.(error); ok {
 			return nil, err
 		}
-		s := fmt.Sprintf("%v", v)
-		result = append(result, s)
+		result = append(result, v)
 	}
 	return result, nil
 }
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testJQInput = `{
+	"name": "static-server",
+	"port": 8080,
+	"meta": {"version": "v1"}
+}`
+
+func TestJQFilter(t *testing.T) {
+	results, err := JQFilter(testJQInput, ".name")
+	require.NoError(t, err)
+	require.Equal(t, []string{"static-server"}, results)
+
+	results, err = JQFilter(testJQInput, ".port")
+	require.NoError(t, err)
+	require.Equal(t, []string{"8080"}, results)
+
+	_, err = JQFilter(testJQInput, ".[")
+	require.Error(t, err)
+}
+
+func TestJQFilterTyped(t *testing.T) {
+	t.Run("string", func(t *testing.T) {
+		results, err := JQFilterTyped(testJQInput, ".name")
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"static-server"}, results)
+	})
+
+	t.Run("number", func(t *testing.T) {
+		results, err := JQFilterTyped(testJQInput, ".port")
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{float64(8080)}, results)
+	})
+
+	t.Run("object", func(t *testing.T) {
+		results, err := JQFilterTyped(testJQInput, ".meta")
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{map[string]interface{}{"version": "v1"}}, results)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := JQFilterTyped("{", ".name")
+		require.Error(t, err)
+	})
+}
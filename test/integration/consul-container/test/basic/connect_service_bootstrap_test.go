@@ -0,0 +1,37 @@
+package basic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TestBasicConnectService_BootstrapConfig Summary
+// This test makes sure the envoy bootstrap of a sidecar can be inspected.
+//
+// Steps:
+//   - Create a single agent cluster with the static-server and static-client services
+//   - Read the bootstrap config of the static-client sidecar
+//   - Make sure the node cluster is the static-client service and the admin listener binds to port 19000
+func TestBasicConnectService_BootstrapConfig(t *testing.T) {
+	cluster := createCluster(t)
+	defer terminate(t, cluster)
+
+	clientService := createServices(t, cluster)
+	connectContainer, ok := clientService.(*libservice.ConnectContainer)
+	require.True(t, ok)
+
+	bootstrap, err := connectContainer.GetBootstrapConfig()
+	require.NoError(t, err)
+
+	nodeCluster, err := utils.JQFilter(bootstrap, ".node.cluster")
+	require.NoError(t, err)
+	require.Equal(t, []string{"static-client"}, nodeCluster)
+
+	adminAddress, err := utils.JQFilter(bootstrap, ".admin.address.socket_address | .address, .port_value")
+	require.NoError(t, err)
+	require.Equal(t, []string{"0.0.0.0", "19000"}, adminAddress)
+}
@@ -0,0 +1,45 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+)
+
+// TestBasicConnectService_ContainerIP Summary
+// This test makes sure the container IP of a service is reachable from another
+// container on the same docker network, without the ports mapped on the host.
+//
+// Steps:
+//   - Create a single agent cluster.
+//   - Create the example static-server and sidecar containers, then register them with Consul
+//   - Make sure the agent container can make an HTTP call to the static-server on its container IP
+func TestBasicConnectService_ContainerIP(t *testing.T) {
+	cluster := createCluster(t)
+	defer terminate(t, cluster)
+
+	node := cluster.Agents[0]
+
+	serverService, serverConnectProxy, err := libservice.CreateAndRegisterStaticServerAndSidecar(node)
+	require.NoError(t, err)
+
+	libassert.CatalogServiceExists(t, node.GetClient(), "static-server")
+
+	ip := serverService.GetContainerIP()
+	require.NotEmpty(t, ip)
+	require.NotEqual(t, ip, serverConnectProxy.GetContainerIP())
+
+	url := fmt.Sprintf("http://%s:8080", ip)
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		exitCode, err := node.Exec(context.Background(), []string{"wget", "-q", "-O", "/dev/null", url})
+		require.NoError(r, err)
+		require.Zero(r, exitCode, "could not reach %s from the agent container", url)
+	})
+}
@@ -0,0 +1,71 @@
+package basic
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+)
+
+// TestBasicConnectServiceFaultInjection Summary
+// This test makes sure faults configured on the static-server sidecar abort
+// the requests of its downstreams, and that static-server is reachable again
+// once the faults are removed.
+//
+// Steps:
+//   - Create a single agent cluster.
+//   - Set the protocol of static-server to http so the envoy HTTP filters apply
+//   - Create the example static-server and a sidecar whose public listener aborts 100% of the requests, then register them with Consul
+//   - Create a static-client sidecar
+//   - Make sure the requests to static-server are aborted with a 503
+//   - Re-register the static-server sidecar without faults
+//   - Make sure the requests to static-server succeed again
+func TestBasicConnectServiceFaultInjection(t *testing.T) {
+	cluster := createCluster(t)
+	defer terminate(t, cluster)
+
+	node := cluster.Agents[0]
+	client := node.GetClient()
+
+	ok, _, err := client.ConfigEntries().Set(&api.ServiceConfigEntry{
+		Kind:     api.ServiceDefaults,
+		Name:     "static-server",
+		Protocol: "http",
+	}, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	faultOpts := libservice.ServerOptions{FaultAbortPercent: 100}
+	serverService, serverConnectProxy, err := libservice.CreateAndRegisterStaticServerAndSidecarWithOptions(node, faultOpts)
+	require.NoError(t, err)
+
+	libassert.CatalogServiceExists(t, client, "static-server-sidecar-proxy")
+	libassert.CatalogServiceExists(t, client, "static-server")
+
+	clientConnectProxy, err := libservice.CreateAndRegisterStaticClientSidecar(node, "", false)
+	require.NoError(t, err)
+
+	libassert.CatalogServiceExists(t, client, "static-client-sidecar-proxy")
+
+	_, port := clientConnectProxy.GetAddr()
+	url := fmt.Sprintf("http://localhost:%d", port)
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		res, err := http.Post(url, "text/plain", strings.NewReader("hello"))
+		require.NoError(r, err)
+		defer res.Body.Close()
+		require.Equal(r, http.StatusServiceUnavailable, res.StatusCode)
+	})
+
+	err = libservice.RegisterStaticServerSidecar(node, serverService, serverConnectProxy, libservice.ServerOptions{})
+	require.NoError(t, err)
+
+	libassert.HTTPServiceEchoes(t, "localhost", port)
+}
@@ -0,0 +1,97 @@
+package basic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TestBasicConnectServiceGRPC Summary
+// This test makes sure gRPC traffic flows between two services in the same
+// datacenter through their sidecars.
+//
+// Steps:
+//   - Create a single agent cluster.
+//   - Set the protocol of the static-server to grpc
+//   - Create the example static-server and sidecar containers, then register the gRPC port of the server with Consul
+//   - Create an example static-client sidecar, then register both the service and sidecar with Consul
+//   - Make sure a gRPC health check to the client sidecar local bind port is answered by the upstream, static-server
+func TestBasicConnectServiceGRPC(t *testing.T) {
+	cluster := createCluster(t)
+	defer terminate(t, cluster)
+
+	node := cluster.Agents[0]
+	client := node.GetClient()
+
+	ok, _, err := client.ConfigEntries().Set(&api.ServiceConfigEntry{
+		Kind:     api.ServiceDefaults,
+		Name:     "static-server",
+		Protocol: "grpc",
+	}, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, _, err = libservice.CreateAndRegisterGRPCStaticServerAndSidecar(node)
+	require.NoError(t, err)
+
+	libassert.CatalogServiceExists(t, client, "static-server-sidecar-proxy")
+	libassert.CatalogServiceExists(t, client, "static-server")
+
+	clientConnectProxy, err := libservice.CreateAndRegisterStaticClientSidecar(node, "", false)
+	require.NoError(t, err)
+
+	libassert.CatalogServiceExists(t, client, "static-client-sidecar-proxy")
+
+	_, port := clientConnectProxy.GetAddr()
+	libassert.GRPCServiceEchoes(t, "localhost", port)
+}
+
+// TestBasicConnectServiceGRPC_UpstreamProtocol Summary
+// This test makes sure the protocol declared on an upstream configures envoy
+// with the matching codec, without a service-defaults config entry.
+//
+// Steps:
+//   - Create a single agent cluster.
+//   - Create the example static-server and sidecar containers, then register the gRPC port of the server with Consul
+//   - Create an example static-client sidecar with a grpc upstream for static-server
+//   - Make sure the upstream cluster of the client sidecar uses HTTP/2
+//   - Make sure a gRPC health check to the client sidecar local bind port is answered by the upstream, static-server
+func TestBasicConnectServiceGRPC_UpstreamProtocol(t *testing.T) {
+	cluster := createCluster(t)
+	defer terminate(t, cluster)
+
+	node := cluster.Agents[0]
+	client := node.GetClient()
+
+	_, _, err := libservice.CreateAndRegisterGRPCStaticServerAndSidecar(node)
+	require.NoError(t, err)
+
+	libassert.CatalogServiceExists(t, client, "static-server-sidecar-proxy")
+	libassert.CatalogServiceExists(t, client, "static-server")
+
+	clientConnectProxy, err := libservice.CreateAndRegisterStaticClientSidecarWithOptions(node, libservice.SidecarOptions{
+		Upstreams: []libservice.Upstream{{
+			ServiceName:   "static-server",
+			LocalBindPort: 5000,
+			Protocol:      "grpc",
+		}},
+	})
+	require.NoError(t, err)
+
+	libassert.CatalogServiceExists(t, client, "static-client-sidecar-proxy")
+
+	_, adminPort := clientConnectProxy.GetAdminAddr()
+	filter := `.configs[] | select(.["@type"] | contains("type.googleapis.com/envoy.admin.v3.ClustersConfigDump")).dynamic_active_clusters[] | select(.cluster.name | contains("static-server.default")).cluster.typed_extension_protocol_options["envoy.extensions.upstreams.http.v3.HttpProtocolOptions"].explicit_http_config | has("http2_protocol_options")`
+	utils.RetryEnvoyConfigDump(t, adminPort, filter, func(results []string) bool {
+		return len(results) == 1 && results[0] == "true"
+	}, 30*time.Second)
+
+	_, port := clientConnectProxy.GetAddr()
+	libassert.GRPCServiceEchoes(t, "localhost", port)
+}
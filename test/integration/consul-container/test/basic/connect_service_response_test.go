@@ -0,0 +1,52 @@
+package basic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+)
+
+// TestBasicConnectServiceResponse Summary
+// This test makes sure the response body tells which backend served a request
+// to an upstream, so tests shifting traffic between versions can rely on it.
+//
+// Steps:
+//   - Create a single agent cluster.
+//   - Create the example static-server, static-client and sidecars, then register them with Consul
+//   - Make sure a call to the client sidecar local bind port is served by static-server
+//   - Create a static-server-v2 service and redirect static-server to it
+//   - Make sure a call to the client sidecar local bind port is now served by static-server-v2
+func TestBasicConnectServiceResponse(t *testing.T) {
+	cluster := createCluster(t)
+	defer terminate(t, cluster)
+
+	node := cluster.Agents[0]
+	client := node.GetClient()
+
+	clientService := createServices(t, cluster)
+	_, port := clientService.GetAddr()
+
+	// fortio reports the FORTIO_NAME of the container, which is the service
+	// name, in its environment dump.
+	libassert.HTTPServiceResponseContainsPath(t, "localhost", port, "/debug?env=dump", "FORTIO_NAME=static-server\n")
+
+	_, _, err := libservice.CreateAndRegisterNamedStaticServerAndSidecar(node, "static-server-v2")
+	require.NoError(t, err)
+	libassert.CatalogServiceExists(t, client, "static-server-v2")
+
+	ok, _, err := client.ConfigEntries().Set(&api.ServiceResolverConfigEntry{
+		Kind: api.ServiceResolver,
+		Name: "static-server",
+		Redirect: &api.ServiceResolverRedirect{
+			Service: "static-server-v2",
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	libassert.HTTPServiceResponseContainsPath(t, "localhost", port, "/debug?env=dump", "FORTIO_NAME=static-server-v2\n")
+}
@@ -0,0 +1,41 @@
+package basic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TestBasicConnectService_RestartEnvoy Summary
+// This test makes sure the mesh reconverges after the client sidecar's envoy
+// is restarted while the application keeps running.
+//
+// Steps:
+//   - Create a single agent cluster with the static-server and static-client services
+//   - Make sure a call to the client sidecar local bind port returns a response from the upstream
+//   - Restart the client sidecar's envoy
+//   - Make sure the envoy admin endpoint serves the config dump again
+//   - Make sure a call to the client sidecar local bind port still reaches the upstream
+func TestBasicConnectService_RestartEnvoy(t *testing.T) {
+	cluster := createCluster(t)
+	defer terminate(t, cluster)
+
+	clientService := createServices(t, cluster)
+	_, port := clientService.GetAddr()
+	libassert.HTTPServiceEchoes(t, "localhost", port)
+
+	connectContainer, ok := clientService.(*libservice.ConnectContainer)
+	require.True(t, ok)
+	require.NoError(t, connectContainer.RestartEnvoy())
+
+	_, adminPort := connectContainer.GetAdminAddr()
+	_, err := utils.GetEnvoyConfigDump(adminPort)
+	require.NoError(t, err)
+
+	_, port = connectContainer.GetAddr()
+	libassert.HTTPServiceEchoes(t, "localhost", port)
+}
@@ -0,0 +1,54 @@
+package basic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+)
+
+// TestBasicConnectServiceStats Summary
+// This test makes sure a request to an upstream flows through the client
+// sidecar by checking the sidecar's envoy stats.
+//
+// Steps:
+//   - Create a single agent cluster.
+//   - Set the protocol of the static-server to http so envoy records request stats
+//   - Create the example static-server, static-client and sidecars, then register them with Consul
+//   - Make sure a call to the client sidecar local bind port increments upstream_rq_completed
+func TestBasicConnectServiceStats(t *testing.T) {
+	cluster := createCluster(t)
+	defer terminate(t, cluster)
+
+	client := cluster.Agents[0].GetClient()
+	ok, _, err := client.ConfigEntries().Set(&api.ServiceConfigEntry{
+		Kind:     api.ServiceDefaults,
+		Name:     "static-server",
+		Protocol: "http",
+	}, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	clientService := createServices(t, cluster)
+	_, port := clientService.GetAddr()
+
+	connectContainer, ok := clientService.(*libservice.ConnectContainer)
+	require.True(t, ok)
+	_, statsPort := connectContainer.GetStatsAddr()
+
+	const upstreamRqCompleted = `^cluster\.static-server\..*\.upstream_rq_completed$`
+
+	// The stat may not exist until the upstream cluster is configured.
+	var before int
+	stats, err := libservice.GetEnvoyStats(statsPort, upstreamRqCompleted)
+	require.NoError(t, err)
+	for _, v := range stats {
+		before += v
+	}
+
+	libassert.HTTPServiceEchoes(t, "localhost", port)
+	libassert.EnvoyStatGreaterThan(t, statsPort, upstreamRqCompleted, before)
+}
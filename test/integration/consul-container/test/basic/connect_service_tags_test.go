@@ -0,0 +1,63 @@
+package basic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+)
+
+// TestBasicConnectServiceTags Summary
+// This test makes sure the tags and metadata of a running service can be
+// changed, and that discovery filtering on them follows the change.
+//
+// Steps:
+//   - Create a single agent cluster.
+//   - Create the example static-server and sidecar containers, then register them both with Consul
+//   - Tag the static-server v1 and make sure discovery filtered on v1 returns it
+//   - Retag the static-server v2 and make sure discovery filtered on v2 returns it and on v1 doesn't
+//   - Update the metadata of the static-server and make sure the catalog reflects it
+func TestBasicConnectServiceTags(t *testing.T) {
+	cluster := createCluster(t)
+	defer terminate(t, cluster)
+
+	node := cluster.Agents[0]
+	client := node.GetClient()
+
+	serverService, _, err := libservice.CreateAndRegisterStaticServerAndSidecar(node)
+	require.NoError(t, err)
+	libassert.CatalogServiceExists(t, client, "static-server")
+
+	require.NoError(t, serverService.UpdateTags([]string{"v1"}))
+	requireTaggedInstances(t, client, "v1", 1)
+
+	require.NoError(t, serverService.UpdateTags([]string{"v2"}))
+	requireTaggedInstances(t, client, "v2", 1)
+	requireTaggedInstances(t, client, "v1", 0)
+
+	require.NoError(t, serverService.UpdateMeta(map[string]string{"version": "2"}))
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		entries, _, err := client.Health().Service("static-server", "", false, nil)
+		require.NoError(r, err)
+		require.Len(r, entries, 1)
+		require.Equal(r, map[string]string{"version": "2"}, entries[0].Service.Meta)
+		// Updating the metadata keeps the tags.
+		require.Equal(r, []string{"v2"}, entries[0].Service.Tags)
+	})
+}
+
+// requireTaggedInstances waits until discovery of static-server filtered on
+// the given tag returns n instances.
+func requireTaggedInstances(t *testing.T, client *api.Client, tag string, n int) {
+	t.Helper()
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		entries, _, err := client.Health().Service("static-server", tag, false, nil)
+		require.NoError(r, err)
+		require.Len(r, entries, n)
+	})
+}
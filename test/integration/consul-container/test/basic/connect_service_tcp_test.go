@@ -0,0 +1,41 @@
+package basic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+)
+
+// TestBasicConnectServiceTCP Summary
+// This test makes sure raw TCP traffic flows between two services in the same
+// datacenter through their sidecars.
+//
+// Steps:
+//   - Create a single agent cluster.
+//   - Create the example static-server and sidecar containers, then register the TCP echo port of the server with Consul
+//   - Create an example static-client sidecar, then register both the service and sidecar with Consul
+//   - Make sure the bytes written to the client sidecar local bind port are echoed back by the upstream, static-server
+func TestBasicConnectServiceTCP(t *testing.T) {
+	cluster := createCluster(t)
+	defer terminate(t, cluster)
+
+	node := cluster.Agents[0]
+	client := node.GetClient()
+
+	_, _, err := libservice.CreateAndRegisterTCPStaticServerAndSidecar(node)
+	require.NoError(t, err)
+
+	libassert.CatalogServiceExists(t, client, "static-server-sidecar-proxy")
+	libassert.CatalogServiceExists(t, client, "static-server")
+
+	clientConnectProxy, err := libservice.CreateAndRegisterStaticClientSidecar(node, "", false)
+	require.NoError(t, err)
+
+	libassert.CatalogServiceExists(t, client, "static-client-sidecar-proxy")
+
+	_, port := clientConnectProxy.GetAddr()
+	libassert.TCPServiceEchoes(t, "localhost", port)
+}
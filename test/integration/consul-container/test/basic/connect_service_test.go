@@ -21,6 +21,7 @@ import (
 //   - Create the example static-server and sidecar containers, then register them both with Consul
 //   - Create an example static-client sidecar, then register both the service and sidecar with Consul
 //   - Make sure a call to the client sidecar local bind port returns a response from the upstream, static-server
+//   - Make sure the client sidecar considers the static-server endpoint healthy
 func TestBasicConnectService(t *testing.T) {
 	cluster := createCluster(t)
 	defer terminate(t, cluster)
@@ -29,6 +30,11 @@ func TestBasicConnectService(t *testing.T) {
 	_, port := clientService.GetAddr()
 
 	libassert.HTTPServiceEchoes(t, "localhost", port)
+
+	connectContainer, ok := clientService.(*libservice.ConnectContainer)
+	require.True(t, ok)
+	_, adminPort := connectContainer.GetAdminAddr()
+	libassert.EnvoyEndpointHealthy(t, adminPort, "static-server")
 }
 
 func terminate(t *testing.T, cluster *libcluster.Cluster) {
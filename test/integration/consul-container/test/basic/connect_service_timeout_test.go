@@ -0,0 +1,51 @@
+package basic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TestBasicConnectServiceUpstreamConnectTimeout Summary
+// This test makes sure the connect timeout configured on an upstream is
+// rendered into the envoy cluster of the client sidecar.
+//
+// Steps:
+//   - Create a single agent cluster.
+//   - Create the example static-server and sidecar containers, then register them with Consul
+//   - Create a static-client sidecar whose static-server upstream has a 250ms connect timeout
+//   - Make sure the static-server cluster in the client sidecar has the connect timeout
+func TestBasicConnectServiceUpstreamConnectTimeout(t *testing.T) {
+	cluster := createCluster(t)
+	defer terminate(t, cluster)
+
+	node := cluster.Agents[0]
+	client := node.GetClient()
+
+	_, _, err := libservice.CreateAndRegisterStaticServerAndSidecar(node)
+	require.NoError(t, err)
+
+	libassert.CatalogServiceExists(t, client, "static-server-sidecar-proxy")
+	libassert.CatalogServiceExists(t, client, "static-server")
+
+	clientConnectProxy, err := libservice.CreateAndRegisterStaticClientSidecarWithOptions(node, libservice.SidecarOptions{
+		ConnectTimeout: 250 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	libassert.CatalogServiceExists(t, client, "static-client-sidecar-proxy")
+
+	_, port := clientConnectProxy.GetAddr()
+	libassert.HTTPServiceEchoes(t, "localhost", port)
+
+	_, adminPort := clientConnectProxy.GetAdminAddr()
+	filter := `.configs[] | select(.["@type"] | contains("type.googleapis.com/envoy.admin.v3.ClustersConfigDump")).dynamic_active_clusters[] | select(.cluster.name | startswith("static-server.")).cluster.connect_timeout`
+	utils.RetryEnvoyConfigDump(t, adminPort, filter, func(results []string) bool {
+		return len(results) == 1 && results[0] == "0.250s"
+	}, 30*time.Second)
+}
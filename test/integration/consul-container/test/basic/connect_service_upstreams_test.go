@@ -0,0 +1,55 @@
+package basic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+)
+
+// TestBasicConnectService_MultipleUpstreams Summary
+// This test makes sure a sidecar with several upstreams routes each local bind
+// port to its own upstream.
+//
+// Steps:
+//   - Create a single agent cluster.
+//   - Create two static servers with sidecars, static-server-1 and static-server-2
+//   - Create a static-client sidecar with an upstream for each static server
+//   - Make sure a call to each upstream's local bind port returns a response
+func TestBasicConnectService_MultipleUpstreams(t *testing.T) {
+	cluster := createCluster(t)
+	defer terminate(t, cluster)
+
+	node := cluster.Agents[0]
+	client := node.GetClient()
+
+	upstreams := []libservice.Upstream{
+		{ServiceName: "static-server-1", LocalBindPort: 5000},
+		{ServiceName: "static-server-2", LocalBindPort: 5001},
+	}
+	for _, u := range upstreams {
+		_, _, err := libservice.CreateAndRegisterNamedStaticServerAndSidecar(node, u.ServiceName)
+		require.NoError(t, err)
+
+		libassert.CatalogServiceExists(t, client, u.ServiceName)
+		libassert.CatalogServiceExists(t, client, u.ServiceName+"-sidecar-proxy")
+	}
+
+	clientConnectProxy, err := libservice.CreateAndRegisterStaticClientSidecarWithOptions(node, libservice.SidecarOptions{
+		Upstreams: upstreams,
+	})
+	require.NoError(t, err)
+
+	libassert.CatalogServiceExists(t, client, "static-client-sidecar-proxy")
+
+	for _, u := range upstreams {
+		ip, port, err := clientConnectProxy.GetUpstreamAddr(u.ServiceName)
+		require.NoError(t, err)
+		libassert.HTTPServiceEchoes(t, ip, port)
+	}
+
+	_, _, err = clientConnectProxy.GetUpstreamAddr("static-server-3")
+	require.Error(t, err)
+}
@@ -0,0 +1,65 @@
+package basic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+)
+
+// TestDNSServiceLookup Summary
+// This test makes sure a service registered with an agent can be resolved
+// through the agent's DNS interface, with a DNS config set by the builder.
+//
+// Steps:
+//   - Create a single agent cluster serving only passing instances over DNS
+//   - Register a service with a passing check
+//   - Make sure an SRV query for the service returns the service's port
+func TestDNSServiceLookup(t *testing.T) {
+	allowStale := true
+	conf, err := libagent.NewConfigBuilder(nil).
+		DNSConfig(libagent.DNSConfig{
+			AllowStale:  &allowStale,
+			NodeTTL:     "10s",
+			ServiceTTL:  map[string]string{"*": "5s"},
+			OnlyPassing: true,
+			SOA:         &libagent.DNSSOA{MinTTL: 30},
+		}).
+		ToAgentConfig()
+	require.NoError(t, err)
+
+	cluster, err := libcluster.New([]libagent.Config{*conf})
+	require.NoError(t, err)
+	defer terminate(t, cluster)
+
+	node := cluster.Agents[0]
+	client := node.GetClient()
+	libcluster.WaitForLeader(t, cluster, client)
+	libcluster.WaitForMembers(t, client, 1)
+
+	require.NoError(t, client.Agent().ServiceRegister(&api.AgentServiceRegistration{
+		Name: "web",
+		Port: 8080,
+		Check: &api.AgentServiceCheck{
+			TTL:    "10m",
+			Status: api.HealthPassing,
+		},
+	}))
+
+	resolver := libcluster.DNSResolver(node)
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, records, err := resolver.LookupSRV(ctx, "", "", "web.service.consul.")
+		require.NoError(r, err)
+		require.Len(r, records, 1)
+		require.Equal(r, uint16(8080), records[0].Port)
+	})
+}
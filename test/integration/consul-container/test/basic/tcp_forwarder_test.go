@@ -0,0 +1,58 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+)
+
+// TestTCPForwarder Summary
+// This test makes sure raw TCP traffic flows through a TCP forwarder put in
+// front of a service, and stops flowing while the forwarder is stopped.
+//
+// Steps:
+//   - Create a single agent cluster.
+//   - Create the example static-server container
+//   - Create a TCP forwarder targeting the TCP echo port of the server
+//   - Make sure the bytes written to the forwarder are echoed back by the static-server
+//   - Stop the forwarder and make sure connections to it fail
+//   - Start the forwarder again and make sure the bytes are echoed back again
+func TestTCPForwarder(t *testing.T) {
+	cluster := createCluster(t)
+	defer terminate(t, cluster)
+
+	node := cluster.Agents[0]
+
+	server, err := libservice.NewExampleService(context.Background(), "static-server", 8080, 8079, node)
+	require.NoError(t, err)
+
+	target := fmt.Sprintf("%s:%d", server.GetContainerIP(), 8078)
+	forwarder, err := libservice.NewTCPForwarder(context.Background(), "static-server", 9090, target, node)
+	require.NoError(t, err)
+	require.Equal(t, target, forwarder.GetTarget())
+
+	_, port := forwarder.GetAddr()
+	libassert.TCPServiceEchoes(t, "localhost", port)
+
+	require.NoError(t, forwarder.Stop())
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", port), time.Second)
+		if err == nil {
+			conn.Close()
+			r.Fatal("the stopped forwarder still accepts connections")
+		}
+	})
+
+	require.NoError(t, forwarder.Start())
+	_, port = forwarder.GetAddr()
+	libassert.TCPServiceEchoes(t, "localhost", port)
+}
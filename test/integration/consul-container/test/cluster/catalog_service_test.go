@@ -0,0 +1,68 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+)
+
+// TestCatalogService Summary
+// This test makes sure catalog-only services are discoverable without a
+// running instance.
+//
+// Steps:
+//   - Create a single agent cluster
+//   - Register a synthetic node with a service and a passing check
+//   - Make sure the service is returned by the health endpoint
+//   - Deregister the node and make sure the service is gone
+func TestCatalogService(t *testing.T) {
+	conf, err := libagent.NewConfigBuilder(nil).ToAgentConfig()
+	require.NoError(t, err)
+
+	cluster, err := libcluster.New([]libagent.Config{*conf})
+	require.NoError(t, err)
+	defer terminate(t, cluster)
+
+	client := cluster.Agents[0].GetClient()
+	libcluster.WaitForLeader(t, cluster, client)
+
+	err = cluster.RegisterCatalogService(&api.CatalogRegistration{
+		Node:    "synthetic-node",
+		Address: "192.0.2.10",
+		Service: &api.AgentService{
+			ID:      "synthetic-1",
+			Service: "synthetic",
+			Port:    8080,
+		},
+		Check: &api.AgentCheck{
+			Node:      "synthetic-node",
+			CheckID:   "synthetic-check",
+			Name:      "synthetic check",
+			Status:    api.HealthPassing,
+			ServiceID: "synthetic-1",
+		},
+	})
+	require.NoError(t, err)
+
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		entries, _, err := client.Health().Service("synthetic", "", true, nil)
+		require.NoError(r, err)
+		require.Len(r, entries, 1)
+		require.Equal(r, "synthetic-node", entries[0].Node.Node)
+		require.Equal(r, "192.0.2.10", entries[0].Node.Address)
+		require.Equal(r, 8080, entries[0].Service.Port)
+	})
+
+	require.NoError(t, cluster.DeregisterCatalogService(&api.CatalogDeregistration{Node: "synthetic-node"}))
+
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		entries, _, err := client.Health().Service("synthetic", "", false, nil)
+		require.NoError(r, err)
+		require.Empty(r, entries)
+	})
+}
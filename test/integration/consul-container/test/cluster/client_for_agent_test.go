@@ -0,0 +1,69 @@
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+)
+
+// TestGetClientForAgent Summary
+// This test makes sure the clients returned by GetClientForAgent are served by
+// their own agent, by querying the leader through two servers that disagree
+// about it during an election.
+//
+// Steps:
+//   - Create a cluster with 3 servers
+//   - Create a client for the leader and one for a follower
+//   - Partition the leader from the followers
+//   - Make sure the two clients report different leaders while the followers elect a new one
+func TestGetClientForAgent(t *testing.T) {
+	const numServers = 3
+
+	var configs []libagent.Config
+	for i := 0; i < numServers; i++ {
+		conf, err := libagent.NewConfigBuilder(nil).
+			Bootstrap(numServers).
+			RetryJoin(fmt.Sprintf("agent-%d", (i+1)%numServers)).
+			ToAgentConfig()
+		require.NoError(t, err)
+		configs = append(configs, *conf)
+	}
+
+	cluster, err := libcluster.New(configs)
+	require.NoError(t, err)
+	defer terminate(t, cluster)
+	defer cluster.DumpLogsOnFailure(t)
+
+	libcluster.WaitForLeader(t, cluster, nil)
+	libcluster.WaitForMembers(t, cluster.Agents[0].GetClient(), numServers)
+
+	leader, err := cluster.Leader()
+	require.NoError(t, err)
+	followers, err := cluster.Followers()
+	require.NoError(t, err)
+
+	leaderClient, err := cluster.GetClientForAgent(leader)
+	require.NoError(t, err)
+	followerClient, err := cluster.GetClientForAgent(followers[0])
+	require.NoError(t, err)
+
+	require.NoError(t, cluster.PartitionAgents([]libagent.Agent{leader}, followers))
+	defer func() {
+		require.NoError(t, cluster.HealPartition())
+	}()
+
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		oldLeaderView, err := leaderClient.Status().Leader()
+		require.NoError(r, err)
+		followerView, err := followerClient.Status().Leader()
+		require.NoError(r, err)
+
+		require.NotEmpty(r, followerView)
+		require.NotEqual(r, oldLeaderView, followerView)
+	})
+}
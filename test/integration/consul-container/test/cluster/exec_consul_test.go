@@ -0,0 +1,37 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TestExecConsul Summary
+// This test makes sure ExecConsul runs the consul CLI in an agent's container
+// and returns its output.
+//
+// Steps:
+//   - Stand up a cluster with 1 server and 2 clients
+//   - Run `consul members` on a client
+//   - Make sure the output lists every agent of the cluster
+//   - Make sure a failing command returns an error and its stderr
+func TestExecConsul(t *testing.T) {
+	cluster := libcluster.StandUp(t, *utils.TargetVersion, 1, 2)
+	defer terminate(t, cluster)
+
+	clients, err := cluster.Clients()
+	require.NoError(t, err)
+
+	stdout, _, err := clients[0].ExecConsul("members")
+	require.NoError(t, err)
+	for _, n := range cluster.Agents {
+		require.Contains(t, stdout, n.GetNodeName())
+	}
+
+	_, stderr, err := clients[0].ExecConsul("not-a-command")
+	require.Error(t, err)
+	require.NotEmpty(t, stderr)
+}
@@ -0,0 +1,78 @@
+package cluster
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+)
+
+// TestNetworkImpairment Summary
+// This test makes sure a cluster stays healthy while the network of one of
+// its followers is slow.
+//
+// Steps:
+//   - Create a cluster with 3 servers
+//   - Add 200ms of latency to the network of a follower
+//   - Make sure the leader is unchanged, all servers are alive, and writes
+//     replicate to every server, including the impaired follower
+//   - Remove the latency and make sure writes still replicate
+func TestNetworkImpairment(t *testing.T) {
+	const numServers = 3
+
+	var configs []libagent.Config
+	for i := 0; i < numServers; i++ {
+		conf, err := libagent.NewConfigBuilder(nil).
+			Bootstrap(numServers).
+			RetryJoin(fmt.Sprintf("agent-%d", (i+1)%numServers)).
+			ToAgentConfig()
+		require.NoError(t, err)
+		configs = append(configs, *conf)
+	}
+
+	cluster, err := libcluster.New(configs)
+	require.NoError(t, err)
+	defer terminate(t, cluster)
+	defer cluster.DumpLogsOnFailure(t)
+
+	libcluster.WaitForLeader(t, cluster, nil)
+	libcluster.WaitForMembers(t, cluster.Agents[0].GetClient(), numServers)
+
+	leader, err := cluster.Leader()
+	require.NoError(t, err)
+	followers, err := cluster.Followers()
+	require.NoError(t, err)
+	require.Len(t, followers, numServers-1)
+
+	leaderAddr, err := leader.GetClient().Status().Leader()
+	require.NoError(t, err)
+
+	require.NoError(t, cluster.Impair(followers[0], 200*time.Millisecond, 0))
+
+	_, err = leader.GetClient().KV().Put(&api.KVPair{Key: "impaired", Value: []byte("slow")}, nil)
+	require.NoError(t, err)
+	require.NoError(t, cluster.WaitForKV("impaired", []byte("slow"), 30*time.Second))
+
+	// The latency is well below the Raft and Serf timeouts, so the cluster
+	// keeps its leader and members.
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		for _, n := range cluster.Agents {
+			addr, err := n.GetClient().Status().Leader()
+			require.NoError(r, err)
+			require.Equal(r, leaderAddr, addr)
+		}
+	})
+	libcluster.WaitForMembers(t, followers[0].GetClient(), numServers)
+
+	require.NoError(t, cluster.Unimpair(followers[0]))
+
+	_, err = leader.GetClient().KV().Put(&api.KVPair{Key: "impaired", Value: []byte("fast")}, nil)
+	require.NoError(t, err)
+	require.NoError(t, cluster.WaitForKV("impaired", []byte("fast"), 30*time.Second))
+}
@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TestWaitForKV Summary
+// This test makes sure WaitForKV waits until a KV write has been replicated to
+// every server, as seen by stale reads from each of them.
+//
+// Steps:
+//   - Stand up a cluster with 3 servers
+//   - Write a key through a follower
+//   - Make sure all the servers converge on the value
+//   - Update and then delete the key, and make sure the servers converge each time
+func TestWaitForKV(t *testing.T) {
+	const (
+		numServers = 3
+		key        = "convergence/key"
+		timeout    = 30 * time.Second
+	)
+
+	cluster := libcluster.StandUp(t, *utils.TargetVersion, numServers, 0)
+	defer terminate(t, cluster)
+	defer cluster.DumpLogsOnFailure(t)
+
+	followers, err := cluster.Followers()
+	require.NoError(t, err)
+	require.NotEmpty(t, followers)
+	kv := followers[0].GetClient().KV()
+
+	_, err = kv.Put(&api.KVPair{Key: key, Value: []byte("v1")}, nil)
+	require.NoError(t, err)
+	require.NoError(t, cluster.WaitForKV(key, []byte("v1"), timeout))
+
+	_, err = kv.Put(&api.KVPair{Key: key, Value: []byte("v2")}, nil)
+	require.NoError(t, err)
+	require.NoError(t, cluster.WaitForKV(key, []byte("v2"), timeout))
+
+	_, err = kv.Delete(key, nil)
+	require.NoError(t, err)
+	require.NoError(t, cluster.WaitForKV(key, nil, timeout))
+}
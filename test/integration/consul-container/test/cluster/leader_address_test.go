@@ -0,0 +1,62 @@
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+)
+
+// TestLeaderAddress Summary
+// This test makes sure LeaderAddress follows the leader across a re-election.
+//
+// Steps:
+//   - Create a cluster with 3 servers
+//   - Kill the leader
+//   - Make sure LeaderAddress reports a different leader once one is elected
+func TestLeaderAddress(t *testing.T) {
+	const numServers = 3
+
+	var configs []libagent.Config
+	for i := 0; i < numServers; i++ {
+		conf, err := libagent.NewConfigBuilder(nil).
+			Bootstrap(numServers).
+			RetryJoin(fmt.Sprintf("agent-%d", (i+1)%numServers)).
+			ToAgentConfig()
+		require.NoError(t, err)
+		configs = append(configs, *conf)
+	}
+
+	cluster, err := libcluster.New(configs)
+	require.NoError(t, err)
+	defer terminate(t, cluster)
+
+	client := cluster.Agents[0].GetClient()
+	libcluster.WaitForLeader(t, cluster, client)
+	libcluster.WaitForMembers(t, client, numServers)
+
+	oldAddr, err := cluster.LeaderAddress()
+	require.NoError(t, err)
+
+	leader, err := cluster.Leader()
+	require.NoError(t, err)
+	ip, _ := leader.GetAddr()
+	require.Contains(t, oldAddr, ip)
+
+	followers, err := cluster.Followers()
+	require.NoError(t, err)
+
+	// Kill the leader and drop it from the cluster to prevent double-termination.
+	require.NoError(t, leader.Terminate())
+	cluster.Agents = followers
+
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		newAddr, err := cluster.LeaderAddress()
+		require.NoError(r, err)
+		require.NotEqual(r, oldAddr, newAddr)
+	})
+}
@@ -0,0 +1,92 @@
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/serf/serf"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+)
+
+// TestLeaveAndForceRemove Summary
+// This test makes sure running agents can leave gracefully, while terminated
+// agents must be forced out of the cluster.
+//
+// Steps:
+//   - Create a cluster with 3 servers and 2 clients
+//   - Make the first client leave gracefully and make sure it is marked as left
+//   - Terminate the second client and make sure it is marked as failed
+//   - Force remove the second client and make sure it is marked as left
+func TestLeaveAndForceRemove(t *testing.T) {
+	const (
+		numServers = 3
+		numClients = 2
+	)
+
+	var configs []libagent.Config
+	for i := 0; i < numServers; i++ {
+		conf, err := libagent.NewConfigBuilder(nil).
+			Bootstrap(numServers).
+			RetryJoin(fmt.Sprintf("agent-%d", (i+1)%numServers)).
+			ToAgentConfig()
+		require.NoError(t, err)
+		configs = append(configs, *conf)
+	}
+	for i := 0; i < numClients; i++ {
+		conf, err := libagent.NewConfigBuilder(nil).
+			Client().
+			RetryJoin("agent-0").
+			ToAgentConfig()
+		require.NoError(t, err)
+		configs = append(configs, *conf)
+	}
+
+	cluster, err := libcluster.New(configs)
+	require.NoError(t, err)
+	defer terminate(t, cluster)
+
+	client := cluster.Agents[0].GetClient()
+	libcluster.WaitForLeader(t, cluster, client)
+	libcluster.WaitForMembers(t, client, numServers+numClients)
+
+	clients, err := cluster.Clients()
+	require.NoError(t, err)
+	require.Len(t, clients, numClients)
+
+	// A graceful leave marks the node as left without further action.
+	leaving := clients[0]
+	require.NoError(t, cluster.Leave(leaving))
+	waitForMemberStatus(t, client, leaving.GetNodeName(), serf.StatusLeft)
+	libcluster.WaitForClients(t, client, numClients-1)
+
+	// A terminated node stays in the member list as failed until it is
+	// forced out.
+	dead := clients[1]
+	require.NoError(t, dead.Terminate())
+	waitForMemberStatus(t, client, dead.GetNodeName(), serf.StatusFailed)
+
+	require.NoError(t, cluster.ForceRemove(dead))
+	waitForMemberStatus(t, client, dead.GetNodeName(), serf.StatusLeft)
+	libcluster.WaitForServers(t, client, numServers)
+	libcluster.WaitForClients(t, client, 0)
+}
+
+func waitForMemberStatus(t *testing.T, client *api.Client, node string, status serf.MemberStatus) {
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		members, err := client.Agent().Members(false)
+		require.NoError(r, err)
+
+		for _, member := range members {
+			if member.Name == node {
+				require.Equal(r, status, serf.MemberStatus(member.Status))
+				return
+			}
+		}
+		r.Fatalf("member %q not found", node)
+	})
+}
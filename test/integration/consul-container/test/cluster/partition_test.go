@@ -0,0 +1,82 @@
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+)
+
+// TestNetworkPartition Summary
+// This test makes sure the majority side of a network partition elects a new
+// leader, and that the cluster converges again once the partition heals.
+//
+// Steps:
+//   - Create a cluster with 3 servers
+//   - Partition the followers from the leader
+//   - Make sure the followers elect one of them as the new leader
+//   - Heal the partition and make sure all servers agree on the leader
+func TestNetworkPartition(t *testing.T) {
+	const numServers = 3
+
+	var configs []libagent.Config
+	for i := 0; i < numServers; i++ {
+		conf, err := libagent.NewConfigBuilder(nil).
+			Bootstrap(numServers).
+			RetryJoin(fmt.Sprintf("agent-%d", (i+1)%numServers)).
+			ToAgentConfig()
+		require.NoError(t, err)
+		configs = append(configs, *conf)
+	}
+
+	cluster, err := libcluster.New(configs)
+	require.NoError(t, err)
+	defer terminate(t, cluster)
+	defer cluster.DumpLogsOnFailure(t)
+
+	libcluster.WaitForLeader(t, cluster, nil)
+	libcluster.WaitForMembers(t, cluster.Agents[0].GetClient(), numServers)
+
+	leader, err := cluster.Leader()
+	require.NoError(t, err)
+	followers, err := cluster.Followers()
+	require.NoError(t, err)
+	require.Len(t, followers, numServers-1)
+
+	oldLeaderAddr, _ := leader.GetAddr()
+
+	require.NoError(t, cluster.PartitionAgents([]libagent.Agent{leader}, followers))
+
+	// The followers hold the majority and elect a new leader among them.
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		for _, follower := range followers {
+			leaderAddr, err := follower.GetClient().Status().Leader()
+			require.NoError(r, err)
+			require.NotEmpty(r, leaderAddr)
+			host, _, err := net.SplitHostPort(leaderAddr)
+			require.NoError(r, err)
+			require.NotEqual(r, oldLeaderAddr, host)
+		}
+	})
+
+	require.NoError(t, cluster.HealPartition())
+
+	// The old leader steps down and follows the new leader.
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		expected, err := followers[0].GetClient().Status().Leader()
+		require.NoError(r, err)
+		require.NotEmpty(r, expected)
+
+		for _, n := range cluster.Agents {
+			leaderAddr, err := n.GetClient().Status().Leader()
+			require.NoError(r, err)
+			require.Equal(r, expected, leaderAddr)
+		}
+	})
+	libcluster.WaitForMembers(t, leader.GetClient(), numServers)
+}
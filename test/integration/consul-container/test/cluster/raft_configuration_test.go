@@ -0,0 +1,85 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+)
+
+// TestRaftConfiguration Summary
+// This test makes sure RaftConfiguration reports which servers are Raft
+// voters, which cluster membership alone doesn't tell.
+//
+// Steps:
+//   - Create a cluster with 3 servers which autopilot doesn't promote for an hour
+//   - Make sure the Raft configuration has the 3 servers as voters
+//   - Add a 4th server
+//   - Make sure the Raft configuration has the new server as a non-voter
+func TestRaftConfiguration(t *testing.T) {
+	const numServers = 3
+
+	// New servers are added to Raft as non-voters and only promoted once they
+	// have been healthy for the stabilization time.
+	autopilot := json.RawMessage(`{"autopilot": {"server_stabilization_time": "1h"}}`)
+
+	var configs []libagent.Config
+	for i := 0; i < numServers; i++ {
+		conf, err := libagent.NewConfigBuilder(nil).
+			Bootstrap(numServers).
+			RetryJoin(fmt.Sprintf("agent-%d", (i+1)%numServers)).
+			ExtraConfig(autopilot).
+			ToAgentConfig()
+		require.NoError(t, err)
+		configs = append(configs, *conf)
+	}
+
+	cluster, err := libcluster.New(configs)
+	require.NoError(t, err)
+	defer terminate(t, cluster)
+	defer cluster.DumpLogsOnFailure(t)
+
+	libcluster.WaitForLeader(t, cluster, nil)
+	libcluster.WaitForMembers(t, cluster.Agents[0].GetClient(), numServers)
+
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		raftConfig, err := cluster.RaftConfiguration()
+		require.NoError(r, err)
+		require.Len(r, raftConfig.Servers, numServers)
+		for _, server := range raftConfig.Servers {
+			require.True(r, server.Voter, "server %s", server.Node)
+		}
+	})
+
+	conf, err := libagent.NewConfigBuilder(nil).
+		Bootstrap(0).
+		RetryJoin("agent-0").
+		ExtraConfig(autopilot).
+		ToAgentConfig()
+	require.NoError(t, err)
+	require.NoError(t, cluster.Add([]libagent.Config{*conf}))
+	libcluster.WaitForMembers(t, cluster.Agents[0].GetClient(), numServers+1)
+
+	newServer := cluster.Agents[numServers].GetNodeName()
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		raftConfig, err := cluster.RaftConfiguration()
+		require.NoError(r, err)
+		require.Len(r, raftConfig.Servers, numServers+1)
+
+		voters := 0
+		for _, server := range raftConfig.Servers {
+			if server.Node == newServer {
+				require.False(r, server.Voter, "server %s", server.Node)
+			}
+			if server.Voter {
+				voters++
+			}
+		}
+		require.Equal(r, numServers, voters)
+	})
+}
@@ -0,0 +1,78 @@
+package cluster
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+)
+
+// TestRollingRestart Summary
+// This test makes sure a cluster keeps a leader while all of its servers are
+// replaced one at a time.
+//
+// Steps:
+//   - Create a cluster with 3 servers and 1 client
+//   - Replace every server with RollingRestart, failing if the leader is lost
+//     for more than 10 seconds
+//   - Make sure none of the original servers remain and a leader is elected
+func TestRollingRestart(t *testing.T) {
+	const numServers = 3
+
+	ctx, err := libagent.NewBuildContext(libagent.BuildOptions{
+		InjectAutoEncryption:   true,
+		InjectGossipEncryption: true,
+	})
+	require.NoError(t, err)
+
+	var configs []libagent.Config
+	for i := 0; i < numServers; i++ {
+		conf, err := libagent.NewConfigBuilder(ctx).
+			Bootstrap(numServers).
+			RetryJoin(fmt.Sprintf("agent-%d", (i+1)%numServers)).
+			ToAgentConfig()
+		require.NoError(t, err)
+		configs = append(configs, *conf)
+	}
+
+	// The client agent is never replaced, so it is used to observe the cluster.
+	clientConf, err := libagent.NewConfigBuilder(ctx).
+		Client().
+		RetryJoin("agent-0", "agent-1", "agent-2").
+		ToAgentConfig()
+	require.NoError(t, err)
+	configs = append(configs, *clientConf)
+
+	cluster, err := libcluster.New(configs)
+	require.NoError(t, err)
+	defer terminate(t, cluster)
+
+	client := cluster.Agents[numServers].GetClient()
+	libcluster.WaitForLeader(t, cluster, client)
+	libcluster.WaitForMembers(t, client, numServers+1)
+
+	originalServers, err := cluster.Servers()
+	require.NoError(t, err)
+
+	cluster.LeaderLossTolerance = 10 * time.Second
+	require.NoError(t, cluster.RollingRestart(client, ctx))
+
+	servers, err := cluster.Servers()
+	require.NoError(t, err)
+	require.Len(t, servers, numServers)
+	for _, server := range servers {
+		require.NotContains(t, originalServers, server)
+	}
+
+	libcluster.WaitForLeader(t, cluster, client)
+	libcluster.WaitForMembers(t, client, numServers+1)
+}
+
+func terminate(t *testing.T, cluster *libcluster.Cluster) {
+	err := cluster.Terminate()
+	require.NoError(t, err)
+}
@@ -0,0 +1,82 @@
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+)
+
+// TestSnapshotRestore Summary
+// This test makes sure the cluster state can be saved and restored with a snapshot.
+//
+// Steps:
+//   - Create a cluster with 3 servers
+//   - Register a service and write a KV entry
+//   - Take a snapshot
+//   - Deregister the service and wipe the KV store
+//   - Restore the snapshot
+//   - Make sure the service and the KV entry reappear
+func TestSnapshotRestore(t *testing.T) {
+	const numServers = 3
+
+	var configs []libagent.Config
+	for i := 0; i < numServers; i++ {
+		conf, err := libagent.NewConfigBuilder(nil).
+			Bootstrap(numServers).
+			RetryJoin(fmt.Sprintf("agent-%d", (i+1)%numServers)).
+			ToAgentConfig()
+		require.NoError(t, err)
+		configs = append(configs, *conf)
+	}
+
+	cluster, err := libcluster.New(configs)
+	require.NoError(t, err)
+	defer terminate(t, cluster)
+
+	client := cluster.Agents[0].GetClient()
+	libcluster.WaitForLeader(t, cluster, client)
+	libcluster.WaitForMembers(t, client, numServers)
+
+	reg := &api.CatalogRegistration{
+		Node:    "snapshot-node",
+		Address: "10.0.0.1",
+		Service: &api.AgentService{
+			Service: "snapshot-service",
+			Port:    8080,
+		},
+	}
+	_, err = client.Catalog().Register(reg, nil)
+	require.NoError(t, err)
+	libassert.CatalogServiceExists(t, client, "snapshot-service")
+
+	_, err = client.KV().Put(&api.KVPair{Key: "snapshot/key", Value: []byte("value")}, nil)
+	require.NoError(t, err)
+
+	snap, err := cluster.Snapshot()
+	require.NoError(t, err)
+	require.NotEmpty(t, snap)
+
+	_, err = client.Catalog().Deregister(&api.CatalogDeregistration{Node: "snapshot-node"}, nil)
+	require.NoError(t, err)
+	_, err = client.KV().DeleteTree("", nil)
+	require.NoError(t, err)
+
+	services, _, err := client.Catalog().Service("snapshot-service", "", nil)
+	require.NoError(t, err)
+	require.Empty(t, services)
+
+	require.NoError(t, cluster.Restore(snap))
+
+	libassert.CatalogServiceExists(t, client, "snapshot-service")
+
+	pair, _, err := client.KV().Get("snapshot/key", &api.QueryOptions{RequireConsistent: true})
+	require.NoError(t, err)
+	require.NotNil(t, pair)
+	require.Equal(t, []byte("value"), pair.Value)
+}
@@ -0,0 +1,39 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TestStandUp Summary
+// This test makes sure StandUp creates a cluster with the requested number of
+// servers and clients.
+//
+// Steps:
+//   - Stand up a cluster with 3 servers and 2 clients
+//   - Make sure every agent sees 3 alive servers and 2 alive clients
+func TestStandUp(t *testing.T) {
+	const (
+		numServers = 3
+		numClients = 2
+	)
+
+	cluster := libcluster.StandUp(t, *utils.TargetVersion, numServers, numClients)
+	defer terminate(t, cluster)
+
+	servers, err := cluster.Servers()
+	require.NoError(t, err)
+	require.Len(t, servers, numServers)
+	clients, err := cluster.Clients()
+	require.NoError(t, err)
+	require.Len(t, clients, numClients)
+
+	for _, n := range cluster.Agents {
+		libcluster.WaitForServers(t, n.GetClient(), numServers)
+		libcluster.WaitForClients(t, n.GetClient(), numClients)
+	}
+}
@@ -0,0 +1,62 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+)
+
+// TestWaitReady Summary
+// This test makes sure WaitReady only returns once the agents can serve
+// requests.
+//
+// Steps:
+//   - Create a cluster with 3 servers and 1 client
+//   - Wait for every server to be ready as a voter
+//   - Wait for the client to be ready with a leader
+//   - Make sure the agent API of every agent responds
+func TestWaitReady(t *testing.T) {
+	const numServers = 3
+
+	var configs []libagent.Config
+	for i := 0; i < numServers; i++ {
+		conf, err := libagent.NewConfigBuilder(nil).
+			Bootstrap(numServers).
+			RetryJoin(fmt.Sprintf("agent-%d", (i+1)%numServers)).
+			ToAgentConfig()
+		require.NoError(t, err)
+		configs = append(configs, *conf)
+	}
+	conf, err := libagent.NewConfigBuilder(nil).
+		Client().
+		RetryJoin("agent-0").
+		ToAgentConfig()
+	require.NoError(t, err)
+	configs = append(configs, *conf)
+
+	cluster, err := libcluster.New(configs)
+	require.NoError(t, err)
+	defer terminate(t, cluster)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	for _, n := range cluster.Agents {
+		opts := libagent.ReadyOptions{RequireLeader: true}
+		if n.IsServer() {
+			opts.RequireVoter = true
+		}
+		require.NoError(t, n.WaitReady(ctx, opts), "agent %s", n.GetNodeName())
+
+		self, err := n.GetClient().Agent().Self()
+		require.NoError(t, err)
+		require.Equal(t, n.GetNodeName(), self["Config"]["NodeName"])
+	}
+	libcluster.WaitForMembers(t, cluster.Agents[0].GetClient(), numServers+1)
+}
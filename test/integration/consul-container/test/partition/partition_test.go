@@ -0,0 +1,60 @@
+package partition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+)
+
+// TestPartitionServiceIsolation Summary
+// This test makes sure a service registered in a non-default admin partition is
+// not visible from the default partition.
+//
+// Steps:
+//   - Create a single agent cluster with an "ap1" admin partition
+//   - Register the example static-server in the "ap1" partition
+//   - Make sure the service is only returned when querying the "ap1" partition
+func TestPartitionServiceIsolation(t *testing.T) {
+	conf, err := libagent.NewConfigBuilder(nil).ToAgentConfig()
+	require.NoError(t, err)
+
+	cluster, err := libcluster.New([]libagent.Config{*conf})
+	require.NoError(t, err)
+	defer terminate(t, cluster)
+
+	node := cluster.Agents[0]
+	client := node.GetClient()
+	libcluster.WaitForLeader(t, cluster, client)
+
+	self, err := client.Agent().Self()
+	require.NoError(t, err)
+	if self["Config"]["VersionMetadata"] != "ent" {
+		t.Skip("admin partitions require Consul Enterprise")
+	}
+
+	require.NoError(t, cluster.CreatePartition("ap1"))
+
+	_, err = libservice.CreateAndRegisterStaticServer(node, "ap1")
+	require.NoError(t, err)
+
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		services, _, err := client.Catalog().Service("static-server", "", &api.QueryOptions{Partition: "ap1"})
+		require.NoError(r, err)
+		require.Len(r, services, 1)
+	})
+
+	services, _, err := client.Catalog().Service("static-server", "", &api.QueryOptions{Partition: "default"})
+	require.NoError(t, err)
+	require.Empty(t, services)
+}
+
+func terminate(t *testing.T, cluster *libcluster.Cluster) {
+	err := cluster.Terminate()
+	require.NoError(t, err)
+}
@@ -0,0 +1,67 @@
+package peering
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TestPeering_MeshGateways
+// This test verifies that two clusters can peer through their mesh gateways,
+// with both the peering control plane and the service traffic going through
+// the gateways.
+//
+// ## Steps
+//   - Create an accepting cluster with 1 server and a client hosting an exported service
+//   - Create a single agent dialing cluster
+//   - Both setups add a mesh gateway and wait for it to be healthy
+//   - Establish the peering in the mesh-gateways mode and verify it becomes active
+//   - Verify the dialing cluster imports the service and can reach it
+func TestPeering_MeshGateways(t *testing.T) {
+	var (
+		wg                   sync.WaitGroup
+		acceptingCluster     *libcluster.Cluster
+		acceptingClient      *api.Client
+		dialingCluster       *libcluster.Cluster
+		dialingClient        *api.Client
+		clientSidecarService libservice.Service
+	)
+
+	wg.Add(1)
+	go func() {
+		acceptingCluster, acceptingClient, _ = libcluster.CreatingAcceptingClusterAndSetup(t, 1, *utils.TargetVersion, acceptingPeerName)
+		wg.Done()
+	}()
+	defer func() {
+		terminate(t, acceptingCluster)
+	}()
+
+	wg.Add(1)
+	go func() {
+		dialingCluster, dialingClient, clientSidecarService = libcluster.CreateDialingClusterAndSetup(t, *utils.TargetVersion, dialingPeerName)
+		wg.Done()
+	}()
+	defer func() {
+		terminate(t, dialingCluster)
+	}()
+
+	wg.Wait()
+
+	err := dialingCluster.PeerWithClusterMode(acceptingClient, acceptingPeerName, dialingPeerName, libcluster.PeeringModeMeshGateways)
+	require.NoError(t, err)
+
+	libassert.PeeringStatus(t, acceptingClient, acceptingPeerName, api.PeeringStateActive)
+	libassert.PeeringExports(t, acceptingClient, acceptingPeerName, 1)
+	libassert.PeeringImports(t, dialingClient, dialingPeerName, 1)
+	libassert.PeeredServiceImported(t, dialingClient, "static-server", dialingPeerName, 1)
+
+	_, port := clientSidecarService.GetAddr()
+	libassert.HTTPServiceEchoes(t, "localhost", port)
+}
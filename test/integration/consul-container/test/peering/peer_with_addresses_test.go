@@ -0,0 +1,81 @@
+package peering
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TestPeering_PeerWithWrongServerAddress
+// This test verifies that the server addresses the dialing cluster learns over the
+// peering stream supersede the initial addresses it was given.
+//
+// ## Steps
+//   - Create an accepting cluster with 1 server and a dialing cluster with a single agent
+//   - Establish the peering with a deliberately unreachable address in front of
+//     the accepting server's real address
+//   - Wait for the stream to replace the dialer's peer server addresses with the
+//     ones advertised by the accepting cluster
+//   - Verify the exported service is reachable from the dialing cluster
+func TestPeering_PeerWithWrongServerAddress(t *testing.T) {
+	const bogusAddress = "192.0.2.1:8503" // TEST-NET-1, never routable
+
+	var acceptingCluster, dialingCluster *libcluster.Cluster
+	var acceptingClient, dialingClient *api.Client
+	var clientSidecarService libservice.Service
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		acceptingCluster, acceptingClient, _ = libcluster.CreatingAcceptingClusterAndSetup(t, 1, *utils.TargetVersion, acceptingPeerName)
+		wg.Done()
+	}()
+	defer func() {
+		terminate(t, acceptingCluster)
+	}()
+
+	wg.Add(1)
+	go func() {
+		dialingCluster, dialingClient, clientSidecarService = libcluster.CreateDialingClusterAndSetup(t, *utils.TargetVersion, dialingPeerName)
+		wg.Done()
+	}()
+	defer func() {
+		terminate(t, dialingCluster)
+	}()
+
+	wg.Wait()
+
+	servers, err := acceptingCluster.Servers()
+	require.NoError(t, err)
+	require.Len(t, servers, 1)
+	serverIP, _ := servers[0].GetAddr()
+	serverAddress := fmt.Sprintf("%s:8503", serverIP)
+
+	err = dialingCluster.PeerWithClusterUsingAddresses(acceptingClient, acceptingPeerName, dialingPeerName, []string{bogusAddress, serverAddress})
+	require.NoError(t, err)
+
+	libassert.PeeringStatus(t, acceptingClient, acceptingPeerName, api.PeeringStateActive)
+	libassert.PeeringExports(t, acceptingClient, acceptingPeerName, 1)
+
+	retry.RunWith(libcluster.LongFailer(), t, func(r *retry.R) {
+		peering, _, err := dialingClient.Peerings().Read(context.Background(), dialingPeerName, &api.QueryOptions{})
+		require.NoError(r, err)
+		require.NotNil(r, peering)
+		require.NotContains(r, peering.PeerServerAddresses, bogusAddress)
+		require.Equal(r, []string{serverAddress}, peering.PeerServerAddresses)
+	})
+
+	_, port := clientSidecarService.GetAddr()
+	libassert.HTTPServiceEchoes(t, "localhost", port)
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/hashicorp/consul/api"
-	"github.com/hashicorp/consul/sdk/testutil/retry"
 	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
 	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
 	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
@@ -82,6 +81,8 @@ func TestPeering_RotateServerAndCAThenFail_(t *testing.T) {
 
 	libassert.PeeringStatus(t, acceptingClient, acceptingPeerName, api.PeeringStateActive)
 	libassert.PeeringExports(t, acceptingClient, acceptingPeerName, 1)
+	libassert.PeeringImports(t, dialingClient, dialingPeerName, 1)
+	libassert.PeeredServiceImported(t, dialingClient, "static-server", dialingPeerName, 1)
 
 	_, port := clientSidecarService.GetAddr()
 	libassert.HTTPServiceEchoes(t, "localhost", port)
@@ -115,9 +116,6 @@ func TestPeering_RotateServerAndCAThenFail_(t *testing.T) {
 		peeringBefore, peerMeta, err := dialingClient.Peerings().Read(context.Background(), dialingPeerName, &api.QueryOptions{})
 		require.NoError(t, err)
 
-		_, caMeta, err := acceptingClient.Connect().CAGetConfig(&api.QueryOptions{})
-		require.NoError(t, err)
-
 		// There should be one root cert
 		rootList, _, err := acceptingClient.Connect().CARoots(&api.QueryOptions{})
 		require.NoError(t, err)
@@ -133,12 +131,14 @@ func TestPeering_RotateServerAndCAThenFail_(t *testing.T) {
 		_, err = acceptingClient.Connect().CASetConfig(req, &api.WriteOptions{})
 		require.NoError(t, err)
 
-		// wait up to 30 seconds for the update
-		_, _, err = acceptingClient.Connect().CAGetConfig(&api.QueryOptions{
-			WaitIndex: caMeta.LastIndex,
-			WaitTime:  30 * time.Second,
-		})
+		// wait up to 30 seconds for the new root to become active, the old
+		// root is kept around so there should be two root certs now on the
+		// accepting side
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		rootList, err = acceptingClient.Connect().WaitForCARoots(ctx, 2)
 		require.NoError(t, err)
+		require.Len(t, rootList.Roots, 2)
 
 		// The peering object should reflect the update
 		peeringAfter, _, err := dialingClient.Peerings().Read(context.Background(), dialingPeerName, &api.QueryOptions{
@@ -149,11 +149,6 @@ func TestPeering_RotateServerAndCAThenFail_(t *testing.T) {
 		require.Len(t, peeringAfter.PeerCAPems, 2)
 		require.NoError(t, err)
 
-		// There should be two root certs now on the accepting side
-		rootList, _, err = acceptingClient.Connect().CARoots(&api.QueryOptions{})
-		require.NoError(t, err)
-		require.Len(t, rootList.Roots, 2)
-
 		// Connectivity should still be contained
 		_, port := clientSidecarService.GetAddr()
 		libassert.HTTPServiceEchoes(t, "localhost", port)
@@ -212,39 +207,23 @@ func verifySidecarHasTwoRootCAs(t *testing.T, sidecar libservice.Service) {
 	require.True(t, ok)
 	_, adminPort := connectContainer.GetAdminAddr()
 
-	failer := func() *retry.Timer {
-		return &retry.Timer{Timeout: 30 * time.Second, Wait: 1 * time.Second}
-	}
-
-	retry.RunWith(failer(), t, func(r *retry.R) {
-		dump, err := libservice.GetEnvoyConfigDump(adminPort)
-		if err != nil {
-			r.Fatal("could not curl envoy configuration")
-		}
-
-		// Make sure there are two certs in the sidecar
-		filter := `.configs[] | select(.["@type"] | contains("type.googleapis.com/envoy.admin.v3.ClustersConfigDump")).dynamic_active_clusters[] | select(.cluster.name | contains("static-server.default.dialing-to-acceptor.external")).cluster.transport_socket.typed_config.common_tls_context.validation_context.trusted_ca.inline_string`
-		results, err := utils.JQFilter(dump, filter)
-		if err != nil {
-			r.Fatal("could not parse envoy configuration")
-		}
-		if len(results) != 1 {
-			r.Fatal("could not find certificates in cluster TLS context")
-		}
-
-		rest := []byte(results[0])
-		var count int
-		for len(rest) > 0 {
-			var p *pem.Block
-			p, rest = pem.Decode(rest)
-			if p == nil {
-				break
-			}
-			count++
-		}
+	// Make sure there are two certs in the sidecar
+	filter := `.configs[] | select(.["@type"] | contains("type.googleapis.com/envoy.admin.v3.ClustersConfigDump")).dynamic_active_clusters[] | select(.cluster.name | contains("static-server.default.dialing-to-acceptor.external")).cluster.transport_socket.typed_config.common_tls_context.validation_context.trusted_ca.inline_string`
+	utils.RetryEnvoyConfigDump(t, adminPort, filter, func(results []string) bool {
+		return len(results) == 1 && countPEMBlocks(results[0]) == 2
+	}, 30*time.Second)
+}
 
-		if count != 2 {
-			r.Fatalf("expected 2 TLS certificates and %d present", count)
+func countPEMBlocks(s string) int {
+	rest := []byte(s)
+	var count int
+	for len(rest) > 0 {
+		var p *pem.Block
+		p, rest = pem.Decode(rest)
+		if p == nil {
+			break
 		}
-	})
+		count++
+	}
+	return count
 }
@@ -0,0 +1,74 @@
+package peering
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TestPeering_SharedNetwork
+// This test verifies that the accepting and dialing clusters can share a
+// caller-provided docker network.
+//
+// ## Steps
+//   - Create a named docker network
+//   - Create an accepting and a dialing cluster on that network
+//   - Verify an accepting agent can reach a dialing agent by container name
+//   - Establish the peering and verify it becomes active
+func TestPeering_SharedNetwork(t *testing.T) {
+	var (
+		acceptingPeerName = "accepting-to-dialer"
+		dialingPeerName   = "dialing-to-acceptor"
+		networkName       = utils.RandName("consul-int-shared")
+	)
+
+	network, err := libcluster.NewNetwork(networkName)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, network.Remove(context.Background()))
+	}()
+
+	var (
+		wg               sync.WaitGroup
+		acceptingCluster *libcluster.Cluster
+		acceptingClient  *api.Client
+		dialingCluster   *libcluster.Cluster
+	)
+
+	wg.Add(1)
+	go func() {
+		acceptingCluster, acceptingClient, _ = libcluster.CreatingAcceptingClusterAndSetupOnNetwork(t, 1, *utils.TargetVersion, acceptingPeerName, networkName)
+		wg.Done()
+	}()
+
+	wg.Add(1)
+	go func() {
+		dialingCluster, _, _ = libcluster.CreateDialingClusterAndSetupOnNetwork(t, *utils.TargetVersion, dialingPeerName, networkName)
+		wg.Done()
+	}()
+	wg.Wait()
+
+	// Terminate the clusters before the network is removed.
+	defer terminate(t, acceptingCluster)
+	defer terminate(t, dialingCluster)
+
+	require.Equal(t, networkName, acceptingCluster.NetworkName)
+	require.Equal(t, networkName, dialingCluster.NetworkName)
+
+	// The network namespace of an agent is owned by its pod container, which
+	// is named after the node.
+	dialingPod := dialingCluster.Agents[0].GetNodeName() + "-pod"
+	exitCode, err := acceptingCluster.Agents[0].Exec(context.Background(), []string{"ping", "-c", "1", dialingPod})
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode, "could not ping %s from the accepting cluster", dialingPod)
+
+	require.NoError(t, dialingCluster.PeerWithCluster(acceptingClient, acceptingPeerName, dialingPeerName))
+	libassert.PeeringStatus(t, acceptingClient, acceptingPeerName, api.PeeringStateActive)
+}
@@ -0,0 +1,72 @@
+package peering
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	libassert "github.com/hashicorp/consul/test/integration/consul-container/libs/assert"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	libservice "github.com/hashicorp/consul/test/integration/consul-container/libs/service"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TestPeering_UpstreamSNI
+// This test verifies that the sidecar of the dialing cluster dials an upstream
+// imported from the accepting cluster with the SNI the accepting cluster
+// exported it with, which names the peer it is exported to.
+//
+// ## Steps
+//   - Create an accepting cluster with 1 server and a dialing cluster with a single agent
+//   - Create the peering and export the static-server service
+//   - Verify the client sidecar cluster of the imported static-server uses the
+//     SNI of the service exported to the accepting cluster's peer name, under
+//     the trust domain of the accepting cluster
+func TestPeering_UpstreamSNI(t *testing.T) {
+	var acceptingCluster, dialingCluster *libcluster.Cluster
+	var acceptingClient *api.Client
+	var clientSidecarService libservice.Service
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		acceptingCluster, acceptingClient, _ = libcluster.CreatingAcceptingClusterAndSetup(t, 1, *utils.TargetVersion, acceptingPeerName)
+		wg.Done()
+	}()
+	defer func() {
+		terminate(t, acceptingCluster)
+	}()
+
+	wg.Add(1)
+	go func() {
+		dialingCluster, _, clientSidecarService = libcluster.CreateDialingClusterAndSetup(t, *utils.TargetVersion, dialingPeerName)
+		wg.Done()
+	}()
+	defer func() {
+		terminate(t, dialingCluster)
+	}()
+
+	wg.Wait()
+
+	require.NoError(t, dialingCluster.PeerWithCluster(acceptingClient, acceptingPeerName, dialingPeerName))
+
+	libassert.PeeringStatus(t, acceptingClient, acceptingPeerName, api.PeeringStateActive)
+	libassert.PeeringExports(t, acceptingClient, acceptingPeerName, 1)
+
+	_, port := clientSidecarService.GetAddr()
+	libassert.HTTPServiceEchoes(t, "localhost", port)
+
+	roots, _, err := acceptingClient.Agent().ConnectCARoots(nil)
+	require.NoError(t, err)
+
+	connectContainer, ok := clientSidecarService.(*libservice.ConnectContainer)
+	require.True(t, ok)
+	_, adminPort := connectContainer.GetAdminAddr()
+
+	wantSNI := fmt.Sprintf("static-server.default.default.%s.external.%s", acceptingPeerName, roots.TrustDomain)
+	libassert.EnvoyClusterSNI(t, adminPort, "static-server.default."+dialingPeerName+".external", wantSNI)
+}
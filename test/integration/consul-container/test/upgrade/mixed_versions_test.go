@@ -0,0 +1,49 @@
+package upgrade
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TestMixedVersionServers verifies servers running different Consul versions
+// form a single cluster.
+//
+// Steps:
+//   - Create a 3 server cluster with one server on the latest GA version and
+//     the others on the target version
+//   - Verify the servers elect a leader and all join the cluster
+func TestMixedVersionServers(t *testing.T) {
+	const numServers = 3
+
+	buildCtx, err := libagent.NewBuildContext(libagent.BuildOptions{
+		ConsulVersion: *utils.TargetVersion,
+	})
+	require.NoError(t, err)
+
+	var configs []libagent.Config
+	for i := 0; i < numServers; i++ {
+		builder := libagent.NewConfigBuilder(buildCtx).Bootstrap(numServers)
+		if i == 0 {
+			builder = builder.Image(*utils.LatestImage).Version(*utils.LatestVersion)
+		}
+
+		conf, err := builder.ToAgentConfig()
+		require.NoError(t, err)
+		t.Logf("Server %d config (%s:%s):\n%s", i, conf.Image, conf.Version, conf.JSON)
+
+		configs = append(configs, *conf)
+	}
+
+	cluster, err := libcluster.New(configs)
+	require.NoError(t, err)
+	defer terminate(t, cluster)
+
+	client := cluster.Agents[0].GetClient()
+	libcluster.WaitForLeader(t, cluster, client)
+	libcluster.WaitForMembers(t, client, numServers)
+}
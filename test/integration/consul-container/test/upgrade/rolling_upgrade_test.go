@@ -0,0 +1,101 @@
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+	libagent "github.com/hashicorp/consul/test/integration/consul-container/libs/agent"
+	libcluster "github.com/hashicorp/consul/test/integration/consul-container/libs/cluster"
+	"github.com/hashicorp/consul/test/integration/consul-container/libs/utils"
+)
+
+// TestRollingUpgrade Summary
+// This test makes sure Cluster.Upgrade upgrades every agent in place while the
+// services of the cluster stay reachable.
+//
+// Steps:
+//   - Stand up a cluster with 3 servers and 1 client on the latest GA version
+//   - Register a service in the catalog
+//   - Look up the service through any reachable agent in the background
+//   - Upgrade the cluster to the target version
+//   - Make sure every lookup found the service
+func TestRollingUpgrade(t *testing.T) {
+	const (
+		numServers  = 3
+		numClients  = 1
+		serviceName = "api"
+	)
+
+	cluster := libcluster.StandUp(t, *utils.LatestVersion, numServers, numClients,
+		libcluster.WithAgentConfig(func(b *libagent.Builder) {
+			b.Image(*utils.LatestImage)
+		}))
+	defer terminate(t, cluster)
+
+	err := cluster.RegisterCatalogService(&api.CatalogRegistration{
+		Node:    "external-node",
+		Address: "10.0.0.1",
+		Service: &api.AgentService{
+			Service: serviceName,
+			Port:    8080,
+		},
+	})
+	require.NoError(t, err)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failures []error
+	)
+	stopCh := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(time.Second):
+			}
+
+			if err := lookupService(cluster, serviceName); err != nil {
+				mu.Lock()
+				failures = append(failures, err)
+				mu.Unlock()
+			}
+		}
+	}()
+
+	err = cluster.Upgrade(context.Background(), *utils.TargetVersion)
+	close(stopCh)
+	wg.Wait()
+	require.NoError(t, err)
+
+	require.Empty(t, failures, "service was unreachable during the upgrade")
+	require.NoError(t, lookupService(cluster, serviceName))
+}
+
+// lookupService finds the service in the catalog through the first agent that
+// answers. Stale reads are allowed so that the lookup doesn't depend on the
+// leader, which is unavailable while it is upgraded.
+func lookupService(cluster *libcluster.Cluster, serviceName string) error {
+	var lastErr error
+	for _, n := range cluster.Agents {
+		services, _, err := n.GetClient().Catalog().Service(serviceName, "", &api.QueryOptions{AllowStale: true})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(services) != 1 {
+			return fmt.Errorf("found %d instances of %s", len(services), serviceName)
+		}
+		return nil
+	}
+	return fmt.Errorf("no agent could look up %s: %w", serviceName, lastErr)
+}